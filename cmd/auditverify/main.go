@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"webserver/internal/app/apiservser"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	configPath string
+)
+
+func init() {
+	flag.StringVar(&configPath, "config-path", "configs/apiserver.toml", "path to config")
+}
+
+func main() {
+	flag.Parse()
+
+	config := apiserver.NewConfig()
+
+	_, err := toml.DecodeFile(configPath, config)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	n, err := apiserver.VerifyAuditLog(config)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("audit log intact, %d events verified\n", n)
+}
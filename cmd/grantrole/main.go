@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"webserver/internal/app/apiservser"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	configPath string
+	email      string
+	role       string
+)
+
+func init() {
+	flag.StringVar(&configPath, "config-path", "configs/apiserver.toml", "path to config")
+	flag.StringVar(&email, "email", "", "email address of the user")
+	flag.StringVar(&role, "role", "admin", "name of the role to assign")
+}
+
+func main() {
+	flag.Parse()
+
+	if email == "" {
+		log.Fatal("-email is required")
+	}
+
+	config := apiserver.NewConfig()
+
+	_, err := toml.DecodeFile(configPath, config)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := apiserver.GrantRole(config, email, role); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("assigned role %s to %s\n", role, email)
+}
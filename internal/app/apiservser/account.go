@@ -0,0 +1,172 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+)
+
+var (
+	errorIncorrectPassword        = errors.New("incorrect password")
+	errorReauthenticationRequired = errors.New("log in again or confirm your password")
+)
+
+// handlePasswordUpdate changes the password of the current user. All other
+// sessions and refresh tokens of the user are revoked.
+func (s *server) handlePasswordUpdate() http.HandlerFunc {
+
+	type request struct {
+		CurrentPassword string `json:"current_password"`
+		Password        string `json:"password"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		if !u.ComparePassword(req.CurrentPassword) {
+			s.error(rw, r, http.StatusForbidden, errorIncorrectPassword)
+			return
+		}
+
+		u.Password = req.Password
+
+		if err := s.store.User().UpdatePassword(u); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.revokeCredentials(u.ID, currentSessionID(r)); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditPasswordChanged, u.ID, u.ID, nil)
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleEmailUpdate sends a verification token to the new address. The
+// address of the user only changes once that token is redeemed through
+// POST /users/verify.
+func (s *server) handleEmailUpdate() http.HandlerFunc {
+
+	type request struct {
+		Password string `json:"password"`
+		Email    string `json:"email"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		if !u.ComparePassword(req.Password) {
+			s.error(rw, r, http.StatusForbidden, errorIncorrectPassword)
+			return
+		}
+
+		if err := validation.Validate(req.Email, validation.Required, is.Email); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		_, err := s.store.User().FindByEmail(req.Email)
+
+		if err == nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, errorEmailTaken)
+			return
+		}
+
+		if err != store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.sendEmailVerification(u, req.Email); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusAccepted, nil)
+	}
+}
+
+// handleAccountDelete closes the account of the current user. The user has to
+// confirm their password, or to have logged in recently, which is the only
+// way for users without a local password. The user is soft-deleted, so that
+// it can still be restored, and all of its sessions and refresh tokens are
+// revoked.
+func (s *server) handleAccountDelete() http.HandlerFunc {
+
+	type request struct {
+		Password string `json:"password"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		// Without a password the request has no fields, so it may come
+		// without a body at all.
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		if req.Password != "" && !u.ComparePassword(req.Password) {
+			s.error(rw, r, http.StatusForbidden, errorIncorrectPassword)
+			return
+		}
+
+		if req.Password == "" && !s.recentlyAuthenticated(r) {
+			s.error(rw, r, http.StatusForbidden, errorReauthenticationRequired)
+			return
+		}
+
+		if err := s.store.User().SoftDelete(u.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.revokeCredentials(u.ID, ""); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.clearSession(rw, r); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// recentlyAuthenticated reports whether r is authenticated with a session
+// that was started within the reauthentication TTL.
+func (s *server) recentlyAuthenticated(r *http.Request) bool {
+	sess, ok := r.Context().Value(contextKeySession).(*model.Session)
+
+	return ok && time.Since(sess.CreatedAt) < s.config.ReauthenticationTTL.Duration
+}
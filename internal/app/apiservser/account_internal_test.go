@@ -0,0 +1,276 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandlePasswordUpdate(t *testing.T) {
+
+	u := model.TestUser(t)
+	password := u.Password
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, password)
+	otherCookie := testLogin(t, srv, u.Email, password)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "incorrect current password",
+			payload: map[string]string{
+				"current_password": "invalid",
+				"password":         "new-password",
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "invalid new password",
+			payload: map[string]string{
+				"current_password": password,
+				"password":         "short",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "valid",
+			payload: map[string]string{
+				"current_password": password,
+				"password":         "new-password",
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPut, "/private/password", b)
+			req.Header.Set("Cookie", cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, cookie))
+	assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, otherCookie))
+	testLogin(t, srv, u.Email, "new-password")
+}
+
+func Test_HandleEmailUpdate(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	other := model.TestUser(t)
+	other.Email = "taken@example.org"
+
+	store := teststore.New()
+
+	store.User().Create(u)
+	store.User().Create(other)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "incorrect password",
+			payload: map[string]string{
+				"password": "invalid",
+				"email":    "new@example.org",
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "invalid email",
+			payload: map[string]string{
+				"password": u.Password,
+				"email":    "invalid",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "taken email",
+			payload: map[string]string{
+				"password": u.Password,
+				"email":    other.Email,
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "valid",
+			payload: map[string]string{
+				"password": u.Password,
+				"email":    "new@example.org",
+			},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPut, "/private/email", b)
+			req.Header.Set("Cookie", cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	found, _ := store.User().Find(u.ID)
+	assert.Equal(t, "e@gmail.com", found.Email)
+
+	msg := m.Last("new@example.org")
+	assert.NotNil(t, msg)
+
+	verify := func() int {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{"token": testMailToken(msg)})
+		req, _ := http.NewRequest(http.MethodPost, "/users/verify", b)
+		srv.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, verify())
+
+	found, _ = store.User().Find(u.ID)
+	assert.Equal(t, "new@example.org", found.Email)
+	assert.True(t, found.IsEmailVerified())
+
+	assert.Equal(t, http.StatusBadRequest, verify())
+}
+
+func Test_HandleAccountDelete(t *testing.T) {
+
+	u := model.TestUser(t)
+	password := u.Password
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, password)
+	otherCookie := testLogin(t, srv, u.Email, password)
+
+	// Both logins are too old to close the account without the password.
+	old, _ := store.Session().FindByUser(u.ID)
+
+	for _, sess := range old {
+		sess.CreatedAt = time.Now().Add(-time.Hour)
+	}
+
+	recentCookie := testLogin(t, srv, u.Email, password)
+
+	testCases := []struct {
+		name         string
+		cookie       string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name:         "invalid payload",
+			cookie:       cookie,
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "incorrect password",
+			cookie: recentCookie,
+			payload: map[string]string{
+				"password": "invalid",
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "no password, old login",
+			cookie:       cookie,
+			payload:      map[string]string{},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:   "valid password",
+			cookie: cookie,
+			payload: map[string]string{
+				"password": password,
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodDelete, "/private/account", b)
+			req.Header.Set("Cookie", tc.cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, cookie))
+	assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, otherCookie))
+	assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, recentCookie))
+
+	_, err := store.User().FindByEmail(u.Email)
+	assert.Error(t, err)
+
+	assert.NoError(t, store.User().Restore(u.ID))
+
+	t.Run("recent login without password", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/private/account", strings.NewReader("{}"))
+		req.Header.Set("Cookie", testLogin(t, srv, u.Email, password))
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	assert.NoError(t, store.User().Restore(u.ID))
+
+	t.Run("recent login without a body", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/private/account", http.NoBody)
+		req.Header.Set("Cookie", testLogin(t, srv, u.Email, password))
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+}
@@ -0,0 +1,98 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+// handleAdminUserList returns a page of users. The query parameters email,
+// created_after, created_before, sort, order, limit and cursor map onto
+// store.ListOptions.
+func (s *server) handleAdminUserList() http.HandlerFunc {
+
+	type response struct {
+		Users      []*model.User `json:"users"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+
+		if err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		users, next, err := s.store.User().List(r.Context(), *opts)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, u := range users {
+			u.Sanitize()
+		}
+
+		res := &response{Users: users}
+
+		if next != nil {
+			res.NextCursor = next.Encode()
+		}
+
+		s.respond(rw, r, http.StatusOK, res)
+	}
+}
+
+func parseListOptions(r *http.Request) (*store.ListOptions, error) {
+	q := r.URL.Query()
+
+	opts := &store.ListOptions{
+		Email: q.Get("email"),
+		Sort:  q.Get("sort"),
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return nil, errors.New("order must be asc or desc")
+	}
+
+	var err error
+
+	if v := q.Get("created_after"); v != "" {
+		if opts.CreatedAfter, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("created_before"); v != "" {
+		if opts.CreatedBefore, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if opts.Limit, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		if opts.Cursor, err = store.DecodeCursor(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := opts.Normalize(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,174 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleAdminUserList(t *testing.T) {
+
+	admin := model.TestUser(t)
+	admin.Email = "admin@example.org"
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(admin)
+	store.User().Create(u)
+
+	for i := 0; i < 3; i++ {
+		other := model.TestUser(t)
+		other.Email = fmt.Sprintf("user%d@example.org", i)
+		store.User().Create(other)
+	}
+
+	testGrant(t, store, admin.ID, model.PermissionUsersRead)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	adminCookie := testLogin(t, srv, admin.Email, "password")
+	userCookie := testLogin(t, srv, u.Email, "password")
+
+	type response struct {
+		Users      []*model.User `json:"users"`
+		NextCursor string        `json:"next_cursor"`
+	}
+
+	testCases := []struct {
+		name          string
+		cookie        string
+		query         string
+		expectedCode  int
+		expectedUsers int
+		hasNext       bool
+	}{
+		{
+			name:         "not authenticated",
+			query:        "",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "not admin",
+			cookie:       userCookie,
+			query:        "",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:          "all",
+			cookie:        adminCookie,
+			query:         "",
+			expectedCode:  http.StatusOK,
+			expectedUsers: 5,
+		},
+		{
+			name:          "limited",
+			cookie:        adminCookie,
+			query:         "?limit=2&sort=email&order=desc",
+			expectedCode:  http.StatusOK,
+			expectedUsers: 2,
+			hasNext:       true,
+		},
+		{
+			name:          "email filter",
+			cookie:        adminCookie,
+			query:         "?email=user",
+			expectedCode:  http.StatusOK,
+			expectedUsers: 3,
+		},
+		{
+			name:         "invalid sort",
+			cookie:       adminCookie,
+			query:        "?sort=password",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid cursor",
+			cookie:       adminCookie,
+			query:        "?cursor=invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid date",
+			cookie:       adminCookie,
+			query:        "?created_after=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/admin/users"+tc.query, nil)
+			req.Header.Set("Cookie", tc.cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			res := &response{}
+			json.NewDecoder(rec.Body).Decode(res)
+			assert.Len(t, res.Users, tc.expectedUsers)
+			assert.Equal(t, tc.hasNext, res.NextCursor != "")
+		})
+	}
+
+	emails := []string{}
+	cursor := ""
+
+	for {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/admin/users?limit=2&cursor="+cursor, nil)
+		req.Header.Set("Cookie", adminCookie)
+		srv.ServeHTTP(rec, req)
+
+		res := &response{}
+		json.NewDecoder(rec.Body).Decode(res)
+
+		for _, u := range res.Users {
+			emails = append(emails, u.Email)
+		}
+
+		if res.NextCursor == "" {
+			break
+		}
+
+		cursor = res.NextCursor
+	}
+
+	assert.Equal(t, []string{
+		"admin@example.org",
+		"e@gmail.com",
+		"user0@example.org",
+		"user1@example.org",
+		"user2@example.org",
+	}, emails)
+}
+
+// testGrant assigns a new role granting permissions to the user.
+func testGrant(t *testing.T, store *teststore.Store, userID int, permissions ...string) {
+	t.Helper()
+
+	role := &model.Role{
+		Name:        fmt.Sprintf("role%d", userID),
+		Permissions: permissions,
+	}
+
+	if err := store.Role().Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Role().Assign(userID, role.ID); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,128 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+// apiTokenTouchInterval is how long the recorded last use of a token may lag
+// behind, so that not every request made with it writes to the store.
+const apiTokenTouchInterval = time.Minute
+
+func (s *server) handleAPITokenCreate() http.HandlerFunc {
+
+	type request struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		// Credentials restricted to scopes only create tokens restricted to
+		// some of those scopes, as a token without scopes has full access.
+		if scopes, ok := r.Context().Value(contextKeyScopes).([]string); ok {
+			if len(req.Scopes) == 0 || !model.HasScopes(scopes, req.Scopes) {
+				s.error(rw, r, http.StatusForbidden, errorInsufficientScope)
+				return
+			}
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		t := &model.APIToken{
+			UserID:    u.ID,
+			Name:      req.Name,
+			Scopes:    req.Scopes,
+			ExpiresAt: req.ExpiresAt,
+		}
+
+		if err := s.store.APIToken().Create(t); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusCreated, t)
+	}
+}
+
+func (s *server) handleAPITokenList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		tokens, err := s.store.APIToken().FindByUser(u.ID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, t := range tokens {
+			t.Sanitize()
+		}
+
+		s.respond(rw, r, http.StatusOK, tokens)
+	}
+}
+
+func (s *server) handleAPITokenDelete() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		t, err := s.store.APIToken().Find(id)
+
+		if err != nil || t.UserID != u.ID {
+			s.error(rw, r, http.StatusNotFound, store.ErrorRecordNotFound)
+			return
+		}
+
+		if err := s.store.APIToken().Delete(t.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// authenticateAPIToken resolves the owner of a personal access token and
+// records that the token has been used, unless it has been recorded within
+// apiTokenTouchInterval.
+func (s *server) authenticateAPIToken(token string) (*model.User, []string, error) {
+	t, err := s.store.APIToken().FindByToken(token)
+
+	if err != nil || t.IsExpired() {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	u, err := s.store.User().Find(t.UserID)
+
+	if err != nil {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	if t.LastUsedAt == nil || time.Since(*t.LastUsedAt) > apiTokenTouchInterval {
+		if err := s.store.APIToken().Touch(t.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(t.Scopes) == 0 {
+		return u, nil, nil
+	}
+
+	return u, t.Scopes, nil
+}
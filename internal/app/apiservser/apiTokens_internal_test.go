@@ -0,0 +1,289 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleAPITokenCreate(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "valid",
+			payload: map[string]interface{}{
+				"name":   "ci",
+				"scopes": []string{model.ScopeUserRead},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown scope",
+			payload: map[string]interface{}{
+				"name":   "ci",
+				"scopes": []string{"admin"},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "expired",
+			payload: map[string]interface{}{
+				"name":       "ci",
+				"expires_at": time.Now().Add(-time.Hour),
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/private/tokens", b)
+			req.Header.Set("Cookie", cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func Test_HandleAPITokenCreateWithScopedToken(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	scoped := model.TestAPIToken(t, u.ID)
+	scoped.Scopes = []string{model.ScopeTokensWrite, model.ScopeUserRead}
+	store.APIToken().Create(scoped)
+
+	testCases := []struct {
+		name         string
+		scopes       []string
+		expectedCode int
+	}{
+		{
+			name:         "subset of scopes",
+			scopes:       []string{model.ScopeUserRead},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "same scopes",
+			scopes:       []string{model.ScopeTokensWrite, model.ScopeUserRead},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "without scopes",
+			scopes:       nil,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "scope not held",
+			scopes:       []string{model.ScopeUserRead, model.ScopeAdminWrite},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "unknown scope",
+			scopes:       []string{"admin"},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(map[string]interface{}{
+				"name":   "ci",
+				"scopes": tc.scopes,
+			})
+			req, _ := http.NewRequest(http.MethodPost, "/private/tokens", b)
+			req.Header.Set("Authorization", "Bearer "+scoped.Token)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func Test_APITokenAuthentication(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	full := model.TestAPIToken(t, u.ID)
+	store.APIToken().Create(full)
+
+	restricted := model.TestAPIToken(t, u.ID)
+	restricted.Scopes = []string{model.ScopeUserRead}
+	store.APIToken().Create(restricted)
+
+	expired := model.TestAPIToken(t, u.ID)
+	store.APIToken().Create(expired)
+	past := time.Now().Add(-time.Hour)
+	expired.ExpiresAt = &past
+
+	testCases := []struct {
+		name         string
+		token        string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "unrestricted",
+			token:        full.Token,
+			path:         "/private/tokens",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "within scope",
+			token:        restricted.Token,
+			path:         "/private/whoami",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "outside of scope",
+			token:        restricted.Token,
+			path:         "/private/tokens",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "expired",
+			token:        expired.Token,
+			path:         "/private/whoami",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "unknown",
+			token:        model.APITokenPrefix + "unknown",
+			path:         "/private/whoami",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	found, _ := store.APIToken().Find(restricted.ID)
+	assert.NotNil(t, found.LastUsedAt)
+
+	used := model.TestAPIToken(t, u.ID)
+	store.APIToken().Create(used)
+
+	whoAmI := func() {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+		req.Header.Set("Authorization", "Bearer "+used.Token)
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	recent := time.Now().Add(-time.Second)
+	used.LastUsedAt = &recent
+	whoAmI()
+
+	found, _ = store.APIToken().Find(used.ID)
+	assert.Equal(t, recent, *found.LastUsedAt)
+
+	stale := time.Now().Add(-time.Hour)
+	used.LastUsedAt = &stale
+	whoAmI()
+
+	found, _ = store.APIToken().Find(used.ID)
+	assert.True(t, found.LastUsedAt.After(stale))
+}
+
+func Test_HandleAPITokenDelete(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+
+	own := model.TestAPIToken(t, u.ID)
+	store.APIToken().Create(own)
+
+	foreign := model.TestAPIToken(t, u.ID+1)
+	store.APIToken().Create(foreign)
+
+	testCases := []struct {
+		name         string
+		id           int
+		expectedCode int
+	}{
+		{
+			name:         "own token",
+			id:           own.ID,
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "already deleted",
+			id:           own.ID,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "token of another user",
+			id:           foreign.ID,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/private/tokens/%d", tc.id), nil)
+			req.Header.Set("Cookie", cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
@@ -1,11 +1,24 @@
 package apiserver
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
 	"webserver/internal/app/store/sqlstore"
+	"webserver/internal/app/throttle"
 
 	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func Start(config *Config) error {
@@ -19,11 +32,207 @@ func Start(config *Config) error {
 
 	store := sqlstore.New(db)
 
-	sessionStore := sessions.NewCookieStore([]byte(config.SessionKey))
+	hasher, err := newPasswordHasher(config)
 
-	srv := newServer(store, sessionStore)
+	if err != nil {
+		return err
+	}
+
+	model.SetPasswordHasher(hasher)
+	model.SetAuditKey(newAuditKey(config))
+
+	if config.JWTSecret == "" {
+		config.JWTSecret = config.SessionKey
+	}
+
+	if _, err := logrus.ParseLevel(config.LogLevel); err != nil {
+		return err
+	}
+
+	if config.LogFormat != logFormatText && config.LogFormat != logFormatJSON {
+		return fmt.Errorf("unknown log format %q", config.LogFormat)
+	}
+
+	if config.SessionCleanupInterval.Duration <= 0 {
+		return errors.New("session cleanup interval must be positive")
+	}
+
+	if config.ThrottleCleanupInterval.Duration <= 0 {
+		return errors.New("throttle cleanup interval must be positive")
+	}
+
+	logger := newLogger(config)
+
+	var sessionStore sessions.Store
+
+	switch config.SessionBackend {
+	case sessionBackendCookie:
+		cs := sessions.NewCookieStore([]byte(config.SessionKey))
+		cs.MaxAge(int(config.SessionMaxAge.Seconds()))
+		sessionStore = cs
+	case sessionBackendDatabase:
+		ss := sqlstore.NewSessionStore(db, []byte(config.SessionKey))
+		ss.MaxAge(int(config.SessionMaxAge.Seconds()))
+
+		quit, done := ss.StartCleanup(config.SessionCleanupInterval.Duration, logger)
+		defer ss.StopCleanup(quit, done)
+
+		sessionStore = ss
+	default:
+		return fmt.Errorf("unknown session backend %q", config.SessionBackend)
+	}
+
+	if config.Mailer != mailerLog && config.Mailer != mailerSMTP {
+		return fmt.Errorf("unknown mailer %q", config.Mailer)
+	}
+
+	if len(config.Authenticators) == 0 {
+		return errors.New("no authenticator configured")
+	}
+
+	for _, name := range config.Authenticators {
+		if name != authenticatorLocal && name != authenticatorLDAP {
+			return fmt.Errorf("unknown authenticator %q", name)
+		}
+	}
+
+	srv := newServer(store, sessionStore, config)
+
+	switch config.ThrottleBackend {
+	case throttleBackendMemory:
+		ts := throttle.NewMemoryStore()
+
+		quit, done := ts.StartCleanup(config.ThrottleCleanupInterval.Duration)
+		defer ts.StopCleanup(quit, done)
+
+		srv.configureThrottles(ts)
+	case throttleBackendDatabase:
+		ts := sqlstore.NewThrottleStore(db)
+
+		quit, done := ts.StartCleanup(config.ThrottleCleanupInterval.Duration, logger)
+		defer ts.StopCleanup(quit, done)
+
+		srv.configureThrottles(ts)
+	default:
+		return fmt.Errorf("unknown throttle backend %q", config.ThrottleBackend)
+	}
+
+	ln, err := net.Listen("tcp", config.BindAddr)
+
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The deferred calls above stop the cleanup routines in reverse order of
+	// their start and close the database once serve has returned and the
+	// work handlers left running in the background is done.
+	err = serve(ctx, ln, srv, config, srv.logger)
+	srv.background.Wait()
+
+	return err
+}
+
+// serve handles requests on ln until ctx is done. It then stops accepting
+// connections and waits for running requests to finish, for at most the
+// shutdown timeout, before closing the remaining connections.
+func serve(ctx context.Context, ln net.Listener, handler http.Handler, config *Config, logger *logrus.Logger) error {
+	hs := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout.Duration,
+		ReadHeaderTimeout: config.ReadHeaderTimeout.Duration,
+		WriteTimeout:      config.WriteTimeout.Duration,
+		IdleTimeout:       config.IdleTimeout.Duration,
+	}
+
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- hs.Serve(ln)
+	}()
+
+	logger.Infof("listening on %s", ln.Addr())
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout.Duration)
+	defer cancel()
 
-	return http.ListenAndServe(config.BindAddr, srv)
+	if err := hs.Shutdown(shutdownCtx); err != nil {
+		hs.Close()
+		return err
+	}
+
+	if err := <-errs; err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+func newPasswordHasher(config *Config) (model.PasswordHasher, error) {
+	switch config.PasswordHash {
+	case passwordHashBcrypt:
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+
+		return &model.BcryptHasher{Cost: config.BcryptCost}, nil
+	case passwordHashArgon2id:
+		if config.Argon2Time < 1 {
+			return nil, errors.New("argon2 time must be at least 1")
+		}
+
+		if config.Argon2Threads < 1 {
+			return nil, errors.New("argon2 threads must be at least 1")
+		}
+
+		if config.Argon2Memory < 8*uint32(config.Argon2Threads) {
+			return nil, errors.New("argon2 memory must be at least 8 KiB per thread")
+		}
+
+		return &model.Argon2idHasher{
+			Memory:  config.Argon2Memory,
+			Time:    config.Argon2Time,
+			Threads: config.Argon2Threads,
+			SaltLen: 16,
+			KeyLen:  32,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown password hash %q", config.PasswordHash)
+	}
+}
+
+// newLogger returns a logger with the level and format of config. Invalid
+// settings are rejected by Start and leave the defaults of logrus in place.
+func newLogger(config *Config) *logrus.Logger {
+	logger := logrus.New()
+
+	if level, err := logrus.ParseLevel(config.LogLevel); err == nil {
+		logger.SetLevel(level)
+	}
+
+	if config.LogFormat == logFormatJSON {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	}
+
+	return logger
+}
+
+func newMailer(config *Config, logger *logrus.Logger) mailer.Mailer {
+	if config.Mailer == mailerSMTP {
+		return mailer.NewSMTP(config.SMTPAddr, config.MailFrom, config.SMTPUsername, config.SMTPPassword)
+	}
+
+	return mailer.NewLog(logger)
 }
 
 func newDB(databaseURL string) (*sql.DB, error) {
@@ -39,3 +248,48 @@ func newDB(databaseURL string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// GrantRole assigns the role with the given name to the user with the given
+// email address, in the database of config. It is how the first admin is
+// created, before anyone can use the /admin routes.
+func GrantRole(config *Config, email, roleName string) error {
+	db, err := newDB(config.DatabaseURL)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	s := sqlstore.New(db)
+
+	u, err := s.User().FindByEmail(email)
+
+	if err != nil {
+		return fmt.Errorf("finding user %q: %w", email, err)
+	}
+
+	role, err := s.Role().FindByName(roleName)
+
+	if err != nil {
+		return fmt.Errorf("finding role %q: %w", roleName, err)
+	}
+
+	return s.Role().Assign(u.ID, role.ID)
+}
+
+// VerifyAuditLog checks the hash chain of the audit log in the database of
+// config and returns the number of events checked.
+func VerifyAuditLog(config *Config) (int, error) {
+	db, err := newDB(config.DatabaseURL)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer db.Close()
+
+	model.SetAuditKey(newAuditKey(config))
+
+	return store.VerifyAuditChain(sqlstore.New(db).Audit())
+}
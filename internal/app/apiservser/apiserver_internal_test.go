@@ -0,0 +1,170 @@
+package apiserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe(t *testing.T) {
+	testCases := []struct {
+		name            string
+		shutdownTimeout time.Duration
+		release         bool
+		expectedErr     error
+	}{
+		{
+			name:            "drains running requests",
+			shutdownTimeout: time.Minute,
+			release:         true,
+			expectedErr:     nil,
+		},
+		{
+			name:            "gives up after the shutdown timeout",
+			shutdownTimeout: 50 * time.Millisecond,
+			release:         false,
+			expectedErr:     context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			defer close(release)
+
+			handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				close(started)
+
+				select {
+				case <-release:
+				case <-r.Context().Done():
+					return
+				}
+
+				rw.WriteHeader(http.StatusOK)
+			})
+
+			config := testConfig()
+			config.ShutdownTimeout = Duration{tc.shutdownTimeout}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			served := make(chan error, 1)
+
+			go func() {
+				served <- serve(ctx, ln, handler, config, logrus.New())
+			}()
+
+			codes := make(chan int, 1)
+			url := "http://" + ln.Addr().String()
+
+			go func() {
+				res, err := http.Get(url)
+
+				if err != nil {
+					codes <- 0
+					return
+				}
+
+				res.Body.Close()
+				codes <- res.StatusCode
+			}()
+
+			<-started
+			cancel()
+
+			if tc.release {
+				time.Sleep(50 * time.Millisecond)
+
+				_, err := http.Get(url)
+				assert.Error(t, err)
+
+				release <- struct{}{}
+				assert.Equal(t, http.StatusOK, <-codes)
+			}
+
+			select {
+			case err := <-served:
+				assert.Equal(t, tc.expectedErr, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("serve did not return")
+			}
+		})
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  func(*Config)
+		isValid bool
+	}{
+		{
+			name:    "bcrypt",
+			config:  func(c *Config) {},
+			isValid: true,
+		},
+		{
+			name:    "bcrypt cost too high",
+			config:  func(c *Config) { c.BcryptCost = 32 },
+			isValid: false,
+		},
+		{
+			name:    "argon2id",
+			config:  func(c *Config) { c.PasswordHash = passwordHashArgon2id },
+			isValid: true,
+		},
+		{
+			name: "argon2id without time",
+			config: func(c *Config) {
+				c.PasswordHash = passwordHashArgon2id
+				c.Argon2Time = 0
+			},
+			isValid: false,
+		},
+		{
+			name: "argon2id without threads",
+			config: func(c *Config) {
+				c.PasswordHash = passwordHashArgon2id
+				c.Argon2Threads = 0
+			},
+			isValid: false,
+		},
+		{
+			name: "argon2id with too little memory",
+			config: func(c *Config) {
+				c.PasswordHash = passwordHashArgon2id
+				c.Argon2Memory = 8
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig()
+			tc.config(config)
+
+			_, err := newPasswordHasher(config)
+
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
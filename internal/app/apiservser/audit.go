@@ -0,0 +1,150 @@
+package apiserver
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// handleAuditEventList returns a page of the audit log, newest events first.
+// The query parameters action, actor_id, target_id, since, until, limit and
+// cursor map onto store.AuditFilter.
+func (s *server) handleAuditEventList() http.HandlerFunc {
+
+	type response struct {
+		Events     []*model.AuditEvent `json:"events"`
+		NextCursor string              `json:"next_cursor,omitempty"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		f, err := parseAuditFilter(r)
+
+		if err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		events, next, err := s.store.Audit().List(r.Context(), *f)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := &response{Events: events}
+
+		if next != nil {
+			res.NextCursor = next.Encode()
+		}
+
+		s.respond(rw, r, http.StatusOK, res)
+	}
+}
+
+func parseAuditFilter(r *http.Request) (*store.AuditFilter, error) {
+	q := r.URL.Query()
+
+	f := &store.AuditFilter{
+		Action: q.Get("action"),
+	}
+
+	var err error
+
+	if v := q.Get("actor_id"); v != "" {
+		if f.ActorID, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("target_id"); v != "" {
+		if f.TargetID, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("since"); v != "" {
+		if f.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("until"); v != "" {
+		if f.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		if f.Cursor, err = store.DecodeCursor(v); err != nil {
+			return nil, err
+		}
+	}
+
+	f.Normalize()
+
+	return f, nil
+}
+
+// newAuditKey returns the key the audit log is chained with.
+func newAuditKey(config *Config) []byte {
+	if config.AuditKey != "" {
+		return []byte(config.AuditKey)
+	}
+
+	return []byte(config.SessionKey)
+}
+
+// audit appends an event caused by r to the audit log. actorID and targetID
+// are user IDs, 0 when there is none.
+func (s *server) audit(r *http.Request, action string, actorID, targetID int, data map[string]string) {
+	recordAudit(s.store, s.logger, r, action, actorID, targetID, data)
+}
+
+// recordAudit is audit for code without access to the server. Events caused
+// with an impersonated session name the impersonating admin in their data. A
+// failure to record the event is logged, but does not fail the request.
+func recordAudit(store store.Store, logger *logrus.Logger, r *http.Request, action string, actorID, targetID int, data map[string]string) {
+	requestID, _ := r.Context().Value(contextKeyRequestID).(string)
+
+	if sess, ok := r.Context().Value(contextKeySession).(*model.Session); ok && sess.IsImpersonated() {
+		withImpersonator := map[string]string{"impersonator_id": strconv.Itoa(*sess.ImpersonatorID)}
+
+		for k, v := range data {
+			withImpersonator[k] = v
+		}
+
+		data = withImpersonator
+	}
+
+	e := &model.AuditEvent{
+		Action:    action,
+		IP:        clientIP(r),
+		RequestID: requestID,
+		Data:      data,
+	}
+
+	if actorID != 0 {
+		e.ActorID = &actorID
+	}
+
+	if targetID != 0 {
+		e.TargetID = &targetID
+	}
+
+	if err := store.Audit().Append(e); err != nil {
+		logger.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"action":     action,
+		}).Errorf("recording audit event: %v", err)
+	}
+}
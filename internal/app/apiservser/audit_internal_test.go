@@ -0,0 +1,81 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleAuditEventList(t *testing.T) {
+
+	admin := model.TestUser(t)
+	admin.Email = "admin@example.org"
+
+	u := model.TestUser(t)
+
+	s := teststore.New()
+	s.User().Create(admin)
+	s.User().Create(u)
+
+	testGrant(t, s, admin.ID, model.PermissionAuditRead)
+
+	srv := newServer(s, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{"email": u.Email, "password": "invalid"})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/sessions", b)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	cookie := testLogin(t, srv, u.Email, "password")
+	adminCookie := testLogin(t, srv, admin.Email, "password")
+
+	list := func(cookie, query string) (int, []*model.AuditEvent) {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/admin/audit-events"+query, nil)
+		req.Header.Set("Cookie", cookie)
+		srv.ServeHTTP(rec, req)
+
+		res := struct {
+			Events []*model.AuditEvent `json:"events"`
+		}{}
+		json.NewDecoder(rec.Body).Decode(&res)
+
+		return rec.Code, res.Events
+	}
+
+	code, _ := list(cookie, "")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = list(adminCookie, "?actor_id=invalid")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, events := list(adminCookie, fmt.Sprintf("?target_id=%d", u.ID))
+	assert.Equal(t, http.StatusOK, code)
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, model.AuditLoginSucceeded, events[0].Action)
+		assert.Equal(t, model.AuditLoginFailed, events[1].Action)
+		assert.Nil(t, events[1].ActorID)
+		assert.Equal(t, u.Email, events[1].Data["login"])
+		assert.NotEmpty(t, events[1].RequestID)
+	}
+
+	code, events = list(adminCookie, "?action="+model.AuditLoginSucceeded+"&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, events, 1)
+
+	n, err := store.VerifyAuditChain(s.Audit())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
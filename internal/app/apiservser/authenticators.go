@@ -0,0 +1,193 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"webserver/internal/app/ldap"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errorDirectoryUnavailable = errors.New("user directory is unavailable")
+)
+
+// authenticator checks the password of a login attempt. It returns
+// errorIncorrectEmailOrPassword when login and password do not match.
+type authenticator interface {
+	authenticate(r *http.Request, login, password string) (*model.User, error)
+}
+
+// newAuthenticators returns the configured authenticators in the order they
+// are tried. Unknown names are rejected by Start.
+func newAuthenticators(config *Config, store store.Store, logger *logrus.Logger) []authenticator {
+	var authenticators []authenticator
+
+	for _, name := range config.Authenticators {
+		switch name {
+		case authenticatorLocal:
+			authenticators = append(authenticators, &localAuthenticator{
+				store:  store,
+				logger: logger,
+			})
+		case authenticatorLDAP:
+			authenticators = append(authenticators, &ldapAuthenticator{
+				directory: ldap.New(ldap.Config{
+					URL:            config.LDAPURL,
+					BindDN:         config.LDAPBindDN,
+					BaseDN:         config.LDAPBaseDN,
+					Filter:         config.LDAPFilter,
+					EmailAttribute: config.LDAPEmailAttribute,
+				}),
+				store:  store,
+				logger: logger,
+			})
+		}
+	}
+
+	return authenticators
+}
+
+// authenticate tries the authenticators in order until one accepts the
+// credentials. When none does, an error other than
+// errorIncorrectEmailOrPassword is preferred, since the user might have been
+// accepted by the failing authenticator.
+func (s *server) authenticate(r *http.Request, login, password string) (*model.User, error) {
+	result := errorIncorrectEmailOrPassword
+
+	for _, a := range s.authenticators {
+		u, err := a.authenticate(r, login, password)
+
+		if err == nil {
+			return u, nil
+		}
+
+		if result == errorIncorrectEmailOrPassword {
+			result = err
+		}
+	}
+
+	return nil, result
+}
+
+// localAuthenticator checks the encrypted password stored with the user.
+type localAuthenticator struct {
+	store  store.Store
+	logger *logrus.Logger
+}
+
+func (a *localAuthenticator) authenticate(r *http.Request, email, password string) (*model.User, error) {
+	u, err := a.store.User().FindByEmail(email)
+
+	if err == store.ErrorRecordNotFound {
+		return nil, errorIncorrectEmailOrPassword
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.ComparePassword(password) {
+		return nil, errorIncorrectEmailOrPassword
+	}
+
+	if u.NeedsRehash() {
+		a.rehashPassword(r, u, password)
+	}
+
+	return u, nil
+}
+
+// rehashPassword upgrades the encrypted password of u to the configured
+// hasher. A failure is only logged, since the old hash remains valid.
+func (a *localAuthenticator) rehashPassword(r *http.Request, u *model.User, password string) {
+	u.Password = password
+
+	if err := a.store.User().UpdatePassword(u); err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"request_id": r.Context().Value(contextKeyRequestID),
+			"user_id":    u.ID,
+		}).Warnf("could not rehash password: %v", err)
+	}
+
+	u.Sanitize()
+}
+
+// ldapAuthenticator binds to an LDAP directory as the user. A user logging
+// in for the first time is provisioned with the email address of its entry,
+// or linked to the local user who already has that address, since the
+// directory is trusted to have verified it.
+type ldapAuthenticator struct {
+	directory *ldap.Directory
+	store     store.Store
+	logger    *logrus.Logger
+}
+
+func (a *ldapAuthenticator) authenticate(r *http.Request, login, password string) (*model.User, error) {
+	e, err := a.directory.Authenticate(login, password)
+
+	if err == ldap.ErrorInvalidCredentials {
+		return nil, errorIncorrectEmailOrPassword
+	}
+
+	if err != nil {
+		a.logger.WithField("request_id", r.Context().Value(contextKeyRequestID)).Errorf("ldap authentication: %v", err)
+		return nil, errorDirectoryUnavailable
+	}
+
+	u, err := a.store.User().FindByEmail(e.Email)
+
+	if err == store.ErrorRecordNotFound {
+		u, err := provisionUser(a.store, e.Email, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		recordAudit(a.store, a.logger, r, model.AuditUserCreated, 0, u.ID, map[string]string{"method": "ldap"})
+
+		return u, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// provisionUser creates a user for an identity established elsewhere. Its
+// password is random, so that it can only log in through the external
+// identity until it resets the password.
+func provisionUser(store store.Store, email string, verified bool) (*model.User, error) {
+	password, err := model.GenerateToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	u := &model.User{
+		Email:    email,
+		Password: password,
+	}
+
+	if err := store.User().Create(u); err != nil {
+		return nil, err
+	}
+
+	u.Sanitize()
+
+	if verified {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+
+		if err := store.User().UpdateEmail(u); err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
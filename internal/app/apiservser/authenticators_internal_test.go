@@ -0,0 +1,112 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webserver/internal/app/ldap"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleSessionCreate_LDAP(t *testing.T) {
+
+	ts := ldap.NewTestServer(t)
+	ts.AddUser("alice", "directory password", "alice@example.org")
+	ts.AddUser("linked", "directory password", "linked@example.org")
+
+	local := model.TestUser(t)
+	linked := model.TestUser(t)
+	linked.Email = "linked@example.org"
+
+	store := teststore.New()
+
+	store.User().Create(local)
+	store.User().Create(linked)
+
+	newTestServer := func(authenticators ...string) *server {
+		ldapConfig := ts.Config()
+
+		config := testConfig()
+		config.Authenticators = authenticators
+		config.LDAPURL = ldapConfig.URL
+		config.LDAPBindDN = ldapConfig.BindDN
+		config.LDAPBaseDN = ldapConfig.BaseDN
+		config.LDAPFilter = ldapConfig.Filter
+		config.LDAPEmailAttribute = ldapConfig.EmailAttribute
+
+		return newServer(store, sessions.NewCookieStore([]byte("secret")), config)
+	}
+
+	login := func(srv *server, login, password string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{
+			"email":    login,
+			"password": password,
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/sessions", b)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("provisioning", func(t *testing.T) {
+		srv := newTestServer(authenticatorLDAP)
+
+		rec := login(srv, "alice", "directory password")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, rec.Header().Get("Set-Cookie")))
+
+		u, err := store.User().FindByEmail("alice@example.org")
+		assert.NoError(t, err)
+		assert.True(t, u.IsEmailVerified())
+
+		assert.Equal(t, http.StatusOK, login(srv, "alice", "directory password").Code)
+
+		users, _ := store.User().GetAll()
+		assert.Len(t, users, 3)
+	})
+
+	t.Run("linking", func(t *testing.T) {
+		srv := newTestServer(authenticatorLDAP)
+
+		assert.Equal(t, http.StatusOK, login(srv, "linked", "directory password").Code)
+
+		users, _ := store.User().GetAll()
+		assert.Len(t, users, 3)
+	})
+
+	t.Run("ldap only", func(t *testing.T) {
+		srv := newTestServer(authenticatorLDAP)
+
+		assert.Equal(t, http.StatusUnauthorized, login(srv, "alice", "wrong").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(srv, local.Email, local.Password).Code)
+	})
+
+	t.Run("ldap and local", func(t *testing.T) {
+		srv := newTestServer(authenticatorLDAP, authenticatorLocal)
+
+		assert.Equal(t, http.StatusOK, login(srv, "alice", "directory password").Code)
+		assert.Equal(t, http.StatusOK, login(srv, local.Email, local.Password).Code)
+		assert.Equal(t, http.StatusUnauthorized, login(srv, local.Email, "wrong").Code)
+	})
+
+	t.Run("directory unavailable", func(t *testing.T) {
+		srv := newTestServer(authenticatorLDAP, authenticatorLocal)
+		srv.authenticators[0].(*ldapAuthenticator).directory = ldap.New(ldap.Config{URL: "ldap://127.0.0.1:1"})
+
+		assert.Equal(t, http.StatusOK, login(srv, local.Email, local.Password).Code)
+		assert.Equal(t, http.StatusBadGateway, login(srv, "alice", "directory password").Code)
+
+		for i := 0; i <= srv.config.LoginFreeAttempts; i++ {
+			assert.Equal(t, http.StatusBadGateway, login(srv, local.Email, "wrong").Code)
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, login(srv, local.Email, "wrong").Code)
+	})
+}
@@ -1,15 +1,163 @@
 package apiserver
 
+import (
+	"time"
+	"webserver/internal/app/model"
+)
+
+const (
+	sessionBackendCookie    = "cookie"
+	sessionBackendDatabase  = "database"
+	mailerLog               = "log"
+	mailerSMTP              = "smtp"
+	throttleBackendMemory   = "memory"
+	throttleBackendDatabase = "database"
+	passwordHashBcrypt      = "bcrypt"
+	passwordHashArgon2id    = "argon2id"
+	authenticatorLocal      = "local"
+	authenticatorLDAP       = "ldap"
+	logFormatText           = "text"
+	logFormatJSON           = "json"
+)
+
 type Config struct {
-	BindAddr    string `toml:"bind_addr"`
-	LogLevel    string `toml:"log_level"`
-	DatabaseURL string `toml:"database_url"`
-	SessionKey  string `toml:"session_key"`
+	BindAddr                string   `toml:"bind_addr"`
+	ReadTimeout             Duration `toml:"read_timeout"`
+	ReadHeaderTimeout       Duration `toml:"read_header_timeout"`
+	WriteTimeout            Duration `toml:"write_timeout"`
+	IdleTimeout             Duration `toml:"idle_timeout"`
+	ShutdownTimeout         Duration `toml:"shutdown_timeout"`
+	LogLevel                string   `toml:"log_level"`
+	LogFormat               string   `toml:"log_format"`
+	DatabaseURL             string   `toml:"database_url"`
+	SessionKey              string   `toml:"session_key"`
+	SessionBackend          string   `toml:"session_backend"`
+	SessionMaxAge           Duration `toml:"session_max_age"`
+	SessionCleanupInterval  Duration `toml:"session_cleanup_interval"`
+	ImpersonationTTL        Duration `toml:"impersonation_ttl"`
+	ReauthenticationTTL     Duration `toml:"reauthentication_ttl"`
+	JWTSecret               string   `toml:"jwt_secret"`
+	AccessTokenTTL          Duration `toml:"access_token_ttl"`
+	RefreshTokenTTL         Duration `toml:"refresh_token_ttl"`
+	Mailer                  string   `toml:"mailer"`
+	MailFrom                string   `toml:"mail_from"`
+	SMTPAddr                string   `toml:"smtp_addr"`
+	SMTPUsername            string   `toml:"smtp_username"`
+	SMTPPassword            string   `toml:"smtp_password"`
+	EmailVerificationTTL    Duration `toml:"email_verification_ttl"`
+	AllowUnverifiedLogin    bool     `toml:"allow_unverified_login"`
+	PasswordResetTTL        Duration `toml:"password_reset_ttl"`
+	PasswordResetMails      int      `toml:"password_reset_mails"`
+	PasswordResetIPMails    int      `toml:"password_reset_ip_mails"`
+	PublicURL               string   `toml:"public_url"`
+	MagicLinkTTL            Duration `toml:"magic_link_ttl"`
+	MagicLinkFreeLinks      int      `toml:"magic_link_free_links"`
+	MagicLinkIPFreeLinks    int      `toml:"magic_link_ip_free_links"`
+	InvitationTTL           Duration `toml:"invitation_ttl"`
+	InviteOnly              bool     `toml:"invite_only"`
+	OAuthAccessTokenTTL     Duration `toml:"oauth_access_token_ttl"`
+	ThrottleBackend         string   `toml:"throttle_backend"`
+	ThrottleCleanupInterval Duration `toml:"throttle_cleanup_interval"`
+	LoginFreeAttempts       int      `toml:"login_free_attempts"`
+	LoginIPFreeAttempts     int      `toml:"login_ip_free_attempts"`
+	LoginBackoffBase        Duration `toml:"login_backoff_base"`
+	LoginBackoffMax         Duration `toml:"login_backoff_max"`
+	LoginLockoutThreshold   int      `toml:"login_lockout_threshold"`
+	LoginLockoutDuration    Duration `toml:"login_lockout_duration"`
+	LoginFailureWindow      Duration `toml:"login_failure_window"`
+	PasswordHash            string   `toml:"password_hash"`
+	BcryptCost              int      `toml:"bcrypt_cost"`
+	Argon2Memory            uint32   `toml:"argon2_memory"`
+	Argon2Time              uint32   `toml:"argon2_time"`
+	Argon2Threads           uint8    `toml:"argon2_threads"`
+	TOTPKey                 string   `toml:"totp_key"`
+	AuditKey                string   `toml:"audit_key"`
+	TOTPIssuer              string   `toml:"totp_issuer"`
+	Authenticators          []string `toml:"authenticators"`
+	LDAPURL                 string   `toml:"ldap_url"`
+	LDAPBindDN              string   `toml:"ldap_bind_dn"`
+	LDAPBaseDN              string   `toml:"ldap_base_dn"`
+	LDAPFilter              string   `toml:"ldap_filter"`
+	LDAPEmailAttribute      string   `toml:"ldap_email_attribute"`
+
+	// LogSampling maps route path templates, such as "/orgs/{id:[0-9]+}", to
+	// the rate at which their requests are logged: 1 in n, or none for 0.
+	// Requests failing with a server error are always logged.
+	LogSampling map[string]int `toml:"log_sampling"`
+
+	// OIDCProviders maps the names used in /auth/{provider} to the
+	// configuration of the provider.
+	OIDCProviders map[string]OIDCProvider `toml:"oidc_providers"`
+}
+
+// OIDCProvider configures an external OpenID Connect provider users can log
+// in with.
+type OIDCProvider struct {
+	Issuer       string   `toml:"issuer"`
+	ClientID     string   `toml:"client_id"`
+	ClientSecret string   `toml:"client_secret"`
+	Scopes       []string `toml:"scopes"`
 }
 
 func NewConfig() *Config {
 	return &Config{
-		BindAddr: ":8080",
-		LogLevel: "debug",
+		BindAddr:                ":8080",
+		ReadTimeout:             Duration{15 * time.Second},
+		ReadHeaderTimeout:       Duration{5 * time.Second},
+		WriteTimeout:            Duration{30 * time.Second},
+		IdleTimeout:             Duration{2 * time.Minute},
+		ShutdownTimeout:         Duration{30 * time.Second},
+		LogLevel:                "debug",
+		LogFormat:               logFormatText,
+		SessionBackend:          sessionBackendCookie,
+		SessionMaxAge:           Duration{30 * 24 * time.Hour},
+		SessionCleanupInterval:  Duration{5 * time.Minute},
+		ImpersonationTTL:        Duration{15 * time.Minute},
+		ReauthenticationTTL:     Duration{5 * time.Minute},
+		AccessTokenTTL:          Duration{15 * time.Minute},
+		RefreshTokenTTL:         Duration{30 * 24 * time.Hour},
+		Mailer:                  mailerLog,
+		EmailVerificationTTL:    Duration{48 * time.Hour},
+		AllowUnverifiedLogin:    true,
+		PasswordResetTTL:        Duration{time.Hour},
+		PasswordResetMails:      3,
+		PasswordResetIPMails:    20,
+		PublicURL:               "http://localhost:8080",
+		MagicLinkTTL:            Duration{15 * time.Minute},
+		MagicLinkFreeLinks:      3,
+		MagicLinkIPFreeLinks:    20,
+		InvitationTTL:           Duration{7 * 24 * time.Hour},
+		OAuthAccessTokenTTL:     Duration{time.Hour},
+		ThrottleBackend:         throttleBackendMemory,
+		ThrottleCleanupInterval: Duration{5 * time.Minute},
+		LoginFreeAttempts:       3,
+		LoginIPFreeAttempts:     20,
+		LoginBackoffBase:        Duration{time.Second},
+		LoginBackoffMax:         Duration{time.Minute},
+		LoginLockoutThreshold:   10,
+		LoginLockoutDuration:    Duration{15 * time.Minute},
+		LoginFailureWindow:      Duration{time.Hour},
+		PasswordHash:            passwordHashBcrypt,
+		BcryptCost:              model.DefaultBcryptCost,
+		Argon2Memory:            64 * 1024,
+		Argon2Time:              3,
+		Argon2Threads:           2,
+		TOTPIssuer:              "webserver",
+		Authenticators:          []string{authenticatorLocal},
+		LDAPFilter:              "(uid=%s)",
+		LDAPEmailAttribute:      "mail",
 	}
 }
+
+// Duration is a time.Duration that can be decoded from strings such as "5m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	var err error
+
+	d.Duration, err = time.ParseDuration(string(text))
+
+	return err
+}
@@ -0,0 +1,162 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+const tokenPurposeEmailVerification = "email-verification"
+
+var (
+	errorInvalidVerificationToken = errors.New("invalid or expired verification token")
+	errorEmailNotVerified         = errors.New("email is not verified")
+	errorEmailTaken               = errors.New("email is already taken")
+)
+
+// emailVerification is the payload of a verification token. The address is
+// part of the payload so that a token only confirms the address it was sent
+// to. Tokens for a change of address also carry the address they replace, so
+// that they are void once the user's address has changed in the meantime.
+type emailVerification struct {
+	UserID   int    `json:"user_id"`
+	Email    string `json:"email"`
+	Previous string `json:"previous,omitempty"`
+}
+
+func (s *server) handleUserVerify() http.HandlerFunc {
+
+	type request struct {
+		Token string `json:"token"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		v := &emailVerification{}
+
+		if err := s.verifyToken(tokenPurposeEmailVerification, s.config.EmailVerificationTTL.Duration, req.Token, v); err != nil {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidVerificationToken)
+			return
+		}
+
+		u, err := s.store.User().Find(v.UserID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidVerificationToken)
+			return
+		}
+
+		expected := v.Email
+
+		if v.Previous != "" {
+			expected = v.Previous
+		}
+
+		if u.Email != expected {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidVerificationToken)
+			return
+		}
+
+		if v.Email != u.Email {
+			if _, err := s.store.User().FindByEmail(v.Email); err != store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusUnprocessableEntity, errorEmailTaken)
+				return
+			}
+
+			u.Email = v.Email
+			u.EmailVerifiedAt = nil
+		}
+
+		if !u.IsEmailVerified() {
+			now := time.Now()
+			u.EmailVerifiedAt = &now
+
+			if err := s.store.User().UpdateEmail(u); err != nil {
+				// Another user may have confirmed the address since it was
+				// checked above.
+				if err == store.ErrorEmailTaken {
+					s.error(rw, r, http.StatusUnprocessableEntity, errorEmailTaken)
+					return
+				}
+
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		u.Sanitize()
+
+		s.respond(rw, r, http.StatusOK, u)
+	}
+}
+
+// handleUserVerifyResend sends a new verification token. It responds the same
+// way whether or not the address belongs to an unverified user.
+func (s *server) handleUserVerifyResend() http.HandlerFunc {
+
+	type request struct {
+		Email string `json:"email"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u, err := s.store.User().FindByEmail(req.Email)
+
+		if err != nil && err != store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err == nil && !u.IsEmailVerified() {
+			if err := s.sendEmailVerification(u, u.Email); err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		s.respond(rw, r, http.StatusAccepted, nil)
+	}
+}
+
+// sendEmailVerification mails a token to email that, once redeemed, marks it
+// as the verified address of u. If email differs from the current address of
+// u, redeeming the token changes the address.
+func (s *server) sendEmailVerification(u *model.User, email string) error {
+	v := &emailVerification{
+		UserID: u.ID,
+		Email:  email,
+	}
+
+	if email != u.Email {
+		v.Previous = u.Email
+	}
+
+	token, err := s.signToken(tokenPurposeEmailVerification, v)
+
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Use the following token to verify your email address:\n\n%s\n", token),
+	})
+}
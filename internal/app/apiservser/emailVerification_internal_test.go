@@ -0,0 +1,143 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleUserVerify(t *testing.T) {
+
+	store := teststore.New()
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	u := model.TestUser(t)
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"email":    u.Email,
+		"password": u.Password,
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/users", b)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	msg := m.Last(u.Email)
+	assert.NotNil(t, msg)
+
+	token := testMailToken(msg)
+
+	foreign, _ := srv.signToken(tokenPurposeEmailVerification, &emailVerification{UserID: 1, Email: "other@example.org"})
+	otherPurpose, _ := srv.signToken("other", &emailVerification{UserID: 1, Email: u.Email})
+
+	testCases := []struct {
+		name         string
+		token        string
+		expectedCode int
+	}{
+		{
+			name:         "invalid token",
+			token:        "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "token for another address",
+			token:        foreign,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "token for another purpose",
+			token:        otherPurpose,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "valid",
+			token:        token,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(map[string]string{"token": tc.token})
+			req, _ := http.NewRequest(http.MethodPost, "/users/verify", b)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	found, _ := store.User().FindByEmail(u.Email)
+	assert.True(t, found.IsEmailVerified())
+}
+
+func Test_HandleUserVerifyResend(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	for _, email := range []string{u.Email, "unknown@example.org"} {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{"email": email})
+		req, _ := http.NewRequest(http.MethodPost, "/users/verify/resend", b)
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+
+	assert.NotNil(t, m.Last(u.Email))
+	assert.Nil(t, m.Last("unknown@example.org"))
+}
+
+func Test_UnverifiedLogin(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	config := testConfig()
+	config.AllowUnverifiedLogin = false
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), config)
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"email":    u.Email,
+		"password": u.Password,
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/sessions", b)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+// testMailToken returns the last non-empty line of a mail body, which is
+// where every mail sent by the server puts its token.
+func testMailToken(msg *mailer.Message) string {
+	lines := strings.Split(strings.TrimSpace(msg.Body), "\n")
+
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,149 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"webserver/internal/app/model"
+)
+
+var (
+	errorImpersonating        = errors.New("not allowed while impersonating a user")
+	errorNotImpersonating     = errors.New("session is not impersonating a user")
+	errorImpersonateSelf      = errors.New("cannot impersonate yourself")
+	errorImpersonateProtected = errors.New("users who can impersonate cannot be impersonated")
+)
+
+// handleImpersonationStart logs the current admin in as the user whose ID is
+// in the URL. The new session expires after the impersonation TTL, and the
+// session the admin started from is restored when the impersonation ends.
+func (s *server) handleImpersonationStart() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		admin := r.Context().Value(contextKeyUser).(*model.User)
+
+		u, ok := s.findUserVar(rw, r)
+
+		if !ok {
+			return
+		}
+
+		if u.ID == admin.ID {
+			s.error(rw, r, http.StatusBadRequest, errorImpersonateSelf)
+			return
+		}
+
+		protected, err := s.store.Role().HasPermission(u.ID, model.PermissionUsersImpersonate)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if protected {
+			s.error(rw, r, http.StatusForbidden, errorImpersonateProtected)
+			return
+		}
+
+		session, err := s.sessionStore.Get(r, sessionName)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		expiresAt := time.Now().Add(s.config.ImpersonationTTL.Duration)
+
+		sess := &model.Session{
+			UserID:         u.ID,
+			ImpersonatorID: &admin.ID,
+			IP:             clientIP(r),
+			UserAgent:      r.UserAgent(),
+			ExpiresAt:      &expiresAt,
+		}
+
+		if err := s.store.Session().Create(sess); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		session.Values["user_id"] = u.ID
+		session.Values["session_id"] = sess.ID
+		session.Values["impersonator_session_id"] = currentSessionID(r)
+
+		if err := s.sessionStore.Save(r, rw, session); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditImpersonationStarted, admin.ID, u.ID, map[string]string{"session_id": sess.ID})
+
+		s.respond(rw, r, http.StatusCreated, sess)
+	}
+}
+
+// handleImpersonationEnd revokes the impersonated session of the request and
+// returns the admin to the session the impersonation was started from.
+func (s *server) handleImpersonationEnd() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		sess, ok := r.Context().Value(contextKeySession).(*model.Session)
+
+		if !ok || !sess.IsImpersonated() {
+			s.error(rw, r, http.StatusBadRequest, errorNotImpersonating)
+			return
+		}
+
+		if err := s.store.Session().Revoke(sess.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.restoreImpersonator(rw, r, sess); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditImpersonationEnded, *sess.ImpersonatorID, sess.UserID, map[string]string{"session_id": sess.ID})
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// forbidImpersonation rejects requests authenticated with an impersonated
+// session, for endpoints that change or hand out credentials.
+func (s *server) forbidImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if sess, ok := r.Context().Value(contextKeySession).(*model.Session); ok && sess.IsImpersonated() {
+			s.error(rw, r, http.StatusForbidden, errorImpersonating)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// restoreImpersonator binds the cookie to the session the impersonation of
+// sess was started from, as long as that session is still active. Otherwise
+// the cookie is cleared.
+func (s *server) restoreImpersonator(rw http.ResponseWriter, r *http.Request, sess *model.Session) error {
+	session, err := s.sessionStore.Get(r, sessionName)
+
+	if err != nil {
+		return err
+	}
+
+	id, _ := session.Values["impersonator_session_id"].(string)
+	delete(session.Values, "impersonator_session_id")
+
+	prev, err := s.store.Session().Find(id)
+
+	if err != nil || prev.IsRevoked() || prev.IsExpired() || prev.UserID != *sess.ImpersonatorID {
+		delete(session.Values, "user_id")
+		delete(session.Values, "session_id")
+		session.Options.MaxAge = -1
+	} else {
+		session.Values["user_id"] = prev.UserID
+		session.Values["session_id"] = prev.ID
+	}
+
+	return s.sessionStore.Save(r, rw, session)
+}
@@ -0,0 +1,140 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleImpersonation(t *testing.T) {
+
+	admin := model.TestUser(t)
+	admin.Email = "admin@example.org"
+
+	otherAdmin := model.TestUser(t)
+	otherAdmin.Email = "other-admin@example.org"
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	for _, user := range []*model.User{admin, otherAdmin, u} {
+		store.User().Create(user)
+	}
+
+	testGrant(t, store, admin.ID, model.PermissionUsersImpersonate, model.PermissionAuditRead)
+	testGrant(t, store, otherAdmin.ID, model.PermissionUsersImpersonate)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	adminCookie := testLogin(t, srv, admin.Email, "password")
+	userCookie := testLogin(t, srv, u.Email, "password")
+
+	do := func(cookie, method, path string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(srv, method, path, http.Header{"Cookie": {cookie}}, payload)
+	}
+
+	impersonate := func(cookie string, target *model.User) *httptest.ResponseRecorder {
+		return do(cookie, http.MethodPost, fmt.Sprintf("/admin/users/%d/impersonate", target.ID), nil)
+	}
+
+	t.Run("start", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, impersonate(userCookie, admin).Code)
+		assert.Equal(t, http.StatusBadRequest, impersonate(adminCookie, admin).Code)
+		assert.Equal(t, http.StatusForbidden, impersonate(adminCookie, otherAdmin).Code)
+		assert.Equal(t, http.StatusNotFound, impersonate(adminCookie, &model.User{}).Code)
+		assert.Equal(t, http.StatusBadRequest, do(userCookie, http.MethodDelete, "/sessions/impersonation", nil).Code)
+	})
+
+	rec := impersonate(adminCookie, u)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	cookie := rec.Header().Get("Set-Cookie")
+
+	sess := &model.Session{}
+	json.NewDecoder(rec.Body).Decode(sess)
+
+	t.Run("whoami", func(t *testing.T) {
+		rec := do(cookie, http.MethodGet, "/private/whoami", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		res := struct {
+			ID            int `json:"id"`
+			Impersonation *struct {
+				Impersonator *model.User `json:"impersonator"`
+				ExpiresAt    *time.Time  `json:"expires_at"`
+			} `json:"impersonation"`
+		}{}
+		json.NewDecoder(rec.Body).Decode(&res)
+
+		assert.Equal(t, u.ID, res.ID)
+
+		if assert.NotNil(t, res.Impersonation) {
+			assert.Equal(t, admin.ID, res.Impersonation.Impersonator.ID)
+			assert.WithinDuration(t, time.Now().Add(srv.config.ImpersonationTTL.Duration), *res.Impersonation.ExpiresAt, time.Minute)
+		}
+
+		rec = do(userCookie, http.MethodGet, "/private/whoami", nil)
+		assert.NotContains(t, rec.Body.String(), "impersonation")
+	})
+
+	t.Run("credentials", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(cookie, http.MethodPut, "/private/password", map[string]string{"password": "password", "new_password": "newpassword"}).Code)
+		assert.Equal(t, http.StatusForbidden, do(cookie, http.MethodPut, "/private/email", map[string]string{"password": "password", "email": "new@example.org"}).Code)
+		assert.Equal(t, http.StatusForbidden, do(cookie, http.MethodPost, "/private/tokens", map[string]string{"name": "token"}).Code)
+		assert.Equal(t, http.StatusForbidden, do(cookie, http.MethodPost, "/private/2fa", nil).Code)
+		assert.Equal(t, http.StatusForbidden, do(cookie, http.MethodDelete, "/private/sessions", nil).Code)
+		assert.Equal(t, http.StatusForbidden, do(cookie, http.MethodDelete, "/private/account", map[string]string{"password": "password"}).Code)
+		assert.Equal(t, http.StatusOK, do(cookie, http.MethodGet, "/private/sessions", nil).Code)
+	})
+
+	t.Run("end", func(t *testing.T) {
+		rec := do(cookie, http.MethodDelete, "/sessions/impersonation", nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		restored := rec.Header().Get("Set-Cookie")
+		assert.Equal(t, admin.ID, testWhoAmIUser(t, srv, restored))
+
+		assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, cookie))
+
+		rec = do(adminCookie, http.MethodGet, fmt.Sprintf("/admin/audit-events?target_id=%d&action=impersonation.ended", u.ID), nil)
+		assert.Contains(t, rec.Body.String(), sess.ID)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		rec := impersonate(adminCookie, u)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		cookie := rec.Header().Get("Set-Cookie")
+		assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, cookie))
+
+		json.NewDecoder(rec.Body).Decode(sess)
+		found, _ := store.Session().Find(sess.ID)
+		expiresAt := time.Now().Add(-time.Second)
+		found.ExpiresAt = &expiresAt
+
+		assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, cookie))
+	})
+}
+
+func testWhoAmIUser(t *testing.T, srv *server, cookie string) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+
+	u := &model.User{}
+	json.NewDecoder(rec.Body).Decode(u)
+
+	return u.ID
+}
@@ -0,0 +1,388 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+const tokenPurposeInvitation = "invitation"
+
+var (
+	errorInvalidInvitation    = errors.New("invalid or expired invitation")
+	errorInvitationRequired   = errors.New("signing up requires an invitation")
+	errorInvitationNotPending = errors.New("invitation has already been accepted or revoked")
+	errorInvitationEmail      = errors.New("invitation was sent to another email address")
+	errorUnknownRole          = errors.New("unknown role")
+)
+
+// invitationToken is the payload of the token mailed with an invitation. The
+// address is part of the payload so that a token is void if it does not match
+// the stored invitation.
+type invitationToken struct {
+	ID    int    `json:"id"`
+	Email string `json:"email"`
+}
+
+// handleInvitationCreate invites an email address to the organization of the
+// request or, outside of an organization, to sign up.
+func (s *server) handleInvitationCreate() http.HandlerFunc {
+
+	type request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		i := &model.Invitation{
+			Email:     req.Email,
+			Role:      req.Role,
+			ExpiresAt: time.Now().Add(s.config.InvitationTTL.Duration),
+		}
+
+		u, err := s.store.User().FindByEmail(req.Email)
+
+		if err != nil && err != store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if o, ok := r.Context().Value(contextKeyOrganization).(*model.Organization); ok {
+			i.OrganizationID = &o.ID
+
+			if u != nil {
+				if _, err := s.store.Membership().Find(o.ID, u.ID); err != store.ErrorRecordNotFound {
+					if err == nil {
+						s.error(rw, r, http.StatusConflict, errorAlreadyMember)
+						return
+					}
+
+					s.error(rw, r, http.StatusInternalServerError, err)
+					return
+				}
+			}
+
+			if !s.checkRoleChange(rw, r, &model.Membership{}, i.Role) {
+				return
+			}
+		} else {
+			if u != nil {
+				s.error(rw, r, http.StatusConflict, errorEmailTaken)
+				return
+			}
+
+			if i.Role != "" {
+				// Accepting the invitation assigns the role, so inviting with
+				// a role takes the same permission as assigning it.
+				ok, err := s.store.Role().HasPermission(r.Context().Value(contextKeyUser).(*model.User).ID, model.PermissionRolesWrite)
+
+				if err != nil {
+					s.error(rw, r, http.StatusInternalServerError, err)
+					return
+				}
+
+				if !ok {
+					s.error(rw, r, http.StatusForbidden, errorPermissionDenied)
+					return
+				}
+
+				if _, err := s.store.Role().FindByName(i.Role); err != nil {
+					if err == store.ErrorRecordNotFound {
+						s.error(rw, r, http.StatusUnprocessableEntity, errorUnknownRole)
+						return
+					}
+
+					s.error(rw, r, http.StatusInternalServerError, err)
+					return
+				}
+			}
+		}
+
+		if err := s.store.Invitation().Create(i); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.sendInvitation(i); err != nil {
+			s.logger.WithField("invitation_id", i.ID).Errorf("sending invitation: %v", err)
+		}
+
+		s.respond(rw, r, http.StatusCreated, i)
+	}
+}
+
+func (s *server) handleInvitationList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var (
+			invitations []*model.Invitation
+			err         error
+		)
+
+		if o, ok := r.Context().Value(contextKeyOrganization).(*model.Organization); ok {
+			invitations, err = s.store.Invitation().FindByOrganization(o.ID)
+		} else {
+			invitations, err = s.store.Invitation().FindWithoutOrganization()
+		}
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, invitations)
+	}
+}
+
+func (s *server) handleInvitationRevoke() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		i, ok := s.findInvitationVar(rw, r)
+
+		if !ok {
+			return
+		}
+
+		if err := s.store.Invitation().Revoke(i.ID); err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusConflict, errorInvitationNotPending)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleInvitationResend mails a new token for an invitation that has not
+// been accepted or revoked, and extends its expiry accordingly. Expired
+// invitations can be resent.
+func (s *server) handleInvitationResend() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		i, ok := s.findInvitationVar(rw, r)
+
+		if !ok {
+			return
+		}
+
+		renewed := *i
+		renewed.ExpiresAt = time.Now().Add(s.config.InvitationTTL.Duration)
+
+		if err := s.store.Invitation().Renew(&renewed); err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusConflict, errorInvitationNotPending)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.sendInvitation(&renewed); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, &renewed)
+	}
+}
+
+// handleInvitationAccept accepts an invitation on behalf of the current user,
+// which has to be the user the invitation was sent to.
+func (s *server) handleInvitationAccept() http.HandlerFunc {
+
+	type request struct {
+		Token string `json:"token"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		i, err := s.findInvitation(req.Token)
+
+		if err != nil {
+			if err == errorInvalidInvitation {
+				s.error(rw, r, http.StatusBadRequest, err)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		if !strings.EqualFold(u.Email, i.Email) {
+			s.error(rw, r, http.StatusForbidden, errorInvitationEmail)
+			return
+		}
+
+		if err := s.acceptInvitation(r, u, i); err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusBadRequest, errorInvalidInvitation)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, i)
+	}
+}
+
+// findInvitation resolves an invitation token to the invitation, as long as
+// the invitation can still be accepted. Tokens that can not be redeemed
+// result in errorInvalidInvitation.
+func (s *server) findInvitation(token string) (*model.Invitation, error) {
+	t := &invitationToken{}
+
+	if err := s.verifyToken(tokenPurposeInvitation, s.config.InvitationTTL.Duration, token, t); err != nil {
+		return nil, errorInvalidInvitation
+	}
+
+	i, err := s.store.Invitation().Find(t.ID)
+
+	if err != nil {
+		if err == store.ErrorRecordNotFound {
+			return nil, errorInvalidInvitation
+		}
+
+		return nil, err
+	}
+
+	if i.Email != t.Email || !i.IsPending() {
+		return nil, errorInvalidInvitation
+	}
+
+	return i, nil
+}
+
+// acceptInvitation consumes an invitation for u. Invitations to an
+// organization make u a member with the role of the invitation, unless u
+// already is a member. Other invitations assign their role, if any. It fails
+// with store.ErrorRecordNotFound if the invitation has been accepted or
+// revoked in the meantime.
+func (s *server) acceptInvitation(r *http.Request, u *model.User, i *model.Invitation) error {
+	if err := s.store.Invitation().MarkAccepted(i.ID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	i.AcceptedAt = &now
+
+	if i.OrganizationID != nil {
+		if _, err := s.store.Membership().Find(*i.OrganizationID, u.ID); err != store.ErrorRecordNotFound {
+			return err
+		}
+
+		return s.store.Membership().Create(&model.Membership{
+			OrganizationID: *i.OrganizationID,
+			UserID:         u.ID,
+			Role:           i.Role,
+		})
+	}
+
+	if i.Role == "" {
+		return nil
+	}
+
+	role, err := s.store.Role().FindByName(i.Role)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Role().Assign(u.ID, role.ID); err != nil {
+		return err
+	}
+
+	s.audit(r, model.AuditRoleAssigned, u.ID, u.ID, map[string]string{
+		"role":          role.Name,
+		"invitation_id": strconv.Itoa(i.ID),
+	})
+
+	return nil
+}
+
+// sendInvitation mails a token for i to the invited address.
+func (s *server) sendInvitation(i *model.Invitation) error {
+	token, err := s.signToken(tokenPurposeInvitation, &invitationToken{
+		ID:    i.ID,
+		Email: i.Email,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	subject := "You have been invited to sign up"
+
+	if i.OrganizationID != nil {
+		o, err := s.store.Organization().Find(*i.OrganizationID)
+
+		if err != nil {
+			return err
+		}
+
+		subject = fmt.Sprintf("You have been invited to join %s", o.Name)
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      i.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("%s. Use the following token to accept the invitation:\n\n%s\n", subject, token),
+	})
+}
+
+// findInvitationVar loads the invitation whose ID is in the URL. Under an
+// organization, only invitations to that organization are found, and
+// elsewhere only invitations to sign up. It responds itself and returns false
+// when there is no such invitation.
+func (s *server) findInvitationVar(rw http.ResponseWriter, r *http.Request) (*model.Invitation, bool) {
+	id, _ := strconv.Atoi(mux.Vars(r)["invitation"])
+
+	i, err := s.store.Invitation().Find(id)
+
+	if err != nil && err != store.ErrorRecordNotFound {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	found := err == nil
+
+	if o, ok := r.Context().Value(contextKeyOrganization).(*model.Organization); ok {
+		found = found && i.OrganizationID != nil && *i.OrganizationID == o.ID
+	} else {
+		found = found && i.OrganizationID == nil
+	}
+
+	if !found {
+		s.error(rw, r, http.StatusNotFound, store.ErrorRecordNotFound)
+		return nil, false
+	}
+
+	return i, true
+}
@@ -0,0 +1,204 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleOrganizationInvitations(t *testing.T) {
+
+	owner := model.TestUser(t)
+	owner.Email = "owner@example.org"
+
+	existing := model.TestUser(t)
+	existing.Email = "existing@example.org"
+
+	other := model.TestUser(t)
+
+	store := teststore.New()
+
+	for _, u := range []*model.User{owner, existing, other} {
+		store.User().Create(u)
+	}
+
+	o := model.TestOrganization(t)
+	store.Organization().Create(o)
+	store.Membership().Create(&model.Membership{OrganizationID: o.ID, UserID: owner.ID, Role: model.OrganizationRoleOwner})
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	cookies := map[*model.User]string{}
+
+	for _, u := range []*model.User{owner, existing, other} {
+		cookies[u] = testLogin(t, srv, u.Email, "password")
+	}
+
+	do := func(u *model.User, method, path string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(srv, method, path, http.Header{"Cookie": {cookies[u]}}, payload)
+	}
+
+	invitations := fmt.Sprintf("/orgs/%d/invitations", o.ID)
+
+	invite := func(email, role string) *model.Invitation {
+		rec := do(owner, http.MethodPost, invitations, map[string]string{"email": email, "role": role})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		i := &model.Invitation{}
+		json.NewDecoder(rec.Body).Decode(i)
+		return i
+	}
+
+	signup := func(email, token string) int {
+		return do(nil, http.MethodPost, "/users", map[string]string{
+			"email":      email,
+			"password":   "password",
+			"invitation": token,
+		}).Code
+	}
+
+	t.Run("create", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(owner, http.MethodPost, invitations, "invalid").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, do(owner, http.MethodPost, invitations, map[string]string{"email": "invalid", "role": model.OrganizationRoleMember}).Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, do(owner, http.MethodPost, invitations, map[string]string{"email": "new@example.org", "role": "superuser"}).Code)
+		assert.Equal(t, http.StatusConflict, do(owner, http.MethodPost, invitations, map[string]string{"email": owner.Email, "role": model.OrganizationRoleMember}).Code)
+		assert.Equal(t, http.StatusNotFound, do(other, http.MethodPost, invitations, map[string]string{"email": "new@example.org", "role": model.OrganizationRoleMember}).Code)
+	})
+
+	t.Run("accept by signing up", func(t *testing.T) {
+		invite("new@example.org", model.OrganizationRoleAdmin)
+
+		token := testMailToken(m.Last("new@example.org"))
+
+		assert.Equal(t, http.StatusBadRequest, signup("other@example.org", token))
+		assert.Equal(t, http.StatusBadRequest, signup("new@example.org", "invalid"))
+		assert.Equal(t, http.StatusCreated, signup("new@example.org", token))
+		assert.Equal(t, http.StatusBadRequest, signup("again@example.org", token))
+
+		u, err := store.User().FindByEmail("new@example.org")
+		assert.NoError(t, err)
+		assert.True(t, u.IsEmailVerified())
+
+		membership, err := store.Membership().Find(o.ID, u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.OrganizationRoleAdmin, membership.Role)
+	})
+
+	t.Run("accept when logged in", func(t *testing.T) {
+		invite(existing.Email, model.OrganizationRoleMember)
+
+		token := testMailToken(m.Last(existing.Email))
+
+		assert.Equal(t, http.StatusForbidden, do(other, http.MethodPost, "/private/invitations/accept", map[string]string{"token": token}).Code)
+		assert.Equal(t, http.StatusBadRequest, do(existing, http.MethodPost, "/private/invitations/accept", map[string]string{"token": "invalid"}).Code)
+		assert.Equal(t, http.StatusOK, do(existing, http.MethodPost, "/private/invitations/accept", map[string]string{"token": token}).Code)
+		assert.Equal(t, http.StatusBadRequest, do(existing, http.MethodPost, "/private/invitations/accept", map[string]string{"token": token}).Code)
+
+		_, err := store.Membership().Find(o.ID, existing.ID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusForbidden, do(existing, http.MethodGet, invitations, nil).Code)
+	})
+
+	t.Run("revoke and resend", func(t *testing.T) {
+		i := invite("revoked@example.org", model.OrganizationRoleMember)
+		path := fmt.Sprintf("%s/%d", invitations, i.ID)
+		token := testMailToken(m.Last("revoked@example.org"))
+
+		assert.Equal(t, http.StatusNoContent, do(owner, http.MethodDelete, path, nil).Code)
+		assert.Equal(t, http.StatusConflict, do(owner, http.MethodDelete, path, nil).Code)
+		assert.Equal(t, http.StatusConflict, do(owner, http.MethodPost, path+"/resend", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, signup("revoked@example.org", token))
+		assert.Equal(t, http.StatusNotFound, do(owner, http.MethodDelete, fmt.Sprintf("%s/%d", invitations, 0), nil).Code)
+
+		i = invite("expired@example.org", model.OrganizationRoleMember)
+		path = fmt.Sprintf("%s/%d", invitations, i.ID)
+		token = testMailToken(m.Last("expired@example.org"))
+
+		stored, _ := store.Invitation().Find(i.ID)
+		stored.ExpiresAt = time.Now().Add(-time.Minute)
+
+		assert.Equal(t, http.StatusBadRequest, signup("expired@example.org", token))
+		assert.Equal(t, http.StatusOK, do(owner, http.MethodPost, path+"/resend", nil).Code)
+		assert.Equal(t, http.StatusCreated, signup("expired@example.org", testMailToken(m.Last("expired@example.org"))))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rec := do(owner, http.MethodGet, invitations, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		res := []*model.Invitation{}
+		json.NewDecoder(rec.Body).Decode(&res)
+		assert.Len(t, res, 4)
+	})
+}
+
+func Test_HandleInvitationSignUp(t *testing.T) {
+
+	admin := model.TestUser(t)
+
+	store := teststore.New()
+
+	recruiter := model.TestUser(t)
+	recruiter.Email = "recruiter@example.org"
+
+	store.User().Create(admin)
+	store.User().Create(recruiter)
+
+	testGrant(t, store, admin.ID, model.PermissionUsersRead, model.PermissionUsersWrite, model.PermissionRolesWrite)
+	testGrant(t, store, recruiter.ID, model.PermissionUsersWrite)
+
+	support := &model.Role{Name: "support", Permissions: []string{model.PermissionUsersRead}}
+	store.Role().Create(support)
+
+	config := testConfig()
+	config.InviteOnly = true
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), config)
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	cookie := testLogin(t, srv, admin.Email, "password")
+	recruiterCookie := testLogin(t, srv, recruiter.Email, "password")
+
+	do := func(cookie, method, path string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(srv, method, path, http.Header{"Cookie": {cookie}}, payload)
+	}
+
+	assert.Equal(t, http.StatusForbidden, do("", http.MethodPost, "/users", map[string]string{"email": "new@example.org", "password": "password"}).Code)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(cookie, http.MethodPost, "/admin/invitations", map[string]string{"email": "new@example.org", "role": "unknown"}).Code)
+	assert.Equal(t, http.StatusConflict, do(cookie, http.MethodPost, "/admin/invitations", map[string]string{"email": admin.Email}).Code)
+	assert.Equal(t, http.StatusForbidden, do(recruiterCookie, http.MethodPost, "/admin/invitations", map[string]string{"email": "new@example.org", "role": "admin"}).Code)
+	assert.Equal(t, http.StatusCreated, do(recruiterCookie, http.MethodPost, "/admin/invitations", map[string]string{"email": "norole@example.org"}).Code)
+	assert.Equal(t, http.StatusCreated, do(cookie, http.MethodPost, "/admin/invitations", map[string]string{"email": "new@example.org", "role": support.Name}).Code)
+
+	rec := do(cookie, http.MethodGet, "/admin/invitations", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := []*model.Invitation{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	assert.Len(t, res, 2)
+
+	rec = do("", http.MethodPost, "/users", map[string]string{
+		"email":      "new@example.org",
+		"password":   "password",
+		"invitation": testMailToken(m.Last("new@example.org")),
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	u, _ := store.User().FindByEmail("new@example.org")
+	ok, _ := store.Role().HasPermission(u.ID, model.PermissionUsersRead)
+	assert.True(t, ok)
+}
@@ -0,0 +1,42 @@
+package apiserver
+
+import "sync"
+
+// logSampler decides which requests get logged, by route. A route with rate
+// n is logged once every n requests and a route with rate 0 not at all.
+// Routes without a rate are always logged.
+type logSampler struct {
+	rates map[string]int
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newLogSampler(rates map[string]int) *logSampler {
+	return &logSampler{
+		rates:  rates,
+		counts: make(map[string]int),
+	}
+}
+
+// sample reports whether the next request of route is to be logged. route is
+// the path template of the route, such as "/orgs/{id:[0-9]+}".
+func (s *logSampler) sample(route string) bool {
+	n, ok := s.rates[route]
+
+	if !ok || n == 1 {
+		return true
+	}
+
+	if n <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := s.counts[route]
+	s.counts[route] = (count + 1) % n
+
+	return count == 0
+}
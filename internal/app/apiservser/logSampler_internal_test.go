@@ -0,0 +1,45 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSampler_Sample(t *testing.T) {
+	s := newLogSampler(map[string]int{
+		"/health":  3,
+		"/metrics": 0,
+		"/users":   1,
+	})
+
+	testCases := []struct {
+		route    string
+		expected []bool
+	}{
+		{
+			route:    "/health",
+			expected: []bool{true, false, false, true, false, false, true},
+		},
+		{
+			route:    "/metrics",
+			expected: []bool{false, false, false},
+		},
+		{
+			route:    "/users",
+			expected: []bool{true, true, true},
+		},
+		{
+			route:    "/sessions",
+			expected: []bool{true, true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.route, func(t *testing.T) {
+			for i, expected := range tc.expected {
+				assert.Equal(t, expected, s.sample(tc.route), "request %d", i)
+			}
+		})
+	}
+}
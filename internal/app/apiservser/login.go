@@ -0,0 +1,179 @@
+package apiserver
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/throttle"
+)
+
+var (
+	errorTooManyAttempts = errors.New("too many failed login attempts")
+)
+
+// configureThrottles sets up the per account and per client IP login
+// throttles on top of ts.
+func (s *server) configureThrottles(ts throttle.Store) {
+	s.accountThrottle = throttle.New(ts, "login:account:", throttle.Policy{
+		FreeAttempts:     s.config.LoginFreeAttempts,
+		BaseDelay:        s.config.LoginBackoffBase.Duration,
+		MaxDelay:         s.config.LoginBackoffMax.Duration,
+		LockoutThreshold: s.config.LoginLockoutThreshold,
+		LockoutDuration:  s.config.LoginLockoutDuration.Duration,
+		Window:           s.config.LoginFailureWindow.Duration,
+	})
+
+	s.ipThrottle = throttle.New(ts, "login:ip:", throttle.Policy{
+		FreeAttempts: s.config.LoginIPFreeAttempts,
+		BaseDelay:    s.config.LoginBackoffBase.Duration,
+		MaxDelay:     s.config.LoginBackoffMax.Duration,
+		Window:       s.config.LoginFailureWindow.Duration,
+	})
+
+	// Every login link or password reset requested counts against the
+	// address and the client IP, so that the mails can not be used to flood
+	// an inbox.
+	s.magicLinkEmailThrottle = throttle.New(ts, "magic-link:email:", throttle.Policy{
+		FreeAttempts: s.config.MagicLinkFreeLinks,
+		BaseDelay:    s.config.LoginBackoffBase.Duration,
+		MaxDelay:     s.config.LoginBackoffMax.Duration,
+		Window:       s.config.LoginFailureWindow.Duration,
+	})
+
+	s.magicLinkIPThrottle = throttle.New(ts, "magic-link:ip:", throttle.Policy{
+		FreeAttempts: s.config.MagicLinkIPFreeLinks,
+		BaseDelay:    s.config.LoginBackoffBase.Duration,
+		MaxDelay:     s.config.LoginBackoffMax.Duration,
+		Window:       s.config.LoginFailureWindow.Duration,
+	})
+
+	s.passwordResetEmailThrottle = throttle.New(ts, "password-reset:email:", throttle.Policy{
+		FreeAttempts: s.config.PasswordResetMails,
+		BaseDelay:    s.config.LoginBackoffBase.Duration,
+		MaxDelay:     s.config.LoginBackoffMax.Duration,
+		Window:       s.config.LoginFailureWindow.Duration,
+	})
+
+	s.passwordResetIPThrottle = throttle.New(ts, "password-reset:ip:", throttle.Policy{
+		FreeAttempts: s.config.PasswordResetIPMails,
+		BaseDelay:    s.config.LoginBackoffBase.Duration,
+		MaxDelay:     s.config.LoginBackoffMax.Duration,
+		Window:       s.config.LoginFailureWindow.Duration,
+	})
+}
+
+// checkCredentials authenticates a login attempt with an email and a
+// password. Failed attempts are throttled per account and per client IP. It
+// responds itself and returns false when the attempt is rejected.
+func (s *server) checkCredentials(rw http.ResponseWriter, r *http.Request, email, password string) (*model.User, bool) {
+	account, ip := accountKey(email), clientIP(r)
+
+	wait, err := s.loginWait(account, ip)
+
+	if err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if wait > 0 {
+		s.tooManyAttempts(rw, r, wait)
+		return nil, false
+	}
+
+	u, err := s.authenticate(r, email, password)
+
+	// No authenticator accepted the credentials, whatever the error. The
+	// attempt counts as failed even when an authenticator could not decide,
+	// or else passwords could be guessed while the directory is failing.
+	if err != nil {
+		s.auditLoginFailure(r, email)
+
+		if _, err := s.accountThrottle.Fail(account); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return nil, false
+		}
+
+		if _, err := s.ipThrottle.Fail(ip); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return nil, false
+		}
+
+		switch err {
+		case errorIncorrectEmailOrPassword:
+			s.error(rw, r, http.StatusUnauthorized, err)
+		case errorDirectoryUnavailable:
+			s.error(rw, r, http.StatusBadGateway, err)
+		default:
+			s.error(rw, r, http.StatusInternalServerError, err)
+		}
+
+		return nil, false
+	}
+
+	// With two-factor authentication the account throttle is only reset
+	// once the second factor has been verified as well.
+	if !u.IsTOTPEnabled() {
+		if err := s.accountThrottle.Reset(account); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return nil, false
+		}
+	}
+
+	if err := s.loginAllowed(u); err != nil {
+		s.error(rw, r, http.StatusForbidden, err)
+		return nil, false
+	}
+
+	return u, true
+}
+
+// loginWait returns how long the account and the client IP have to wait
+// before they may attempt to log in again.
+func (s *server) loginWait(account, ip string) (time.Duration, error) {
+	accountWait, err := s.accountThrottle.Check(account)
+
+	if err != nil {
+		return 0, err
+	}
+
+	ipWait, err := s.ipThrottle.Check(ip)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if ipWait > accountWait {
+		return ipWait, nil
+	}
+
+	return accountWait, nil
+}
+
+func (s *server) tooManyAttempts(rw http.ResponseWriter, r *http.Request, wait time.Duration) {
+	s.tooManyRequests(rw, r, wait, errorTooManyAttempts)
+}
+
+func (s *server) tooManyRequests(rw http.ResponseWriter, r *http.Request, wait time.Duration, err error) {
+	rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	s.error(rw, r, http.StatusTooManyRequests, err)
+}
+
+// auditLoginFailure records a failed password check for the account with the
+// given login. The login may not belong to any user.
+func (s *server) auditLoginFailure(r *http.Request, login string) {
+	targetID := 0
+
+	if u, err := s.store.User().FindByEmail(login); err == nil {
+		targetID = u.ID
+	}
+
+	s.audit(r, model.AuditLoginFailed, 0, targetID, map[string]string{"login": login})
+}
+
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
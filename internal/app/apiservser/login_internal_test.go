@@ -0,0 +1,147 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_HandleSessionCreate_Throttle(t *testing.T) {
+
+	u := model.TestUser(t)
+	other := model.TestUser(t)
+	other.Email = "other@example.org"
+
+	store := teststore.New()
+
+	store.User().Create(u)
+	store.User().Create(other)
+
+	newTestServer := func(configure func(*Config)) *server {
+		config := testConfig()
+		config.LoginFreeAttempts = 100
+		config.LoginIPFreeAttempts = 100
+		config.LoginBackoffBase = Duration{time.Minute}
+		config.LoginBackoffMax = Duration{time.Hour}
+		config.LoginLockoutThreshold = 0
+		configure(config)
+
+		return newServer(store, sessions.NewCookieStore([]byte("secret")), config)
+	}
+
+	login := func(srv *server, email, password string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{
+			"email":    email,
+			"password": password,
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/sessions", b)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("backoff", func(t *testing.T) {
+		srv := newTestServer(func(c *Config) {
+			c.LoginFreeAttempts = 1
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, login(srv, u.Email, "invalid").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(srv, u.Email, "invalid").Code)
+
+		rec := login(srv, u.Email, "password")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, login(srv, other.Email, "password").Code)
+	})
+
+	t.Run("lockout", func(t *testing.T) {
+		srv := newTestServer(func(c *Config) {
+			c.LoginLockoutThreshold = 2
+			c.LoginLockoutDuration = Duration{time.Hour}
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, login(srv, u.Email, "invalid").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(srv, u.Email, "invalid").Code)
+
+		rec := login(srv, u.Email, "password")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "3600", rec.Header().Get("Retry-After"))
+
+		p := model.TestPasswordReset(t, u.ID)
+		store.PasswordReset().Create(p)
+
+		rec = httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{"password": "password"})
+		req, _ := http.NewRequest(http.MethodPut, "/password-resets/"+p.Token, b)
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		assert.Equal(t, http.StatusOK, login(srv, u.Email, "password").Code)
+	})
+
+	t.Run("reset on success", func(t *testing.T) {
+		srv := newTestServer(func(c *Config) {
+			c.LoginLockoutThreshold = 2
+			c.LoginLockoutDuration = Duration{time.Hour}
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, login(srv, u.Email, "invalid").Code)
+		assert.Equal(t, http.StatusOK, login(srv, u.Email, "password").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(srv, u.Email, "invalid").Code)
+		assert.Equal(t, http.StatusOK, login(srv, u.Email, "password").Code)
+	})
+
+	t.Run("client ip", func(t *testing.T) {
+		srv := newTestServer(func(c *Config) {
+			c.LoginIPFreeAttempts = 1
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, login(srv, u.Email, "invalid").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(srv, other.Email, "invalid").Code)
+		assert.Equal(t, http.StatusTooManyRequests, login(srv, "unknown@example.org", "password").Code)
+	})
+}
+
+func Test_HandleSessionCreate_Rehash(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	model.SetPasswordHasher(&model.Argon2idHasher{
+		Memory:  1024,
+		Time:    1,
+		Threads: 1,
+		SaltLen: 16,
+		KeyLen:  32,
+	})
+	defer model.SetPasswordHasher(&model.BcryptHasher{Cost: bcrypt.MinCost})
+
+	found, _ := store.User().Find(u.ID)
+	assert.True(t, found.NeedsRehash())
+
+	testLogin(t, srv, u.Email, "password")
+
+	found, _ = store.User().Find(u.ID)
+	assert.False(t, found.NeedsRehash())
+	assert.True(t, strings.HasPrefix(found.EncryptedPassword, "$argon2id$"))
+
+	testLogin(t, srv, u.Email, "password")
+}
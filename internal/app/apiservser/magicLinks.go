@@ -0,0 +1,243 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/throttle"
+
+	"github.com/gorilla/mux"
+)
+
+const tokenPurposeMagicLink = "magic-link"
+
+var (
+	errorInvalidMagicLink  = errors.New("invalid or expired login link")
+	errorMagicLinkBrowser  = errors.New("login link was requested from another browser")
+	errorTooManyMagicLinks = errors.New("too many login links requested")
+)
+
+// handleMagicLinkCreate mails a login link to the given address. The link is
+// bound to the requesting browser through a nonce kept in its session cookie.
+// It responds the same way whether or not the address belongs to a user, and
+// the mail is sent in the background so that the response time does not tell
+// either. Requests are throttled per address and per client IP.
+func (s *server) handleMagicLinkCreate() http.HandlerFunc {
+
+	type request struct {
+		Email string `json:"email"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if !s.allowMail(rw, r, req.Email, s.magicLinkEmailThrottle, s.magicLinkIPThrottle, errorTooManyMagicLinks) {
+			return
+		}
+
+		nonce, err := s.magicLinkNonce(rw, r)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.runInBackground(r, func() error {
+			return s.sendMagicLink(req.Email, nonce)
+		})
+
+		s.respond(rw, r, http.StatusAccepted, nil)
+	}
+}
+
+// handleMagicLinkRedeem logs the owner of a link in, the same way a password
+// login does. A link opened in another browser than it was requested from is
+// rejected without being consumed, so that it can still be used in the right
+// one.
+func (s *server) handleMagicLinkRedeem() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var token string
+
+		if err := s.verifyToken(tokenPurposeMagicLink, s.config.MagicLinkTTL.Duration, mux.Vars(r)["token"], &token); err != nil {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidMagicLink)
+			return
+		}
+
+		l, err := s.store.MagicLink().FindByToken(token)
+
+		if err != nil || l.IsUsed() || l.IsExpired() {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidMagicLink)
+			return
+		}
+
+		session, err := s.sessionStore.Get(r, sessionName)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		nonce, _ := session.Values["magic_link_nonce"].(string)
+
+		if nonce == "" || model.HashToken(nonce) != l.NonceHash {
+			s.error(rw, r, http.StatusForbidden, errorMagicLinkBrowser)
+			return
+		}
+
+		if err := s.store.MagicLink().MarkUsed(l.ID); err != nil {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidMagicLink)
+			return
+		}
+
+		u, err := s.store.User().Find(l.UserID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidMagicLink)
+			return
+		}
+
+		if err := s.loginAllowed(u); err != nil {
+			s.error(rw, r, http.StatusForbidden, err)
+			return
+		}
+
+		delete(session.Values, "magic_link_nonce")
+
+		if u.IsTOTPEnabled() {
+			if err := s.startTwoFactorChallenge(rw, r, u); err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.respond(rw, r, http.StatusAccepted, map[string]bool{"two_factor_required": true})
+			return
+		}
+
+		if err := s.startSession(rw, r, u); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, nil)
+	}
+}
+
+// allowMail counts a request for a mail to email against the address and the
+// client IP. It responds itself, with tooMany, and returns false when either
+// has to wait.
+func (s *server) allowMail(rw http.ResponseWriter, r *http.Request, email string, emailThrottle, ipThrottle *throttle.Throttler, tooMany error) bool {
+	account, ip := accountKey(email), clientIP(r)
+
+	for _, check := range []struct {
+		throttle *throttle.Throttler
+		key      string
+	}{
+		{emailThrottle, account},
+		{ipThrottle, ip},
+	} {
+		wait, err := check.throttle.Check(check.key)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return false
+		}
+
+		if wait > 0 {
+			s.tooManyRequests(rw, r, wait, tooMany)
+			return false
+		}
+	}
+
+	if _, err := emailThrottle.Fail(account); err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return false
+	}
+
+	if _, err := ipThrottle.Fail(ip); err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return false
+	}
+
+	return true
+}
+
+// sendMagicLink creates a login link for the user with the given address,
+// bound to nonce, and mails it to them. It does nothing if no user has the
+// address.
+func (s *server) sendMagicLink(email, nonce string) error {
+	u, err := s.store.User().FindByEmail(email)
+
+	if err == store.ErrorRecordNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	l := &model.MagicLink{
+		UserID:    u.ID,
+		NonceHash: model.HashToken(nonce),
+		ExpiresAt: time.Now().Add(s.config.MagicLinkTTL.Duration),
+	}
+
+	if err := s.store.MagicLink().Create(l); err != nil {
+		return err
+	}
+
+	token, err := s.signToken(tokenPurposeMagicLink, l.Token)
+
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      u.Email,
+		Subject: "Your login link",
+		Body:    fmt.Sprintf("Use the following link to log in:\n\n%s\n", s.magicLinkURL(token)),
+	})
+}
+
+// magicLinkNonce returns the nonce that binds login links to the browser of
+// r, creating and storing it in the session cookie if there is none yet.
+func (s *server) magicLinkNonce(rw http.ResponseWriter, r *http.Request) (string, error) {
+	session, err := s.sessionStore.Get(r, sessionName)
+
+	if err != nil {
+		return "", err
+	}
+
+	if nonce, ok := session.Values["magic_link_nonce"].(string); ok && nonce != "" {
+		return nonce, nil
+	}
+
+	nonce, err := model.GenerateToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	session.Values["magic_link_nonce"] = nonce
+
+	if err := s.sessionStore.Save(r, rw, session); err != nil {
+		return "", err
+	}
+
+	return nonce, nil
+}
+
+func (s *server) magicLinkURL(token string) string {
+	return strings.TrimRight(s.config.PublicURL, "/") + "/sessions/magic-link/" + url.PathEscape(token)
+}
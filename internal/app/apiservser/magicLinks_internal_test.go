@@ -0,0 +1,177 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleMagicLinkCreate(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	create := func(email string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{"email": email})
+		req, _ := http.NewRequest(http.MethodPost, "/sessions/magic-link", b)
+		srv.ServeHTTP(rec, req)
+		srv.background.Wait()
+		return rec
+	}
+
+	for _, email := range []string{u.Email, "unknown@example.org"} {
+		rec := create(email)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("Set-Cookie"))
+	}
+
+	assert.NotNil(t, m.Last(u.Email))
+	assert.Nil(t, m.Last("unknown@example.org"))
+
+	srv.mailer = failingMailer{}
+	assert.Equal(t, http.StatusAccepted, create(u.Email).Code)
+
+	t.Run("throttled", func(t *testing.T) {
+		// The address has been requested once above. The request past the
+		// free ones is still served, but blocks the address.
+		for i := 1; i <= srv.config.MagicLinkFreeLinks; i++ {
+			assert.Equal(t, http.StatusAccepted, create("unknown@example.org").Code)
+		}
+
+		rec := create("unknown@example.org")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	})
+}
+
+func Test_HandleMagicLinkRedeem(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	request := func(cookie string) (string, string) {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{"email": u.Email})
+		req, _ := http.NewRequest(http.MethodPost, "/sessions/magic-link", b)
+		req.Header.Set("Cookie", cookie)
+		srv.ServeHTTP(rec, req)
+		srv.background.Wait()
+
+		link, _ := url.Parse(testMailToken(m.Last(u.Email)))
+
+		if c := rec.Header().Get("Set-Cookie"); c != "" {
+			cookie = c
+		}
+
+		return link.EscapedPath(), cookie
+	}
+
+	redeem := func(path, cookie string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Cookie", cookie)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	path, browser := request("")
+	_, other := request("")
+
+	expired := model.TestMagicLink(t, u.ID, "nonce")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	store.MagicLink().Create(expired)
+	expiredToken, _ := srv.signToken(tokenPurposeMagicLink, expired.Token)
+
+	unsigned := model.TestMagicLink(t, u.ID, "nonce")
+	store.MagicLink().Create(unsigned)
+
+	testCases := []struct {
+		name         string
+		path         string
+		cookie       string
+		expectedCode int
+	}{
+		{
+			name:         "unsigned token",
+			path:         "/sessions/magic-link/" + unsigned.Token,
+			cookie:       browser,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "expired",
+			path:         srv.magicLinkURL(expiredToken),
+			cookie:       browser,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "no cookie",
+			path:         path,
+			cookie:       "",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "other browser",
+			path:         path,
+			cookie:       other,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "valid",
+			path:         path,
+			cookie:       browser,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "replay",
+			path:         path,
+			cookie:       browser,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := redeem(tc.path, tc.cookie)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, rec.Header().Get("Set-Cookie")))
+			}
+		})
+	}
+
+	t.Run("same browser, several links", func(t *testing.T) {
+		first, cookie := request("")
+		second, cookie := request(cookie)
+
+		assert.Equal(t, http.StatusOK, redeem(first, cookie).Code)
+		assert.Equal(t, http.StatusOK, redeem(second, cookie).Code)
+	})
+}
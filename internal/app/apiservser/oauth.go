@@ -0,0 +1,570 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/oidc"
+	"webserver/internal/app/store"
+)
+
+const (
+	oauthCodeTTL                 = 5 * time.Minute
+	grantTypeAuthorizationCode   = "authorization_code"
+	grantTypeClientCredentials   = "client_credentials"
+	oauthInvalidRequest          = "invalid_request"
+	oauthInvalidClient           = "invalid_client"
+	oauthInvalidGrant            = "invalid_grant"
+	oauthInvalidScope            = "invalid_scope"
+	oauthUnauthorizedClient      = "unauthorized_client"
+	oauthUnsupportedGrantType    = "unsupported_grant_type"
+	oauthUnsupportedResponseType = "unsupported_response_type"
+	oauthAccessDenied            = "access_denied"
+)
+
+var (
+	errorInvalidClient           = errors.New("unknown client or invalid client credentials")
+	errorInvalidRedirectURI      = errors.New("redirect_uri is not registered for the client")
+	errorUnsupportedResponseType = errors.New("unsupported response type")
+	errorPKCERequired            = errors.New("a code_challenge with method S256 is required")
+	errorInvalidScope            = errors.New("scope is not allowed for the client")
+	errorClientAdminScope        = errors.New("admin scopes require an authorization by the user")
+	errorJSONRequired            = errors.New("content type must be application/json")
+	errorInvalidGrant            = errors.New("invalid, expired or already used authorization code")
+	errorAccessDenied            = errors.New("the user denied the request")
+	errorClientNotConfidential   = errors.New("grant requires a confidential client")
+)
+
+// authorizeRequest holds the parameters of an authorization request as
+// defined by RFC 6749, section 4.1.1, and RFC 7636.
+type authorizeRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+// handleOAuthAuthorize handles an authorization request of a logged in user.
+// If the user has already granted the requested scopes to the client, it
+// redirects back to the client with a code. Otherwise it responds with what
+// the user is asked to consent to, which is then answered through
+// handleOAuthConsent.
+func (s *server) handleOAuthAuthorize() http.HandlerFunc {
+
+	type response struct {
+		ClientID   string   `json:"client_id"`
+		ClientName string   `json:"client_name"`
+		Scopes     []string `json:"scopes"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		req := &authorizeRequest{
+			ResponseType:        q.Get("response_type"),
+			ClientID:            q.Get("client_id"),
+			RedirectURI:         q.Get("redirect_uri"),
+			Scope:               q.Get("scope"),
+			State:               q.Get("state"),
+			CodeChallenge:       q.Get("code_challenge"),
+			CodeChallengeMethod: q.Get("code_challenge_method"),
+		}
+
+		c, scopes, ok := s.checkAuthorizeRequest(rw, r, req)
+
+		if !ok {
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		consent, err := s.store.OAuthConsent().Find(u.ID, c.ID)
+
+		if err != nil && err != store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if consent == nil || !consent.Covers(scopes) {
+			s.respond(rw, r, http.StatusOK, &response{
+				ClientID:   c.ClientID,
+				ClientName: c.Name,
+				Scopes:     scopes,
+			})
+			return
+		}
+
+		s.grantAuthorization(rw, r, req, c, u, scopes)
+	}
+}
+
+// handleOAuthConsent records the decision of the user about an authorization
+// request. Since it is called by a script rather than navigated to, it
+// responds with the URI to redirect to instead of redirecting. Only JSON is
+// accepted, as browsers do not send JSON cross-site without a CORS preflight,
+// so that other sites cannot submit a consent with the cookie of the user.
+func (s *server) handleOAuthConsent() http.HandlerFunc {
+
+	type request struct {
+		authorizeRequest
+		Approve bool `json:"approve"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			s.error(rw, r, http.StatusUnsupportedMediaType, errorJSONRequired)
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c, scopes, ok := s.checkAuthorizeRequest(rw, r, &req.authorizeRequest)
+
+		if !ok {
+			return
+		}
+
+		if !req.Approve {
+			s.redirectAuthorization(rw, r, &req.authorizeRequest, url.Values{
+				"error":             {oauthAccessDenied},
+				"error_description": {errorAccessDenied.Error()},
+			})
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		consent, err := s.store.OAuthConsent().Find(u.ID, c.ID)
+
+		if err == store.ErrorRecordNotFound {
+			consent, err = &model.OAuthConsent{UserID: u.ID, ClientID: c.ID}, nil
+		}
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, scope := range scopes {
+			if !model.HasScope(consent.Scopes, scope) {
+				consent.Scopes = append(consent.Scopes, scope)
+			}
+		}
+
+		if err := s.store.OAuthConsent().Save(consent); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.grantAuthorization(rw, r, &req.authorizeRequest, c, u, scopes)
+	}
+}
+
+// handleOAuthToken is the token endpoint of RFC 6749, section 3.2. It
+// supports the authorization code grant, which requires PKCE, and the client
+// credentials grant, whose tokens act on behalf of the owner of the client.
+// As the owner takes no part in the latter, its tokens never carry admin
+// scopes, so that they are not backed by the permissions of the owner.
+func (s *server) handleOAuthToken() http.HandlerFunc {
+
+	type response struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Cache-Control", "no-store")
+		rw.Header().Set("Pragma", "no-cache")
+
+		c, ok := s.checkClient(rw, r)
+
+		if !ok {
+			return
+		}
+
+		var (
+			userID int
+			scopes []string
+		)
+
+		switch r.PostFormValue("grant_type") {
+		case grantTypeAuthorizationCode:
+			code, err := s.store.OAuthCode().FindByCode(r.PostFormValue("code"))
+
+			if err != nil && err != store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if err != nil ||
+				code.IsUsed() ||
+				code.IsExpired() ||
+				code.ClientID != c.ID ||
+				code.RedirectURI != r.PostFormValue("redirect_uri") ||
+				oidc.Challenge(r.PostFormValue("code_verifier")) != code.Challenge {
+				s.oauthError(rw, r, http.StatusBadRequest, oauthInvalidGrant, errorInvalidGrant)
+				return
+			}
+
+			if err := s.store.OAuthCode().MarkUsed(code.ID); err != nil {
+				s.oauthError(rw, r, http.StatusBadRequest, oauthInvalidGrant, errorInvalidGrant)
+				return
+			}
+
+			userID, scopes = code.UserID, code.Scopes
+		case grantTypeClientCredentials:
+			if !c.Confidential {
+				s.oauthError(rw, r, http.StatusBadRequest, oauthUnauthorizedClient, errorClientNotConfidential)
+				return
+			}
+
+			requested, ok := clientScopes(c, r.PostFormValue("scope"))
+
+			if !ok {
+				s.oauthError(rw, r, http.StatusBadRequest, oauthInvalidScope, errorInvalidScope)
+				return
+			}
+
+			if r.PostFormValue("scope") == "" {
+				requested = withoutAdminScopes(requested)
+			}
+
+			if model.HasScope(requested, model.ScopeAdminRead) || model.HasScope(requested, model.ScopeAdminWrite) {
+				s.oauthError(rw, r, http.StatusBadRequest, oauthInvalidScope, errorClientAdminScope)
+				return
+			}
+
+			userID, scopes = c.UserID, requested
+		default:
+			s.oauthError(rw, r, http.StatusBadRequest, oauthUnsupportedGrantType, errorUnsupportedGrantType)
+			return
+		}
+
+		if _, err := s.store.User().Find(userID); err != nil {
+			s.oauthError(rw, r, http.StatusBadRequest, oauthInvalidGrant, errorInvalidGrant)
+			return
+		}
+
+		t := &model.OAuthToken{
+			ClientID:  c.ID,
+			UserID:    userID,
+			Scopes:    scopes,
+			ExpiresAt: time.Now().Add(s.config.OAuthAccessTokenTTL.Duration),
+		}
+
+		if err := s.store.OAuthToken().Create(t); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, &response{
+			AccessToken: t.Token,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(s.config.OAuthAccessTokenTTL.Seconds()),
+			Scope:       strings.Join(t.Scopes, " "),
+		})
+	}
+}
+
+// handleOAuthIntrospect implements token introspection as defined by RFC
+// 7662 for confidential clients, such as resource servers.
+func (s *server) handleOAuthIntrospect() http.HandlerFunc {
+
+	type response struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		Username  string `json:"username,omitempty"`
+		Subject   string `json:"sub,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+		ExpiresAt int64  `json:"exp,omitempty"`
+		IssuedAt  int64  `json:"iat,omitempty"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		c, ok := s.checkClient(rw, r)
+
+		if !ok {
+			return
+		}
+
+		if !c.Confidential {
+			s.oauthError(rw, r, http.StatusUnauthorized, oauthInvalidClient, errorClientNotConfidential)
+			return
+		}
+
+		t, err := s.store.OAuthToken().FindByToken(r.PostFormValue("token"))
+
+		if err != nil && err != store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err != nil || !t.IsActive() {
+			s.respond(rw, r, http.StatusOK, &response{})
+			return
+		}
+
+		u, err := s.store.User().Find(t.UserID)
+
+		if err != nil {
+			s.respond(rw, r, http.StatusOK, &response{})
+			return
+		}
+
+		owner, err := s.store.OAuthClient().Find(t.ClientID)
+
+		if err != nil {
+			s.respond(rw, r, http.StatusOK, &response{})
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, &response{
+			Active:    true,
+			Scope:     strings.Join(t.Scopes, " "),
+			ClientID:  owner.ClientID,
+			Username:  u.Email,
+			Subject:   strconv.Itoa(u.ID),
+			TokenType: "Bearer",
+			ExpiresAt: t.ExpiresAt.Unix(),
+			IssuedAt:  t.CreatedAt.Unix(),
+		})
+	}
+}
+
+// handleOAuthRevoke implements token revocation as defined by RFC 7009. A
+// client can only revoke its own tokens, and the response is the same
+// whether or not the token was known.
+func (s *server) handleOAuthRevoke() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		c, ok := s.checkClient(rw, r)
+
+		if !ok {
+			return
+		}
+
+		t, err := s.store.OAuthToken().FindByToken(r.PostFormValue("token"))
+
+		if err == nil && t.ClientID == c.ID {
+			err = s.store.OAuthToken().Revoke(t.ID)
+		}
+
+		if err != nil && err != store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, nil)
+	}
+}
+
+// authenticateOAuthToken resolves the user behind a token issued to an OAuth
+// client. Such tokens are always restricted to their scopes.
+func (s *server) authenticateOAuthToken(token string) (*model.User, []string, error) {
+	t, err := s.store.OAuthToken().FindByToken(token)
+
+	if err != nil || !t.IsActive() {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	u, err := s.store.User().Find(t.UserID)
+
+	if err != nil {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	if t.Scopes == nil {
+		return u, []string{}, nil
+	}
+
+	return u, t.Scopes, nil
+}
+
+// checkAuthorizeRequest validates an authorization request and returns the
+// client and the requested scopes. The request has to come from a browser
+// session. Problems with the client or the redirect URI are reported to the
+// user agent, all others to the client through the redirect URI. It responds
+// itself and returns false when the request is invalid.
+func (s *server) checkAuthorizeRequest(rw http.ResponseWriter, r *http.Request, req *authorizeRequest) (*model.OAuthClient, []string, bool) {
+	if currentSessionID(r) == "" {
+		s.error(rw, r, http.StatusForbidden, errorNoSession)
+		return nil, nil, false
+	}
+
+	c, err := s.store.OAuthClient().FindByClientID(req.ClientID)
+
+	if err == store.ErrorRecordNotFound {
+		s.error(rw, r, http.StatusBadRequest, errorInvalidClient)
+		return nil, nil, false
+	}
+
+	if err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	if !c.HasRedirectURI(req.RedirectURI) {
+		s.error(rw, r, http.StatusBadRequest, errorInvalidRedirectURI)
+		return nil, nil, false
+	}
+
+	fail := func(code string, err error) {
+		s.redirectAuthorization(rw, r, req, url.Values{
+			"error":             {code},
+			"error_description": {err.Error()},
+		})
+	}
+
+	if req.ResponseType != "code" {
+		fail(oauthUnsupportedResponseType, errorUnsupportedResponseType)
+		return nil, nil, false
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		fail(oauthInvalidRequest, errorPKCERequired)
+		return nil, nil, false
+	}
+
+	scopes, ok := clientScopes(c, req.Scope)
+
+	if !ok {
+		fail(oauthInvalidScope, errorInvalidScope)
+		return nil, nil, false
+	}
+
+	return c, scopes, true
+}
+
+// grantAuthorization issues an authorization code and sends it back to the
+// client.
+func (s *server) grantAuthorization(rw http.ResponseWriter, r *http.Request, req *authorizeRequest, c *model.OAuthClient, u *model.User, scopes []string) {
+	code := &model.OAuthCode{
+		ClientID:    c.ID,
+		UserID:      u.ID,
+		RedirectURI: req.RedirectURI,
+		Scopes:      scopes,
+		Challenge:   req.CodeChallenge,
+		ExpiresAt:   time.Now().Add(oauthCodeTTL),
+	}
+
+	if err := s.store.OAuthCode().Create(code); err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.redirectAuthorization(rw, r, req, url.Values{"code": {code.Code}})
+}
+
+// redirectAuthorization sends the result of an authorization request to the
+// redirect URI of the client. GET requests are redirected, other requests
+// receive the URI in the response body.
+func (s *server) redirectAuthorization(rw http.ResponseWriter, r *http.Request, req *authorizeRequest, params url.Values) {
+	u, err := url.Parse(req.RedirectURI)
+
+	if err != nil {
+		s.error(rw, r, http.StatusBadRequest, errorInvalidRedirectURI)
+		return
+	}
+
+	q := u.Query()
+
+	for key, values := range params {
+		q[key] = values
+	}
+
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+
+	u.RawQuery = q.Encode()
+
+	if r.Method == http.MethodGet {
+		http.Redirect(rw, r, u.String(), http.StatusFound)
+		return
+	}
+
+	s.respond(rw, r, http.StatusOK, map[string]string{"redirect_uri": u.String()})
+}
+
+// checkClient authenticates the client of a request to the token,
+// introspection or revocation endpoint, either with HTTP Basic
+// authentication or with the client_id and client_secret form parameters.
+// Public clients only present their ID. It responds itself and returns false
+// when the client can not be authenticated.
+func (s *server) checkClient(rw http.ResponseWriter, r *http.Request) (*model.OAuthClient, bool) {
+	id, secret, basic := r.BasicAuth()
+
+	if basic {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+
+	c, err := s.store.OAuthClient().FindByClientID(id)
+
+	if err != nil && err != store.ErrorRecordNotFound {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if err != nil || (c.Confidential && !c.CompareSecret(secret)) || (!c.Confidential && secret != "") {
+		s.oauthError(rw, r, http.StatusUnauthorized, oauthInvalidClient, errorInvalidClient)
+		return nil, false
+	}
+
+	return c, true
+}
+
+// clientScopes parses a space-delimited scope parameter. An empty parameter
+// requests every scope of the client. It reports false if the client may not
+// request one of the scopes.
+func clientScopes(c *model.OAuthClient, scope string) ([]string, bool) {
+	scopes := strings.Fields(scope)
+
+	if len(scopes) == 0 {
+		return c.Scopes, true
+	}
+
+	return scopes, model.HasScopes(c.Scopes, scopes)
+}
+
+// withoutAdminScopes returns scopes without admin:read and admin:write.
+func withoutAdminScopes(scopes []string) []string {
+	filtered := []string{}
+
+	for _, scope := range scopes {
+		if scope != model.ScopeAdminRead && scope != model.ScopeAdminWrite {
+			filtered = append(filtered, scope)
+		}
+	}
+
+	return filtered
+}
+
+// oauthError responds with an error as defined by RFC 6749, section 5.2.
+func (s *server) oauthError(rw http.ResponseWriter, r *http.Request, code int, oauthCode string, err error) {
+	if code == http.StatusUnauthorized {
+		rw.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	s.respond(rw, r, code, map[string]string{
+		"error":             oauthCode,
+		"error_description": err.Error(),
+	})
+}
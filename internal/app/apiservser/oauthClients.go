@@ -0,0 +1,80 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *server) handleOAuthClientList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		clients, err := s.store.OAuthClient().GetAll()
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, clients)
+	}
+}
+
+// handleOAuthClientCreate registers a client on behalf of the current user.
+// The secret of a confidential client is only ever part of this response.
+func (s *server) handleOAuthClientCreate() http.HandlerFunc {
+
+	type request struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		Confidential bool     `json:"confidential"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := &model.OAuthClient{
+			Name:         req.Name,
+			RedirectURIs: req.RedirectURIs,
+			Scopes:       req.Scopes,
+			Confidential: req.Confidential,
+			UserID:       r.Context().Value(contextKeyUser).(*model.User).ID,
+		}
+
+		if err := s.store.OAuthClient().Create(c); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusCreated, c)
+	}
+}
+
+// handleOAuthClientDelete removes a client, which also invalidates every
+// code and token issued to it.
+func (s *server) handleOAuthClientDelete() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		if err := s.store.OAuthClient().Delete(id); err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusNotFound, err)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
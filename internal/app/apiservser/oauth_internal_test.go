@@ -0,0 +1,474 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/oidc"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleOAuthClientCreate(t *testing.T) {
+
+	admin := model.TestUser(t)
+	u := model.TestUser(t)
+	u.Email = "user@example.org"
+
+	store := teststore.New()
+
+	store.User().Create(admin)
+	store.User().Create(u)
+
+	testGrant(t, store, admin.ID, model.PermissionClientsRead, model.PermissionClientsWrite)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	adminCookie := testLogin(t, srv, admin.Email, admin.Password)
+	userCookie := testLogin(t, srv, u.Email, u.Password)
+
+	testCases := []struct {
+		name         string
+		cookie       string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name:   "confidential",
+			cookie: adminCookie,
+			payload: map[string]interface{}{
+				"name":         "partner",
+				"scopes":       []string{model.ScopeUserRead},
+				"confidential": true,
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:   "public",
+			cookie: adminCookie,
+			payload: map[string]interface{}{
+				"name":          "app",
+				"redirect_uris": []string{"https://app.example.org/callback"},
+				"scopes":        []string{model.ScopeUserRead},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:   "public without redirect uri",
+			cookie: adminCookie,
+			payload: map[string]interface{}{
+				"name":   "app",
+				"scopes": []string{model.ScopeUserRead},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "invalid payload",
+			cookie:       adminCookie,
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "without permission",
+			cookie: userCookie,
+			payload: map[string]interface{}{
+				"name":         "partner",
+				"scopes":       []string{model.ScopeUserRead},
+				"confidential": true,
+			},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/admin/oauth-clients", b)
+			req.Header.Set("Cookie", tc.cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	clients, _ := store.OAuthClient().GetAll()
+	assert.Len(t, clients, 2)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/admin/oauth-clients/%d", clients[0].ID), nil)
+	req.Header.Set("Cookie", adminCookie)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_HandleOAuthAuthorizationCode(t *testing.T) {
+
+	owner := model.TestUser(t)
+	u := model.TestUser(t)
+	u.Email = "user@example.org"
+
+	store := teststore.New()
+
+	store.User().Create(owner)
+	store.User().Create(u)
+
+	c := model.TestOAuthClient(t, owner.ID)
+	store.OAuthClient().Create(c)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+
+	verifier, _ := oidc.GenerateVerifier()
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.ClientID},
+		"redirect_uri":          {c.RedirectURIs[0]},
+		"scope":                 {model.ScopeUserRead},
+		"state":                 {"xyz"},
+		"code_challenge":        {oidc.Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	authorize := func(params url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?"+params.Encode(), nil)
+		req.Header.Set("Cookie", cookie)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	postConsent := func(approve bool, contentType string) *httptest.ResponseRecorder {
+		payload := map[string]interface{}{"approve": approve}
+
+		for key := range params {
+			payload[key] = params.Get(key)
+		}
+
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(payload)
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/authorize", b)
+		req.Header.Set("Cookie", cookie)
+		req.Header.Set("Content-Type", contentType)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	consent := func(approve bool) *url.URL {
+		rec := postConsent(approve, "application/json")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		res := map[string]string{}
+		json.NewDecoder(rec.Body).Decode(&res)
+		redirect, _ := url.Parse(res["redirect_uri"])
+
+		return redirect
+	}
+
+	exchange := func(form url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(c.ClientID, c.Secret)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("invalid requests", func(t *testing.T) {
+		testCases := []struct {
+			name          string
+			key, value    string
+			expectedCode  int
+			expectedError string
+		}{
+			{
+				name:         "unknown client",
+				key:          "client_id",
+				value:        "unknown",
+				expectedCode: http.StatusBadRequest,
+			},
+			{
+				name:         "unregistered redirect uri",
+				key:          "redirect_uri",
+				value:        "https://evil.example.org/callback",
+				expectedCode: http.StatusBadRequest,
+			},
+			{
+				name:          "without pkce",
+				key:           "code_challenge",
+				value:         "",
+				expectedCode:  http.StatusFound,
+				expectedError: oauthInvalidRequest,
+			},
+			{
+				name:          "scope of the client exceeded",
+				key:           "scope",
+				value:         model.ScopeAdminRead,
+				expectedCode:  http.StatusFound,
+				expectedError: oauthInvalidScope,
+			},
+			{
+				name:          "unsupported response type",
+				key:           "response_type",
+				value:         "token",
+				expectedCode:  http.StatusFound,
+				expectedError: oauthUnsupportedResponseType,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				p := url.Values{}
+
+				for key := range params {
+					p.Set(key, params.Get(key))
+				}
+
+				p.Set(tc.key, tc.value)
+
+				rec := authorize(p)
+				assert.Equal(t, tc.expectedCode, rec.Code)
+
+				if tc.expectedError != "" {
+					redirect, _ := url.Parse(rec.Header().Get("Location"))
+					assert.Equal(t, tc.expectedError, redirect.Query().Get("error"))
+					assert.Equal(t, "xyz", redirect.Query().Get("state"))
+				}
+			})
+		}
+	})
+
+	t.Run("without session", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?"+params.Encode(), nil)
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("cross-site form", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, postConsent(true, "text/plain").Code)
+		assert.Equal(t, http.StatusUnsupportedMediaType, postConsent(true, "").Code)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		redirect := consent(false)
+		assert.Equal(t, oauthAccessDenied, redirect.Query().Get("error"))
+	})
+
+	t.Run("flow", func(t *testing.T) {
+		rec := authorize(params)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), c.Name)
+
+		redirect := consent(true)
+		assert.Equal(t, "xyz", redirect.Query().Get("state"))
+
+		code := redirect.Query().Get("code")
+		assert.NotEmpty(t, code)
+
+		form := url.Values{
+			"grant_type":    {grantTypeAuthorizationCode},
+			"code":          {code},
+			"redirect_uri":  {c.RedirectURIs[0]},
+			"code_verifier": {"wrong verifier"},
+		}
+		assert.Equal(t, http.StatusBadRequest, exchange(form).Code)
+
+		// Consent is remembered, so the next request redirects right away.
+		rec = authorize(params)
+		assert.Equal(t, http.StatusFound, rec.Code)
+		redirect, _ = url.Parse(rec.Header().Get("Location"))
+		form.Set("code", redirect.Query().Get("code"))
+
+		form.Set("code_verifier", verifier)
+		form.Set("redirect_uri", "https://other.example.org/callback")
+		assert.Equal(t, http.StatusBadRequest, exchange(form).Code)
+
+		rec = authorize(params)
+		redirect, _ = url.Parse(rec.Header().Get("Location"))
+		form.Set("code", redirect.Query().Get("code"))
+		form.Set("redirect_uri", c.RedirectURIs[0])
+
+		rec = exchange(form)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+		res := map[string]interface{}{}
+		json.NewDecoder(rec.Body).Decode(&res)
+		assert.Equal(t, "Bearer", res["token_type"])
+		assert.Equal(t, model.ScopeUserRead, res["scope"])
+
+		assert.Equal(t, http.StatusBadRequest, exchange(form).Code)
+
+		token := res["access_token"].(string)
+
+		for path, expectedCode := range map[string]int{
+			"/private/whoami":   http.StatusOK,
+			"/private/sessions": http.StatusForbidden,
+		} {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, expectedCode, rec.Code, path)
+		}
+	})
+}
+
+func Test_HandleOAuthClientCredentials(t *testing.T) {
+
+	owner := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(owner)
+
+	c := model.TestOAuthClient(t, owner.ID)
+	store.OAuthClient().Create(c)
+
+	public := model.TestOAuthClient(t, owner.ID)
+	public.Confidential = false
+	store.OAuthClient().Create(public)
+
+	admin := model.TestOAuthClient(t, owner.ID)
+	admin.Scopes = []string{model.ScopeUserRead, model.ScopeAdminRead}
+	store.OAuthClient().Create(admin)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	post := func(path, id, secret string, form url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(id, secret)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	grant := url.Values{"grant_type": {grantTypeClientCredentials}}
+
+	testCases := []struct {
+		name         string
+		id, secret   string
+		form         url.Values
+		expectedCode int
+	}{
+		{
+			name:         "valid",
+			id:           c.ClientID,
+			secret:       c.Secret,
+			form:         grant,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "wrong secret",
+			id:           c.ClientID,
+			secret:       "wrong",
+			form:         grant,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "public client",
+			id:           public.ClientID,
+			form:         grant,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "scope of the client exceeded",
+			id:     c.ClientID,
+			secret: c.Secret,
+			form: url.Values{
+				"grant_type": {grantTypeClientCredentials},
+				"scope":      {model.ScopeAdminWrite},
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "admin scope",
+			id:     admin.ClientID,
+			secret: admin.Secret,
+			form: url.Values{
+				"grant_type": {grantTypeClientCredentials},
+				"scope":      {model.ScopeAdminRead},
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unsupported grant type",
+			id:           c.ClientID,
+			secret:       c.Secret,
+			form:         url.Values{"grant_type": {"password"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := post("/oauth/token", tc.id, tc.secret, tc.form)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	t.Run("admin scopes left out", func(t *testing.T) {
+		rec := post("/oauth/token", admin.ClientID, admin.Secret, grant)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		res := map[string]interface{}{}
+		json.NewDecoder(rec.Body).Decode(&res)
+		assert.Equal(t, model.ScopeUserRead, res["scope"])
+	})
+
+	t.Run("introspection and revocation", func(t *testing.T) {
+		rec := post("/oauth/token", c.ClientID, c.Secret, grant)
+		res := map[string]interface{}{}
+		json.NewDecoder(rec.Body).Decode(&res)
+		token := res["access_token"].(string)
+
+		introspect := func() map[string]interface{} {
+			rec := post("/oauth/introspect", c.ClientID, c.Secret, url.Values{"token": {token}})
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			res := map[string]interface{}{}
+			json.NewDecoder(rec.Body).Decode(&res)
+
+			return res
+		}
+
+		res = introspect()
+		assert.Equal(t, true, res["active"])
+		assert.Equal(t, c.ClientID, res["client_id"])
+		assert.Equal(t, owner.Email, res["username"])
+
+		rec = post("/oauth/introspect", public.ClientID, "", url.Values{"token": {token}})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = post("/oauth/revoke", public.ClientID, "", url.Values{"token": {token}})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, true, introspect()["active"])
+
+		rec = post("/oauth/revoke", c.ClientID, c.Secret, url.Values{"token": {token}})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, false, introspect()["active"])
+
+		rec = httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
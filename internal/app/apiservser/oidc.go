@@ -0,0 +1,316 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/oidc"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+// oidcFlowTTL is how long a user may take to log in at the provider.
+const oidcFlowTTL = 10 * time.Minute
+
+var (
+	errorUnknownProvider     = errors.New("unknown identity provider")
+	errorProviderUnavailable = errors.New("identity provider is unavailable")
+	errorInvalidOIDCState    = errors.New("invalid or expired login state")
+	errorOIDCDenied          = errors.New("login was denied by the identity provider")
+	errorOIDCNoEmail         = errors.New("identity provider did not return an email address")
+	errorIdentityLinked      = errors.New("identity is already linked to another user")
+)
+
+func newOIDCProviders(config *Config) map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider, len(config.OIDCProviders))
+
+	for name, p := range config.OIDCProviders {
+		providers[name] = oidc.New(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  strings.TrimRight(config.PublicURL, "/") + "/auth/" + name + "/callback",
+			Scopes:       p.Scopes,
+		}, nil)
+	}
+
+	return providers
+}
+
+// handleOIDCStart redirects to the authorization endpoint of a provider. The
+// state, nonce and PKCE verifier of the flow are kept in the session cookie.
+func (s *server) handleOIDCStart() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["provider"]
+		p, ok := s.oidcProviders[name]
+
+		if !ok {
+			s.error(rw, r, http.StatusNotFound, errorUnknownProvider)
+			return
+		}
+
+		session, err := s.sessionStore.Get(r, sessionName)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		values := map[string]string{}
+
+		for _, key := range []string{"oidc_state", "oidc_nonce", "oidc_verifier"} {
+			v, err := oidc.GenerateVerifier()
+
+			if err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			values[key] = v
+		}
+
+		authURL, err := p.AuthCodeURL(r.Context(), values["oidc_state"], values["oidc_nonce"], oidc.Challenge(values["oidc_verifier"]))
+
+		if err != nil {
+			s.logger.WithField("provider", name).Errorf("oidc discovery: %v", err)
+			s.error(rw, r, http.StatusBadGateway, errorProviderUnavailable)
+			return
+		}
+
+		for key, v := range values {
+			session.Values[key] = v
+		}
+
+		session.Values["oidc_provider"] = name
+		session.Values["oidc_at"] = time.Now().Unix()
+
+		if err := s.sessionStore.Save(r, rw, session); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.Redirect(rw, r, authURL, http.StatusFound)
+	}
+}
+
+// handleOIDCCallback completes the flow started by handleOIDCStart. The
+// external identity is linked to the logged in user, if any, or else to the
+// user with the same verified email address, who is created if necessary.
+// A replayed callback fails at the provider, which accepts a code only once.
+func (s *server) handleOIDCCallback() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["provider"]
+		p, ok := s.oidcProviders[name]
+
+		if !ok {
+			s.error(rw, r, http.StatusNotFound, errorUnknownProvider)
+			return
+		}
+
+		session, err := s.sessionStore.Get(r, sessionName)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		provider, _ := session.Values["oidc_provider"].(string)
+		state, _ := session.Values["oidc_state"].(string)
+		nonce, _ := session.Values["oidc_nonce"].(string)
+		verifier, _ := session.Values["oidc_verifier"].(string)
+		at, _ := session.Values["oidc_at"].(int64)
+
+		q := r.URL.Query()
+
+		if provider != name || state == "" || q.Get("state") != state || time.Since(time.Unix(at, 0)) > oidcFlowTTL {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidOIDCState)
+			return
+		}
+
+		if q.Get("error") != "" {
+			s.error(rw, r, http.StatusUnauthorized, errorOIDCDenied)
+			return
+		}
+
+		raw, err := p.Exchange(r.Context(), q.Get("code"), verifier)
+
+		if err != nil {
+			s.logger.WithField("provider", name).Errorf("oidc code exchange: %v", err)
+			s.error(rw, r, http.StatusBadGateway, errorProviderUnavailable)
+			return
+		}
+
+		claims, err := p.Verify(r.Context(), raw, nonce)
+
+		if errors.Is(err, oidc.ErrorInvalidIDToken) {
+			s.error(rw, r, http.StatusUnauthorized, oidc.ErrorInvalidIDToken)
+			return
+		}
+
+		if err != nil {
+			s.logger.WithField("provider", name).Errorf("oidc id token verification: %v", err)
+			s.error(rw, r, http.StatusBadGateway, errorProviderUnavailable)
+			return
+		}
+
+		current, sess, err := s.authenticateSession(r)
+
+		if err != nil && err != errorNotAuthenticated {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Linking an identity is a credential of the current user, which an
+		// impersonating admin must not be able to add.
+		if sess != nil && sess.IsImpersonated() {
+			s.error(rw, r, http.StatusForbidden, errorImpersonating)
+			return
+		}
+
+		u, ok := s.resolveIdentity(rw, r, name, claims, current)
+
+		if !ok {
+			return
+		}
+
+		for _, key := range []string{"oidc_provider", "oidc_state", "oidc_nonce", "oidc_verifier", "oidc_at"} {
+			delete(session.Values, key)
+		}
+
+		if current != nil {
+			if err := s.sessionStore.Save(r, rw, session); err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.respond(rw, r, http.StatusOK, nil)
+			return
+		}
+
+		if err := s.loginAllowed(u); err != nil {
+			s.error(rw, r, http.StatusForbidden, err)
+			return
+		}
+
+		if u.IsTOTPEnabled() {
+			if err := s.startTwoFactorChallenge(rw, r, u); err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.respond(rw, r, http.StatusAccepted, map[string]bool{"two_factor_required": true})
+			return
+		}
+
+		if err := s.startSession(rw, r, u); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, nil)
+	}
+}
+
+func (s *server) handleIdentityList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		identities, err := s.store.Identity().FindByUser(u.ID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, identities)
+	}
+}
+
+// resolveIdentity returns the user an external identity belongs to, linking
+// it first if it is new. New identities are linked to current when it is not
+// nil. Otherwise they are linked by an email address verified on both sides,
+// and a user without a usable password is provisioned when no user has that
+// address. It responds itself and returns false when the identity can not be resolved.
+func (s *server) resolveIdentity(rw http.ResponseWriter, r *http.Request, provider string, claims *oidc.Claims, current *model.User) (*model.User, bool) {
+	i, err := s.store.Identity().FindBySubject(provider, claims.Subject)
+
+	if err == nil {
+		if current != nil && current.ID != i.UserID {
+			s.error(rw, r, http.StatusConflict, errorIdentityLinked)
+			return nil, false
+		}
+
+		u, err := s.store.User().Find(i.UserID)
+
+		if err == store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusUnauthorized, errorNotAuthenticated)
+			return nil, false
+		}
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return nil, false
+		}
+
+		return u, true
+	}
+
+	if err != store.ErrorRecordNotFound {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	u := current
+
+	if u == nil {
+		if claims.Email == "" {
+			s.error(rw, r, http.StatusUnprocessableEntity, errorOIDCNoEmail)
+			return nil, false
+		}
+
+		// An address the provider has not verified says nothing about who
+		// owns it, so it can neither claim an account nor create one that
+		// its owner would find taken.
+		if !claims.EmailVerified {
+			s.error(rw, r, http.StatusUnprocessableEntity, errorEmailNotVerified)
+			return nil, false
+		}
+
+		u, err = s.store.User().FindByEmail(claims.Email)
+
+		// Whoever registered an address nobody has confirmed keeps its
+		// password, so linking would hand the identity to them.
+		if err == nil && !u.IsEmailVerified() {
+			s.error(rw, r, http.StatusUnprocessableEntity, errorEmailNotVerified)
+			return nil, false
+		}
+
+		if err == store.ErrorRecordNotFound {
+			u, err = provisionUser(s.store, claims.Email, true)
+
+			if err == nil {
+				s.audit(r, model.AuditUserCreated, 0, u.ID, map[string]string{"method": "oidc", "provider": provider})
+			}
+		}
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return nil, false
+		}
+	}
+
+	if err := s.store.Identity().Create(&model.Identity{
+		UserID:   u.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return u, true
+}
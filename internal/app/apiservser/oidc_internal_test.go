@@ -0,0 +1,177 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/oidc"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleOIDC(t *testing.T) {
+
+	tp := oidc.NewTestProvider(t)
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	config := testConfig()
+	config.OIDCProviders = map[string]OIDCProvider{
+		"test": {
+			Issuer:       tp.URL,
+			ClientID:     tp.ClientID,
+			ClientSecret: tp.ClientSecret,
+		},
+	}
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), config)
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	get := func(path, cookie string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Cookie", cookie)
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// authorize starts a flow and returns the callback URL the provider
+	// redirects to, together with the cookie holding the flow state.
+	authorize := func(cookie string) (*url.URL, string) {
+		rec := get("/auth/test", cookie)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		if c := rec.Header().Get("Set-Cookie"); c != "" {
+			cookie = c
+		}
+
+		res, err := client.Get(rec.Header().Get("Location"))
+		assert.NoError(t, err)
+		res.Body.Close()
+
+		callback, _ := url.Parse(res.Header.Get("Location"))
+
+		return callback, cookie
+	}
+
+	login := func(cookie string) *httptest.ResponseRecorder {
+		callback, cookie := authorize(cookie)
+		return get(callback.RequestURI(), cookie)
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("/auth/unknown", "").Code)
+
+	t.Run("provisioning", func(t *testing.T) {
+		tp.Subject, tp.Email, tp.EmailVerified = "new", "new@example.org", true
+
+		rec := login("")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, rec.Header().Get("Set-Cookie")))
+
+		created, err := store.User().FindByEmail("new@example.org")
+		assert.NoError(t, err)
+		assert.True(t, created.IsEmailVerified())
+
+		assert.Equal(t, http.StatusOK, login("").Code)
+
+		identities, _ := store.Identity().FindByUser(created.ID)
+		assert.Len(t, identities, 1)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		tp.Subject, tp.Email, tp.EmailVerified = "unverified", "unverified@example.org", false
+		assert.Equal(t, http.StatusUnprocessableEntity, login("").Code)
+
+		_, err := store.User().FindByEmail("unverified@example.org")
+		assert.Error(t, err)
+	})
+
+	t.Run("link by verified email", func(t *testing.T) {
+		tp.Subject, tp.Email, tp.EmailVerified = "existing", u.Email, false
+		assert.Equal(t, http.StatusUnprocessableEntity, login("").Code)
+
+		tp.EmailVerified = true
+		assert.Equal(t, http.StatusUnprocessableEntity, login("").Code)
+
+		verifiedAt := time.Now()
+		u.EmailVerifiedAt = &verifiedAt
+		assert.NoError(t, store.User().UpdateEmail(u))
+		assert.Equal(t, http.StatusOK, login("").Code)
+
+		identities, _ := store.Identity().FindByUser(u.ID)
+		assert.Len(t, identities, 1)
+	})
+
+	t.Run("link to current user", func(t *testing.T) {
+		cookie := testLogin(t, srv, u.Email, u.Password)
+
+		tp.Subject, tp.Email = "second", "second@example.org"
+		assert.Equal(t, http.StatusOK, login(cookie).Code)
+
+		identities, _ := store.Identity().FindByUser(u.ID)
+		assert.Len(t, identities, 2)
+
+		tp.Subject = "new"
+		assert.Equal(t, http.StatusConflict, login(cookie).Code)
+	})
+
+	t.Run("impersonating", func(t *testing.T) {
+		admin := model.TestUser(t)
+		admin.Email = "admin@example.org"
+		store.User().Create(admin)
+		testGrant(t, store, admin.ID, model.PermissionUsersImpersonate)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/admin/users/%d/impersonate", u.ID), nil)
+		req.Header.Set("Cookie", testLogin(t, srv, admin.Email, "password"))
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		tp.Subject, tp.Email = "admin", admin.Email
+		assert.Equal(t, http.StatusForbidden, login(rec.Header().Get("Set-Cookie")).Code)
+
+		identities, _ := store.Identity().FindByUser(u.ID)
+		assert.Len(t, identities, 2)
+	})
+
+	t.Run("state", func(t *testing.T) {
+		callback, cookie := authorize("")
+		assert.Equal(t, http.StatusBadRequest, get(callback.RequestURI(), "").Code)
+
+		q := callback.Query()
+		q.Set("state", "other")
+		forged := *callback
+		forged.RawQuery = q.Encode()
+		assert.Equal(t, http.StatusBadRequest, get(forged.RequestURI(), cookie).Code)
+
+		callback, cookie = authorize("")
+		q = callback.Query()
+		q.Del("code")
+		q.Set("error", "access_denied")
+		callback.RawQuery = q.Encode()
+		assert.Equal(t, http.StatusUnauthorized, get(callback.RequestURI(), cookie).Code)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		tp.Subject, tp.Email = "new", "new@example.org"
+
+		callback, cookie := authorize("")
+		assert.Equal(t, http.StatusOK, get(callback.RequestURI(), cookie).Code)
+		assert.NotEqual(t, http.StatusOK, get(callback.RequestURI(), cookie).Code)
+	})
+}
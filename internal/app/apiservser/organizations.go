@@ -0,0 +1,349 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errorAlreadyMember = errors.New("user is already a member of the organization")
+	errorLastOwner     = errors.New("organization must keep at least one owner")
+)
+
+func (s *server) handleOrganizationList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		organizations, err := s.store.Organization().FindByUser(u.ID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, organizations)
+	}
+}
+
+// handleOrganizationCreate creates an organization owned by the current
+// user.
+func (s *server) handleOrganizationCreate() http.HandlerFunc {
+
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		o := &model.Organization{
+			Name: req.Name,
+		}
+
+		if err := s.store.Organization().Create(o); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		m := &model.Membership{
+			OrganizationID: o.ID,
+			UserID:         u.ID,
+			Role:           model.OrganizationRoleOwner,
+		}
+
+		if err := s.store.Membership().Create(m); err != nil {
+			s.store.Organization().Delete(o.ID)
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusCreated, o)
+	}
+}
+
+func (s *server) handleOrganizationGet() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		s.respond(rw, r, http.StatusOK, r.Context().Value(contextKeyOrganization).(*model.Organization))
+	}
+}
+
+func (s *server) handleOrganizationDelete() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		o := r.Context().Value(contextKeyOrganization).(*model.Organization)
+
+		if err := s.store.Organization().Delete(o.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+func (s *server) handleMemberList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		o := r.Context().Value(contextKeyOrganization).(*model.Organization)
+
+		memberships, err := s.store.Membership().FindByOrganization(o.ID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, memberships)
+	}
+}
+
+// handleMemberAdd adds an existing user to the organization, by email
+// address.
+func (s *server) handleMemberAdd() http.HandlerFunc {
+
+	type request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		o := r.Context().Value(contextKeyOrganization).(*model.Organization)
+
+		u, err := s.store.User().FindByEmail(req.Email)
+
+		if err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusNotFound, err)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if _, err := s.store.Membership().Find(o.ID, u.ID); err != store.ErrorRecordNotFound {
+			if err == nil {
+				s.error(rw, r, http.StatusConflict, errorAlreadyMember)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		m := &model.Membership{
+			OrganizationID: o.ID,
+			UserID:         u.ID,
+			Role:           req.Role,
+		}
+
+		if !s.checkRoleChange(rw, r, &model.Membership{}, m.Role) {
+			return
+		}
+
+		if err := s.store.Membership().Create(m); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusCreated, m)
+	}
+}
+
+func (s *server) handleMemberUpdate() http.HandlerFunc {
+
+	type request struct {
+		Role string `json:"role"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		m, ok := s.findMemberVar(rw, r)
+
+		if !ok || !s.checkRoleChange(rw, r, m, req.Role) {
+			return
+		}
+
+		updated := *m
+		updated.Role = req.Role
+
+		if err := s.store.Membership().UpdateRole(&updated); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.audit(r, model.AuditMemberRoleChanged, r.Context().Value(contextKeyUser).(*model.User).ID, m.UserID, map[string]string{
+			"organization_id": strconv.Itoa(m.OrganizationID),
+			"from":            m.Role,
+			"to":              updated.Role,
+		})
+
+		s.respond(rw, r, http.StatusOK, &updated)
+	}
+}
+
+// handleMemberRemove removes a user from the organization. Admins remove
+// other members, and every member can leave by removing themselves.
+func (s *server) handleMemberRemove() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		current := r.Context().Value(contextKeyMembership).(*model.Membership)
+
+		m, ok := s.findMemberVar(rw, r)
+
+		if !ok {
+			return
+		}
+
+		if m.UserID != current.UserID && !current.HasRole(model.OrganizationRoleAdmin) {
+			s.error(rw, r, http.StatusForbidden, errorPermissionDenied)
+			return
+		}
+
+		if !s.checkRoleChange(rw, r, m, "") {
+			return
+		}
+
+		if err := s.store.Membership().Delete(m.OrganizationID, m.UserID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// requireMembership loads the organization whose ID is in the URL into the
+// request context, together with the membership of the current user in it.
+// Users outside the organization get a 404, so that its existence is not
+// disclosed to them.
+func (s *server) requireMembership(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		m, err := s.store.Membership().Find(id, u.ID)
+
+		if err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusNotFound, err)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		o, err := s.store.Organization().Find(id)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), contextKeyOrganization, o)
+		ctx = context.WithValue(ctx, contextKeyMembership, m)
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// requireOrganizationRole rejects requests of members whose role in the
+// organization of the request is less privileged than role.
+func (s *server) requireOrganizationRole(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(contextKeyMembership).(*model.Membership).HasRole(role) {
+			s.error(rw, r, http.StatusForbidden, errorPermissionDenied)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// findMemberVar loads the membership of the user whose ID is in the URL in
+// the organization of the request. It responds itself and returns false when
+// the user is not a member.
+func (s *server) findMemberVar(rw http.ResponseWriter, r *http.Request) (*model.Membership, bool) {
+	o := r.Context().Value(contextKeyOrganization).(*model.Organization)
+	userID, _ := strconv.Atoi(mux.Vars(r)["user"])
+
+	m, err := s.store.Membership().Find(o.ID, userID)
+
+	if err != nil {
+		if err == store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusNotFound, err)
+			return nil, false
+		}
+
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return m, true
+}
+
+// checkRoleChange reports whether the current member may move m from its
+// role to role, where an empty role means that m leaves the organization.
+// Only owners grant or take away the owner role, and the last owner cannot
+// give it up. It responds itself when the change is not allowed.
+func (s *server) checkRoleChange(rw http.ResponseWriter, r *http.Request, m *model.Membership, role string) bool {
+	if m.Role != model.OrganizationRoleOwner && role != model.OrganizationRoleOwner {
+		return true
+	}
+
+	if !r.Context().Value(contextKeyMembership).(*model.Membership).HasRole(model.OrganizationRoleOwner) {
+		s.error(rw, r, http.StatusForbidden, errorPermissionDenied)
+		return false
+	}
+
+	if m.Role != model.OrganizationRoleOwner || role == model.OrganizationRoleOwner {
+		return true
+	}
+
+	memberships, err := s.store.Membership().FindByOrganization(m.OrganizationID)
+
+	if err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return false
+	}
+
+	owners := 0
+
+	for _, other := range memberships {
+		if other.Role == model.OrganizationRoleOwner {
+			owners++
+		}
+	}
+
+	if owners < 2 {
+		s.error(rw, r, http.StatusConflict, errorLastOwner)
+		return false
+	}
+
+	return true
+}
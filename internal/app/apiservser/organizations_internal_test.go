@@ -0,0 +1,184 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleOrganizations(t *testing.T) {
+
+	owner := model.TestUser(t)
+	owner.Email = "owner@example.org"
+
+	admin := model.TestUser(t)
+	admin.Email = "admin@example.org"
+
+	member := model.TestUser(t)
+	member.Email = "member@example.org"
+
+	outsider := model.TestUser(t)
+
+	store := teststore.New()
+
+	for _, u := range []*model.User{owner, admin, member, outsider} {
+		store.User().Create(u)
+	}
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookies := map[*model.User]string{}
+
+	for _, u := range []*model.User{owner, admin, member, outsider} {
+		cookies[u] = testLogin(t, srv, u.Email, "password")
+	}
+
+	do := func(u *model.User, method, path string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(srv, method, path, http.Header{"Cookie": {cookies[u]}}, payload)
+	}
+
+	rec := do(owner, http.MethodPost, "/orgs", map[string]string{"name": "Example"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(owner, http.MethodPost, "/orgs", map[string]string{"name": ""}).Code)
+
+	o := &model.Organization{}
+	json.NewDecoder(rec.Body).Decode(o)
+
+	org := fmt.Sprintf("/orgs/%d", o.ID)
+	members := org + "/members"
+
+	t.Run("add members", func(t *testing.T) {
+		testCases := []struct {
+			name         string
+			user         *model.User
+			payload      interface{}
+			expectedCode int
+		}{
+			{
+				name:         "invalid payload",
+				user:         owner,
+				payload:      "invalid",
+				expectedCode: http.StatusBadRequest,
+			},
+			{
+				name:         "unknown user",
+				user:         owner,
+				payload:      map[string]string{"email": "unknown@example.org", "role": model.OrganizationRoleMember},
+				expectedCode: http.StatusNotFound,
+			},
+			{
+				name:         "unknown role",
+				user:         owner,
+				payload:      map[string]string{"email": admin.Email, "role": "superuser"},
+				expectedCode: http.StatusUnprocessableEntity,
+			},
+			{
+				name:         "admin",
+				user:         owner,
+				payload:      map[string]string{"email": admin.Email, "role": model.OrganizationRoleAdmin},
+				expectedCode: http.StatusCreated,
+			},
+			{
+				name:         "already a member",
+				user:         owner,
+				payload:      map[string]string{"email": admin.Email, "role": model.OrganizationRoleMember},
+				expectedCode: http.StatusConflict,
+			},
+			{
+				name:         "owner added by an admin",
+				user:         admin,
+				payload:      map[string]string{"email": member.Email, "role": model.OrganizationRoleOwner},
+				expectedCode: http.StatusForbidden,
+			},
+			{
+				name:         "member added by an admin",
+				user:         admin,
+				payload:      map[string]string{"email": member.Email, "role": model.OrganizationRoleMember},
+				expectedCode: http.StatusCreated,
+			},
+			{
+				name:         "by a member",
+				user:         member,
+				payload:      map[string]string{"email": outsider.Email, "role": model.OrganizationRoleMember},
+				expectedCode: http.StatusForbidden,
+			},
+			{
+				name:         "by an outsider",
+				user:         outsider,
+				payload:      map[string]string{"email": outsider.Email, "role": model.OrganizationRoleMember},
+				expectedCode: http.StatusNotFound,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				assert.Equal(t, tc.expectedCode, do(tc.user, http.MethodPost, members, tc.payload).Code)
+			})
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		rec := do(member, http.MethodGet, members, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		res := []*model.Membership{}
+		json.NewDecoder(rec.Body).Decode(&res)
+		assert.Len(t, res, 3)
+		assert.Equal(t, owner.Email, res[0].Email)
+		assert.Equal(t, model.OrganizationRoleOwner, res[0].Role)
+
+		rec = do(member, http.MethodGet, "/orgs", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		organizations := []*model.Organization{}
+		json.NewDecoder(rec.Body).Decode(&organizations)
+		assert.Len(t, organizations, 1)
+
+		assert.Equal(t, http.StatusOK, do(member, http.MethodGet, org, nil).Code)
+		assert.Equal(t, http.StatusNotFound, do(outsider, http.MethodGet, org, nil).Code)
+		assert.Equal(t, http.StatusNotFound, do(owner, http.MethodGet, "/orgs/0", nil).Code)
+	})
+
+	t.Run("update roles", func(t *testing.T) {
+		path := func(u *model.User) string {
+			return fmt.Sprintf("%s/%d", members, u.ID)
+		}
+
+		assert.Equal(t, http.StatusForbidden, do(member, http.MethodPut, path(member), map[string]string{"role": model.OrganizationRoleAdmin}).Code)
+		assert.Equal(t, http.StatusForbidden, do(admin, http.MethodPut, path(owner), map[string]string{"role": model.OrganizationRoleMember}).Code)
+		assert.Equal(t, http.StatusConflict, do(owner, http.MethodPut, path(owner), map[string]string{"role": model.OrganizationRoleAdmin}).Code)
+		assert.Equal(t, http.StatusNotFound, do(owner, http.MethodPut, path(outsider), map[string]string{"role": model.OrganizationRoleAdmin}).Code)
+		assert.Equal(t, http.StatusOK, do(admin, http.MethodPut, path(member), map[string]string{"role": model.OrganizationRoleAdmin}).Code)
+		assert.Equal(t, http.StatusOK, do(owner, http.MethodPut, path(admin), map[string]string{"role": model.OrganizationRoleOwner}).Code)
+		assert.Equal(t, http.StatusOK, do(owner, http.MethodPut, path(owner), map[string]string{"role": model.OrganizationRoleAdmin}).Code)
+
+		m, err := store.Membership().Find(o.ID, owner.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.OrganizationRoleAdmin, m.Role)
+	})
+
+	t.Run("remove members", func(t *testing.T) {
+		path := func(u *model.User) string {
+			return fmt.Sprintf("%s/%d", members, u.ID)
+		}
+
+		assert.Equal(t, http.StatusForbidden, do(owner, http.MethodDelete, path(admin), nil).Code)
+		assert.Equal(t, http.StatusConflict, do(admin, http.MethodDelete, path(admin), nil).Code)
+		assert.Equal(t, http.StatusNoContent, do(member, http.MethodDelete, path(member), nil).Code)
+		assert.Equal(t, http.StatusNotFound, do(member, http.MethodGet, org, nil).Code)
+		assert.Equal(t, http.StatusNoContent, do(admin, http.MethodDelete, path(owner), nil).Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(owner, http.MethodDelete, org, nil).Code)
+		assert.Equal(t, http.StatusNoContent, do(admin, http.MethodDelete, org, nil).Code)
+		assert.Equal(t, http.StatusNotFound, do(admin, http.MethodGet, org, nil).Code)
+	})
+}
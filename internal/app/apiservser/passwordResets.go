@@ -0,0 +1,157 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errorInvalidResetToken     = errors.New("invalid or expired password reset token")
+	errorTooManyPasswordResets = errors.New("too many password resets requested")
+)
+
+// handlePasswordResetCreate mails a reset token to the given address. It
+// responds the same way whether or not the address belongs to a user, and the
+// mail is sent in the background so that the response time does not tell
+// either. Requests are throttled per address and per client IP.
+func (s *server) handlePasswordResetCreate() http.HandlerFunc {
+
+	type request struct {
+		Email string `json:"email"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if !s.allowMail(rw, r, req.Email, s.passwordResetEmailThrottle, s.passwordResetIPThrottle, errorTooManyPasswordResets) {
+			return
+		}
+
+		s.runInBackground(r, func() error {
+			return s.sendPasswordReset(req.Email)
+		})
+
+		s.respond(rw, r, http.StatusAccepted, nil)
+	}
+}
+
+// handlePasswordResetComplete sets a new password and ends every session of
+// the user, since the old password has to be considered compromised. Every
+// outstanding reset of the user is consumed along with the one used, and a
+// login lockout of the account is lifted.
+func (s *server) handlePasswordResetComplete() http.HandlerFunc {
+
+	type request struct {
+		Password string `json:"password"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		p, err := s.store.PasswordReset().FindByToken(mux.Vars(r)["token"])
+
+		if err != nil || p.IsUsed() || p.IsExpired() {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidResetToken)
+			return
+		}
+
+		u, err := s.store.User().Find(p.UserID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusBadRequest, errorInvalidResetToken)
+			return
+		}
+
+		// The password is checked before the reset is claimed, so that a
+		// rejected one does not use up the token.
+		if err := (&model.User{Email: u.Email, Password: req.Password}).Validate(); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.store.PasswordReset().MarkUsed(p.ID); err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusBadRequest, errorInvalidResetToken)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u.Password = req.Password
+
+		if err := s.store.User().UpdatePassword(u); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		// Any other reset mailed before is as much a way into the account as
+		// the old password was.
+		if err := s.store.PasswordReset().MarkUsedByUser(u.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.revokeCredentials(u.ID, ""); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditPasswordChanged, u.ID, u.ID, map[string]string{"method": "reset"})
+
+		if err := s.accountThrottle.Reset(accountKey(u.Email)); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// sendPasswordReset creates a reset for the user with the given address and
+// mails its token to them. It does nothing if no user has the address.
+func (s *server) sendPasswordReset(email string) error {
+	u, err := s.store.User().FindByEmail(email)
+
+	if err == store.ErrorRecordNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	p := &model.PasswordReset{
+		UserID:    u.ID,
+		ExpiresAt: time.Now().Add(s.config.PasswordResetTTL.Duration),
+	}
+
+	if err := s.store.PasswordReset().Create(p); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Use the following token to set a new password:\n\n%s\n", p.Token),
+	})
+}
@@ -0,0 +1,156 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webserver/internal/app/mailer"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandlePasswordResetCreate(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+	m := mailer.NewMemory()
+	srv.mailer = m
+
+	create := func(email string) int {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{"email": email})
+		req, _ := http.NewRequest(http.MethodPost, "/password-resets", b)
+		srv.ServeHTTP(rec, req)
+		srv.background.Wait()
+		return rec.Code
+	}
+
+	for _, email := range []string{u.Email, "unknown@example.org"} {
+		assert.Equal(t, http.StatusAccepted, create(email))
+	}
+
+	assert.NotNil(t, m.Last(u.Email))
+	assert.Nil(t, m.Last("unknown@example.org"))
+
+	srv.mailer = failingMailer{}
+	assert.Equal(t, http.StatusAccepted, create(u.Email))
+
+	t.Run("throttled", func(t *testing.T) {
+		// The address has been requested once above. The request past the
+		// free ones is still served, but blocks the address.
+		for i := 1; i <= srv.config.PasswordResetMails; i++ {
+			assert.Equal(t, http.StatusAccepted, create("unknown@example.org"))
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, create("unknown@example.org"))
+	})
+}
+
+// failingMailer fails to deliver any message.
+type failingMailer struct{}
+
+func (failingMailer) Send(*mailer.Message) error {
+	return errors.New("mail server is unavailable")
+}
+
+func Test_HandlePasswordResetComplete(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+
+	p := model.TestPasswordReset(t, u.ID)
+	store.PasswordReset().Create(p)
+
+	outstanding := model.TestPasswordReset(t, u.ID)
+	store.PasswordReset().Create(outstanding)
+
+	expired := model.TestPasswordReset(t, u.ID)
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	store.PasswordReset().Create(expired)
+
+	testCases := []struct {
+		name         string
+		token        string
+		password     string
+		expectedCode int
+	}{
+		{
+			name:         "unknown token",
+			token:        "unknown",
+			password:     "new-password",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "expired token",
+			token:        expired.Token,
+			password:     "new-password",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid password",
+			token:        p.Token,
+			password:     "short",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "empty password",
+			token:        p.Token,
+			password:     "",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "valid",
+			token:        p.Token,
+			password:     "new-password",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "reused token",
+			token:        p.Token,
+			password:     "another-password",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "outstanding token",
+			token:        outstanding.Token,
+			password:     "another-password",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(map[string]string{"password": tc.password})
+			req, _ := http.NewRequest(http.MethodPut, "/password-resets/"+tc.token, b)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, cookie))
+
+	found, _ := store.User().Find(u.ID)
+	assert.True(t, found.ComparePassword("new-password"))
+}
@@ -2,12 +2,22 @@ package apiserver
 
 import "net/http"
 
+// responseWriter records the status code and the size of a response for the
+// request log.
 type responseWriter struct {
 	http.ResponseWriter
-	code int
+	code  int
+	bytes int
 }
 
 func (rw *responseWriter) WriteHeader(statusCode int) {
 	rw.code = statusCode
 	rw.ResponseWriter.WriteHeader(statusCode)
-}
\ No newline at end of file
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+
+	return n, err
+}
@@ -0,0 +1,161 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *server) handleRoleList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		roles, err := s.store.Role().GetAll()
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, roles)
+	}
+}
+
+func (s *server) handleRoleCreate() http.HandlerFunc {
+
+	type request struct {
+		Name        string   `json:"name"`
+		Permissions []string `json:"permissions"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		role := &model.Role{
+			Name:        req.Name,
+			Permissions: req.Permissions,
+		}
+
+		if err := s.store.Role().Create(role); err != nil {
+			s.error(rw, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusCreated, role)
+	}
+}
+
+func (s *server) handleUserRoleList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, ok := s.findUserVar(rw, r)
+
+		if !ok {
+			return
+		}
+
+		roles, err := s.store.Role().FindByUser(u.ID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, roles)
+	}
+}
+
+// handleUserRoleAssign grants the role named in the URL to a user. The
+// change applies to the next request of that user.
+func (s *server) handleUserRoleAssign() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, role, ok := s.findUserRoleVars(rw, r)
+
+		if !ok {
+			return
+		}
+
+		if err := s.store.Role().Assign(u.ID, role.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditRoleAssigned, r.Context().Value(contextKeyUser).(*model.User).ID, u.ID, map[string]string{"role": role.Name})
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+func (s *server) handleUserRoleUnassign() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, role, ok := s.findUserRoleVars(rw, r)
+
+		if !ok {
+			return
+		}
+
+		if err := s.store.Role().Unassign(u.ID, role.ID); err != nil {
+			if err == store.ErrorRecordNotFound {
+				s.error(rw, r, http.StatusNotFound, err)
+				return
+			}
+
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditRoleUnassigned, r.Context().Value(contextKeyUser).(*model.User).ID, u.ID, map[string]string{"role": role.Name})
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// findUserVar loads the user whose ID is in the URL. It responds itself and
+// returns false when there is no such user.
+func (s *server) findUserVar(rw http.ResponseWriter, r *http.Request) (*model.User, bool) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	u, err := s.store.User().Find(id)
+
+	if err != nil {
+		if err == store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusNotFound, err)
+			return nil, false
+		}
+
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return u, true
+}
+
+// findUserRoleVars loads the user and the role named in the URL. It responds
+// itself and returns false when either does not exist.
+func (s *server) findUserRoleVars(rw http.ResponseWriter, r *http.Request) (*model.User, *model.Role, bool) {
+	u, ok := s.findUserVar(rw, r)
+
+	if !ok {
+		return nil, nil, false
+	}
+
+	role, err := s.store.Role().FindByName(mux.Vars(r)["role"])
+
+	if err != nil {
+		if err == store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusNotFound, err)
+			return nil, nil, false
+		}
+
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	return u, role, true
+}
@@ -0,0 +1,173 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleRoleCreate(t *testing.T) {
+
+	admin := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(admin)
+
+	testGrant(t, store, admin.ID, model.PermissionRolesWrite)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, admin.Email, "password")
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown permission",
+			payload: map[string]interface{}{
+				"name":        "support",
+				"permissions": []string{"everything"},
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "valid",
+			payload: map[string]interface{}{
+				"name":        "support",
+				"permissions": []string{model.PermissionUsersRead},
+			},
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/admin/roles", b)
+			req.Header.Set("Cookie", cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func Test_HandleUserRoleAssign(t *testing.T) {
+
+	admin := model.TestUser(t)
+	admin.Email = "admin@example.org"
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(admin)
+	store.User().Create(u)
+
+	testGrant(t, store, admin.ID, model.PermissionRolesRead, model.PermissionRolesWrite)
+
+	store.Role().Create(&model.Role{
+		Name:        "support",
+		Permissions: []string{model.PermissionUsersRead},
+	})
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	adminCookie := testLogin(t, srv, admin.Email, "password")
+	userCookie := testLogin(t, srv, u.Email, "password")
+
+	listUsers := func() int {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/admin/users", nil)
+		req.Header.Set("Cookie", userCookie)
+		srv.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	testCases := []struct {
+		name         string
+		cookie       string
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "not permitted",
+			cookie:       userCookie,
+			method:       http.MethodPut,
+			path:         "/admin/users/2/roles/support",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "unknown user",
+			cookie:       adminCookie,
+			method:       http.MethodPut,
+			path:         "/admin/users/42/roles/support",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown role",
+			cookie:       adminCookie,
+			method:       http.MethodPut,
+			path:         "/admin/users/2/roles/unknown",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "not assigned",
+			cookie:       adminCookie,
+			method:       http.MethodDelete,
+			path:         "/admin/users/2/roles/support",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "assign",
+			cookie:       adminCookie,
+			method:       http.MethodPut,
+			path:         "/admin/users/2/roles/support",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "list",
+			cookie:       adminCookie,
+			method:       http.MethodGet,
+			path:         "/admin/users/2/roles",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Cookie", tc.cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	assert.Equal(t, http.StatusOK, listUsers())
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/admin/users/2/roles/support", nil)
+	req.Header.Set("Cookie", adminCookie)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Equal(t, http.StatusForbidden, listUsers())
+}
@@ -0,0 +1,543 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/scim"
+	"webserver/internal/app/store"
+
+	"github.com/gorilla/mux"
+)
+
+// scimFilterAttributes are the attributes users can be filtered by.
+var scimFilterAttributes = []string{"userName", "emails", "emails.value", "id", "active"}
+
+// requireBearer rejects requests that are not authenticated with a bearer
+// token, which is the only authentication scheme SCIM clients use.
+func (s *server) requireBearer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := bearerToken(r); !ok {
+			rw.Header().Set("WWW-Authenticate", "Bearer")
+			s.scimError(rw, r, scim.NewError(http.StatusUnauthorized, "", errorNotAuthenticated.Error()))
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (s *server) handleSCIMServiceProviderConfig() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		s.scimRespond(rw, r, http.StatusOK, scim.ServiceProviderConfig(store.MaxListLimit))
+	}
+}
+
+func (s *server) handleSCIMSchemaList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		s.scimRespond(rw, r, http.StatusOK, scim.NewListResponse([]interface{}{scim.UserSchema}, 1, 1, 1))
+	}
+}
+
+func (s *server) handleSCIMSchema() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] != scim.SchemaUser {
+			s.scimError(rw, r, scim.NewError(http.StatusNotFound, "", "unknown schema"))
+			return
+		}
+
+		s.scimRespond(rw, r, http.StatusOK, scim.UserSchema)
+	}
+}
+
+// handleSCIMUserList answers a query for users, including deactivated ones.
+// Only a single attribute comparison is supported as filter. Since the store
+// pages with cursors rather than offsets, all matching users are loaded to
+// count them and cut out the requested page.
+func (s *server) handleSCIMUserList() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		startIndex, count := 1, store.DefaultListLimit
+
+		if v, err := strconv.Atoi(q.Get("startIndex")); err == nil && v > 1 {
+			startIndex = v
+		}
+
+		if v, err := strconv.Atoi(q.Get("count")); err == nil {
+			count = v
+		}
+
+		if count < 0 {
+			count = 0
+		}
+
+		if count > store.MaxListLimit {
+			count = store.MaxListLimit
+		}
+
+		var filter *scim.Filter
+
+		if v := q.Get("filter"); v != "" {
+			f, err := scim.ParseFilter(v)
+
+			if err != nil {
+				s.scimError(rw, r, err)
+				return
+			}
+
+			if !scimFilterSupported(f) {
+				s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidFilter, "unsupported filter attribute "+strconv.Quote(f.Attribute)))
+				return
+			}
+
+			filter = f
+		}
+
+		users, total, err := s.scimQuery(r, filter, startIndex-1+count)
+
+		if err != nil {
+			s.scimError(rw, r, err)
+			return
+		}
+
+		resources := []*scim.User{}
+
+		for i := startIndex - 1; i < len(users); i++ {
+			resources = append(resources, s.scimUser(users[i]))
+		}
+
+		s.scimRespond(rw, r, http.StatusOK, scim.NewListResponse(resources, total, startIndex, len(resources)))
+	}
+}
+
+func (s *server) handleSCIMUserGet() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, ok := s.findSCIMUser(rw, r)
+
+		if !ok {
+			return
+		}
+
+		s.scimRespond(rw, r, http.StatusOK, s.scimUser(u))
+	}
+}
+
+// handleSCIMUserCreate provisions a user. The directory of the client is
+// trusted with the email address, so it is marked as verified. Without a
+// password, the user can only log in in other ways until it sets one.
+func (s *server) handleSCIMUserCreate() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &scim.User{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, err.Error()))
+			return
+		}
+
+		if !s.checkSCIMUserName(rw, r, req.UserName, 0) {
+			return
+		}
+
+		password := req.Password
+
+		if password == "" {
+			var err error
+
+			if password, err = model.GenerateToken(); err != nil {
+				s.scimError(rw, r, err)
+				return
+			}
+		}
+
+		u := &model.User{
+			Email:    req.UserName,
+			Password: password,
+		}
+
+		if err := s.store.User().Create(u); err != nil {
+			s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error()))
+			return
+		}
+
+		s.audit(r, model.AuditUserCreated, r.Context().Value(contextKeyUser).(*model.User).ID, u.ID, map[string]string{"method": "scim"})
+
+		u.Sanitize()
+
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+
+		if err := s.store.User().UpdateEmail(u); err != nil {
+			s.scimError(rw, r, err)
+			return
+		}
+
+		if !req.IsActive() {
+			if err := s.store.User().SoftDelete(u.ID); err != nil {
+				s.scimError(rw, r, err)
+				return
+			}
+
+			u.DeletedAt = &now
+		}
+
+		res := s.scimUser(u)
+
+		rw.Header().Set("Location", res.Meta.Location)
+		s.scimRespond(rw, r, http.StatusCreated, res)
+	}
+}
+
+func (s *server) handleSCIMUserReplace() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, ok := s.findSCIMUser(rw, r)
+
+		if !ok {
+			return
+		}
+
+		req := &scim.User{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, err.Error()))
+			return
+		}
+
+		if !s.updateSCIMUser(rw, r, u, req) {
+			return
+		}
+
+		s.scimRespond(rw, r, http.StatusOK, s.scimUser(u))
+	}
+}
+
+func (s *server) handleSCIMUserPatch() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, ok := s.findSCIMUser(rw, r)
+
+		if !ok {
+			return
+		}
+
+		req := &scim.PatchRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, err.Error()))
+			return
+		}
+
+		patched := s.scimUser(u)
+
+		if err := req.Apply(patched); err != nil {
+			s.scimError(rw, r, err)
+			return
+		}
+
+		if !s.updateSCIMUser(rw, r, u, patched) {
+			return
+		}
+
+		s.scimRespond(rw, r, http.StatusOK, s.scimUser(u))
+	}
+}
+
+// handleSCIMUserDelete deletes a user for good. Clients that only want to
+// deactivate a user set active to false instead.
+func (s *server) handleSCIMUserDelete() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, ok := s.findSCIMUser(rw, r)
+
+		if !ok {
+			return
+		}
+
+		if err := s.store.User().Delete(u.ID); err != nil {
+			s.scimError(rw, r, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// updateSCIMUser applies the state described by req to u. A changed
+// userName is taken as a verified email address, a new password and
+// deactivation revoke all credentials of the user. It responds itself and
+// returns false when the update fails.
+func (s *server) updateSCIMUser(rw http.ResponseWriter, r *http.Request, u *model.User, req *scim.User) bool {
+	if req.UserName != u.Email {
+		if !s.checkSCIMUserName(rw, r, req.UserName, u.ID) {
+			return false
+		}
+
+		now := time.Now()
+		u.Email = req.UserName
+		u.EmailVerifiedAt = &now
+
+		if err := s.store.User().UpdateEmail(u); err != nil {
+			if err == store.ErrorEmailTaken {
+				s.scimError(rw, r, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, err.Error()))
+				return false
+			}
+
+			s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error()))
+			return false
+		}
+	}
+
+	revoke := false
+
+	if req.Password != "" {
+		u.Password = req.Password
+
+		if err := s.store.User().UpdatePassword(u); err != nil {
+			s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error()))
+			return false
+		}
+
+		u.Sanitize()
+		revoke = true
+
+		s.audit(r, model.AuditPasswordChanged, r.Context().Value(contextKeyUser).(*model.User).ID, u.ID, map[string]string{"method": "scim"})
+	}
+
+	if req.IsActive() && u.IsDeleted() {
+		err := s.store.User().Restore(u.ID)
+
+		if err == store.ErrorEmailTaken {
+			s.scimError(rw, r, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, err.Error()))
+			return false
+		}
+
+		if err != nil {
+			s.scimError(rw, r, err)
+			return false
+		}
+
+		u.DeletedAt = nil
+	}
+
+	if !req.IsActive() && !u.IsDeleted() {
+		if err := s.store.User().SoftDelete(u.ID); err != nil {
+			s.scimError(rw, r, err)
+			return false
+		}
+
+		now := time.Now()
+		u.DeletedAt = &now
+		revoke = true
+	}
+
+	if revoke {
+		if err := s.revokeCredentials(u.ID, ""); err != nil {
+			s.scimError(rw, r, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkSCIMUserName makes sure that userName is set and not taken by a user
+// other than the one with the given ID, deactivated users included. It
+// responds itself and returns false otherwise.
+func (s *server) checkSCIMUserName(rw http.ResponseWriter, r *http.Request, userName string, id int) bool {
+	if userName == "" {
+		s.scimError(rw, r, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, "userName is required"))
+		return false
+	}
+
+	f := &scim.Filter{Attribute: "userName", Operator: "eq", Value: userName}
+
+	// Two users are enough to find one other than the user with the ID.
+	users, _, err := s.scimQuery(r, f, 2)
+
+	if err != nil {
+		s.scimError(rw, r, err)
+		return false
+	}
+
+	for _, u := range users {
+		if u.ID != id {
+			s.scimError(rw, r, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "userName is already taken"))
+			return false
+		}
+	}
+
+	return true
+}
+
+// scimQuery returns the first limit users matching filter, which may be nil,
+// and the number of all users matching it. Filters on the ID and on whether
+// users are active are applied by the store, which then counts the matches
+// without the remaining users being read. Filters on the email address narrow
+// down the query to the store.
+func (s *server) scimQuery(r *http.Request, filter *scim.Filter, limit int) ([]*model.User, int, error) {
+	opts, exact := scimListOptions(filter)
+	users, total := []*model.User{}, 0
+
+	for {
+		page, next, err := s.store.User().List(r.Context(), opts)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, u := range page {
+			if filter != nil && !scimFilterMatches(filter, u) {
+				continue
+			}
+
+			if len(users) < limit {
+				users = append(users, u)
+			}
+
+			total++
+		}
+
+		if next == nil {
+			return users, total, nil
+		}
+
+		if exact && len(users) == limit {
+			total, err := s.store.User().Count(r.Context(), opts)
+
+			return users, total, err
+		}
+
+		opts.Cursor = next
+	}
+}
+
+// scimListOptions returns the options to list the users matching filter
+// with, deactivated users included, and whether they match exactly the users
+// the filter does.
+func scimListOptions(filter *scim.Filter) (store.ListOptions, bool) {
+	opts := store.ListOptions{
+		IncludeDeleted: true,
+		Limit:          store.MaxListLimit,
+	}
+
+	switch {
+	case filter == nil:
+		return opts, true
+	case filter.Is("id") && filter.Operator == "eq":
+		id, _ := filter.Value.(string)
+
+		if n, err := strconv.Atoi(id); err == nil && n > 0 {
+			opts.ID = n
+			return opts, true
+		}
+	case filter.Is("active") && (filter.Operator == "eq" || filter.Operator == "ne"):
+		if active, ok := filter.Value.(bool); ok {
+			if active == (filter.Operator == "eq") {
+				opts.IncludeDeleted = false
+			} else {
+				opts.OnlyDeleted = true
+			}
+
+			return opts, true
+		}
+	case filter.Is("userName") || filter.Is("emails") || filter.Is("emails.value"):
+		switch filter.Operator {
+		case "eq", "co", "sw", "ew":
+			opts.Email, _ = filter.Value.(string)
+		}
+	}
+
+	return opts, false
+}
+
+// findSCIMUser looks up the user in the path, deactivated users included. It
+// responds itself and returns false when there is no such user.
+func (s *server) findSCIMUser(rw http.ResponseWriter, r *http.Request) (*model.User, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err != nil {
+		s.scimError(rw, r, scim.NewError(http.StatusNotFound, "", store.ErrorRecordNotFound.Error()))
+		return nil, false
+	}
+
+	u, err := s.store.User().FindIncludingDeleted(id)
+
+	if err == store.ErrorRecordNotFound {
+		s.scimError(rw, r, scim.NewError(http.StatusNotFound, "", err.Error()))
+		return nil, false
+	}
+
+	if err != nil {
+		s.scimError(rw, r, err)
+		return nil, false
+	}
+
+	return u, true
+}
+
+// scimUser maps u onto the User resource. The email address is both the
+// userName and the only email, and deactivated users are the soft deleted
+// ones.
+func (s *server) scimUser(u *model.User) *scim.User {
+	id := strconv.Itoa(u.ID)
+	active := !u.IsDeleted()
+
+	return &scim.User{
+		Schemas:  []string{scim.SchemaUser},
+		ID:       id,
+		UserName: u.Email,
+		Emails: []scim.Email{
+			{
+				Value:   u.Email,
+				Type:    "work",
+				Primary: true,
+			},
+		},
+		Active: &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      u.CreatedAt,
+			LastModified: u.CreatedAt,
+			Location:     strings.TrimRight(s.config.PublicURL, "/") + "/scim/v2/Users/" + id,
+		},
+	}
+}
+
+func scimFilterSupported(f *scim.Filter) bool {
+	for _, attribute := range scimFilterAttributes {
+		if f.Is(attribute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func scimFilterMatches(f *scim.Filter, u *model.User) bool {
+	switch {
+	case f.Is("id"):
+		return f.MatchString(strconv.Itoa(u.ID))
+	case f.Is("active"):
+		return f.MatchBool(!u.IsDeleted())
+	default:
+		return f.MatchString(u.Email)
+	}
+}
+
+func (s *server) scimRespond(rw http.ResponseWriter, r *http.Request, code int, data interface{}) {
+	rw.Header().Set("Content-Type", scim.ContentType)
+	s.respond(rw, r, code, data)
+}
+
+// scimError responds with err in the format of RFC 7644, section 3.12.
+// Errors other than *scim.Error are internal server errors.
+func (s *server) scimError(rw http.ResponseWriter, r *http.Request, err error) {
+	e, ok := err.(*scim.Error)
+
+	if !ok {
+		e = scim.NewError(http.StatusInternalServerError, "", err.Error())
+	}
+
+	s.scimRespond(rw, r, e.StatusCode(), e)
+}
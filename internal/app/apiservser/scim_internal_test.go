@@ -0,0 +1,218 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/scim"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleSCIM(t *testing.T) {
+
+	admin := model.TestUser(t)
+	u := model.TestUser(t)
+	u.Email = "user@example.org"
+
+	store := teststore.New()
+
+	store.User().Create(admin)
+	store.User().Create(u)
+
+	testGrant(t, store, admin.ID, model.PermissionUsersRead, model.PermissionUsersWrite)
+
+	token := model.TestAPIToken(t, admin.ID)
+	token.Scopes = []string{model.ScopeAdminRead, model.ScopeAdminWrite}
+	store.APIToken().Create(token)
+
+	unprivileged := model.TestAPIToken(t, u.ID)
+	store.APIToken().Create(unprivileged)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	do := func(method, path string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(srv, method, path, http.Header{"Authorization": {"Bearer " + token.Token}}, payload)
+	}
+
+	decode := func(rec *httptest.ResponseRecorder) *scim.User {
+		res := &scim.User{}
+		json.NewDecoder(rec.Body).Decode(res)
+		return res
+	}
+
+	list := func(filter string) *scim.ListResponse {
+		rec := do(http.MethodGet, "/scim/v2/Users?filter="+url.QueryEscape(filter), nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		res := &scim.ListResponse{}
+		json.NewDecoder(rec.Body).Decode(res)
+		return res
+	}
+
+	t.Run("authentication", func(t *testing.T) {
+		cookie := testLogin(t, srv, admin.Email, admin.Password)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+		req.Header.Set("Cookie", cookie)
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, testRequest(srv, http.MethodGet, "/scim/v2/Users", http.Header{"Authorization": {"Bearer " + model.APITokenPrefix + "unknown"}}, nil).Code)
+		assert.Equal(t, http.StatusForbidden, testRequest(srv, http.MethodGet, "/scim/v2/Users", http.Header{"Authorization": {"Bearer " + unprivileged.Token}}, nil).Code)
+	})
+
+	t.Run("discovery", func(t *testing.T) {
+		rec := do(http.MethodGet, "/scim/v2/ServiceProviderConfig", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, scim.ContentType, rec.Header().Get("Content-Type"))
+
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/scim/v2/Schemas", nil).Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/scim/v2/Schemas/"+scim.SchemaUser, nil).Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/scim/v2/Schemas/unknown", nil).Code)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		testCases := []struct {
+			name         string
+			payload      interface{}
+			expectedCode int
+		}{
+			{
+				name: "valid",
+				payload: map[string]interface{}{
+					"schemas":  []string{scim.SchemaUser},
+					"userName": "scim@example.org",
+					"name":     map[string]string{"givenName": "Scim"},
+				},
+				expectedCode: http.StatusCreated,
+			},
+			{
+				name: "taken",
+				payload: map[string]interface{}{
+					"userName": "SCIM@example.org",
+				},
+				expectedCode: http.StatusConflict,
+			},
+			{
+				name: "not an email address",
+				payload: map[string]interface{}{
+					"userName": "scim",
+				},
+				expectedCode: http.StatusBadRequest,
+			},
+			{
+				name:         "invalid payload",
+				payload:      "invalid",
+				expectedCode: http.StatusBadRequest,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				rec := do(http.MethodPost, "/scim/v2/Users", tc.payload)
+				assert.Equal(t, tc.expectedCode, rec.Code)
+			})
+		}
+
+		created, err := store.User().FindByEmail("scim@example.org")
+		assert.NoError(t, err)
+		assert.True(t, created.IsEmailVerified())
+	})
+
+	t.Run("read and list", func(t *testing.T) {
+		rec := do(http.MethodGet, fmt.Sprintf("/scim/v2/Users/%d", u.ID), nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		res := decode(rec)
+		assert.Equal(t, u.Email, res.UserName)
+		assert.True(t, res.IsActive())
+		assert.True(t, strings.HasSuffix(res.Meta.Location, "/scim/v2/Users/"+res.ID))
+
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/scim/v2/Users/0", nil).Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/scim/v2/Users/abc", nil).Code)
+
+		assert.Equal(t, 1, list(`userName eq "USER@example.org"`).TotalResults)
+		assert.Equal(t, 2, list(`emails.value ew "@example.org"`).TotalResults)
+		assert.Equal(t, 0, list(`active eq false`).TotalResults)
+		assert.Equal(t, 3, list(`active eq true`).TotalResults)
+		assert.Equal(t, 1, list(fmt.Sprintf(`id eq "%d"`, u.ID)).TotalResults)
+
+		rec = do(http.MethodGet, "/scim/v2/Users?startIndex=2&count=1", nil)
+		page := &scim.ListResponse{}
+		json.NewDecoder(rec.Body).Decode(page)
+		assert.Equal(t, 3, page.TotalResults)
+		assert.Equal(t, 2, page.StartIndex)
+		assert.Equal(t, 1, page.ItemsPerPage)
+
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/scim/v2/Users?filter="+url.QueryEscape(`name.givenName eq "a"`), nil).Code)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/scim/v2/Users?filter=invalid", nil).Code)
+	})
+
+	t.Run("deactivate and reactivate", func(t *testing.T) {
+		cookie := testLogin(t, srv, u.Email, u.Password)
+		path := fmt.Sprintf("/scim/v2/Users/%d", u.ID)
+
+		rec := do(http.MethodPatch, path, map[string]interface{}{
+			"schemas": []string{scim.SchemaPatchOp},
+			"Operations": []map[string]interface{}{
+				{"op": "replace", "value": map[string]interface{}{"active": false}},
+			},
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.False(t, decode(rec).IsActive())
+
+		assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, cookie))
+		assert.Equal(t, 1, list(`active eq false`).TotalResults)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, path, nil).Code)
+
+		rec = do(http.MethodPatch, path, map[string]interface{}{
+			"Operations": []map[string]interface{}{
+				{"op": "Replace", "path": "active", "value": "True"},
+			},
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, decode(rec).IsActive())
+
+		rec = do(http.MethodPatch, path, map[string]interface{}{
+			"Operations": []map[string]interface{}{
+				{"op": "remove", "path": "userName"},
+			},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		path := fmt.Sprintf("/scim/v2/Users/%d", u.ID)
+
+		rec := do(http.MethodPut, path, map[string]interface{}{
+			"schemas":  []string{scim.SchemaUser},
+			"userName": "renamed@example.org",
+			"password": "new password",
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "renamed@example.org", decode(rec).UserName)
+
+		testLogin(t, srv, "renamed@example.org", "new password")
+
+		rec = do(http.MethodPut, path, map[string]interface{}{
+			"userName": admin.Email,
+		})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		path := fmt.Sprintf("/scim/v2/Users/%d", u.ID)
+
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, path, nil).Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, path, nil).Code)
+	})
+}
@@ -4,10 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
+	"webserver/internal/app/mailer"
 	"webserver/internal/app/model"
+	"webserver/internal/app/oidc"
+	"webserver/internal/app/secret"
 	"webserver/internal/app/store"
+	"webserver/internal/app/throttle"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
@@ -17,33 +24,71 @@ import (
 )
 
 const (
-	sessionName               = "ebweb"
-	contextKeyUser contextKey = iota
+	sessionName                     = "ebweb"
+	sessionTouchInterval            = time.Minute
+	contextKeyUser       contextKey = iota
 	contextKeyRequestID
+	contextKeySession
+	contextKeyScopes
+	contextKeyOrganization
+	contextKeyMembership
+	contextKeyRequestLog
 )
 
 var (
 	errorIncorrectEmailOrPassword = errors.New("incorrect email or password")
 	errorNotAuthenticated         = errors.New("not authenticated")
+	errorCurrentSession           = errors.New("current session can only be ended with DELETE /sessions")
+	errorNoSession                = errors.New("request is not authenticated with a session cookie")
+	errorInsufficientScope        = errors.New("insufficient scope")
+	errorPermissionDenied         = errors.New("permission denied")
 )
 
 type contextKey int8
 
+// requestLog collects what handlers learn about a request, such as the
+// authenticated user, for logRequest to log once the request is done.
+type requestLog struct {
+	userID int
+}
+
 type server struct {
-	router       *mux.Router
-	store        store.Store
-	logger       *logrus.Logger
-	sessionStore sessions.Store
+	router          *mux.Router
+	store           store.Store
+	logger          *logrus.Logger
+	sessionStore    sessions.Store
+	config          *Config
+	mailer          mailer.Mailer
+	accountThrottle *throttle.Throttler
+	ipThrottle      *throttle.Throttler
+	secrets         *secret.Box
+	oidcProviders   map[string]*oidc.Provider
+	authenticators  []authenticator
+	logSampler      *logSampler
+	background      sync.WaitGroup
+
+	magicLinkEmailThrottle     *throttle.Throttler
+	magicLinkIPThrottle        *throttle.Throttler
+	passwordResetEmailThrottle *throttle.Throttler
+	passwordResetIPThrottle    *throttle.Throttler
 }
 
-func newServer(store store.Store, sessionStore sessions.Store) *server {
+func newServer(store store.Store, sessionStore sessions.Store, config *Config) *server {
 	s := &server{
 		router:       mux.NewRouter(),
-		logger:       logrus.New(),
+		logger:       newLogger(config),
 		store:        store,
 		sessionStore: sessionStore,
+		config:       config,
 	}
 
+	s.mailer = newMailer(config, s.logger)
+	s.secrets = newSecretBox(config)
+	s.oidcProviders = newOIDCProviders(config)
+	s.authenticators = newAuthenticators(config, store, s.logger)
+	s.logSampler = newLogSampler(config.LogSampling)
+	s.configureThrottles(throttle.NewMemoryStore())
+
 	s.configureRouter()
 
 	return s
@@ -57,13 +102,98 @@ func (s *server) configureRouter() {
 	s.router.Use(s.setRequestID)
 	s.router.Use(s.logRequest)
 	s.router.Use(handlers.CORS(handlers.AllowedOrigins([]string{"*"})))
+	s.router.HandleFunc("/health", s.handleHealth()).Methods("GET")
 	s.router.HandleFunc("/users", s.handleUserCreate()).Methods("POST")
+	s.router.HandleFunc("/users/verify", s.handleUserVerify()).Methods("POST")
+	s.router.HandleFunc("/users/verify/resend", s.handleUserVerifyResend()).Methods("POST")
 	s.router.HandleFunc("/sessions", s.handleSessionCreate()).Methods("POST")
+	s.router.HandleFunc("/sessions/2fa", s.handleSessionTwoFactor()).Methods("POST")
+	s.router.HandleFunc("/sessions/magic-link", s.handleMagicLinkCreate()).Methods("POST")
+	s.router.HandleFunc("/sessions/magic-link/{token}", s.handleMagicLinkRedeem()).Methods("GET")
+	s.router.Handle("/sessions", s.authenticateUser(s.handleSessionDelete())).Methods("DELETE")
+	s.router.Handle("/sessions/impersonation", s.authenticateUser(s.handleImpersonationEnd())).Methods("DELETE")
+	s.router.HandleFunc("/auth/{provider}", s.handleOIDCStart()).Methods("GET")
+	s.router.HandleFunc("/auth/{provider}/callback", s.handleOIDCCallback()).Methods("GET")
+	s.router.Handle("/oauth/authorize", s.authenticateUser(s.forbidImpersonation(s.handleOAuthAuthorize()))).Methods("GET")
+	s.router.Handle("/oauth/authorize", s.authenticateUser(s.forbidImpersonation(s.handleOAuthConsent()))).Methods("POST")
+	s.router.HandleFunc("/oauth/token", s.handleOAuthToken()).Methods("POST")
+	s.router.HandleFunc("/oauth/introspect", s.handleOAuthIntrospect()).Methods("POST")
+	s.router.HandleFunc("/oauth/revoke", s.handleOAuthRevoke()).Methods("POST")
+	s.router.HandleFunc("/tokens", s.handleTokenCreate()).Methods("POST")
+	s.router.HandleFunc("/tokens", s.handleTokenRevoke()).Methods("DELETE")
+	s.router.HandleFunc("/password-resets", s.handlePasswordResetCreate()).Methods("POST")
+	s.router.HandleFunc("/password-resets/{token}", s.handlePasswordResetComplete()).Methods("PUT")
 
 	private := s.router.PathPrefix("/private").Subrouter()
 
 	private.Use(s.authenticateUser)
-	private.HandleFunc("/whoami", s.handleWhoAmI()).Methods("GET")
+	private.Handle("/whoami", s.requireScope(model.ScopeUserRead, s.handleWhoAmI())).Methods("GET")
+	private.Handle("/sessions", s.requireScope(model.ScopeSessionsRead, s.handleSessionList())).Methods("GET")
+	private.Handle("/sessions", s.requireScope(model.ScopeSessionsWrite, s.forbidImpersonation(s.handleSessionRevokeOthers()))).Methods("DELETE")
+	private.Handle("/sessions/{id}", s.requireScope(model.ScopeSessionsWrite, s.forbidImpersonation(s.handleSessionRevoke()))).Methods("DELETE")
+	private.Handle("/tokens", s.requireScope(model.ScopeTokensWrite, s.forbidImpersonation(s.handleAPITokenCreate()))).Methods("POST")
+	private.Handle("/tokens", s.requireScope(model.ScopeTokensRead, s.handleAPITokenList())).Methods("GET")
+	private.Handle("/tokens/{id:[0-9]+}", s.requireScope(model.ScopeTokensWrite, s.forbidImpersonation(s.handleAPITokenDelete()))).Methods("DELETE")
+	private.Handle("/password", s.requireScope(model.ScopeUserWrite, s.forbidImpersonation(s.handlePasswordUpdate()))).Methods("PUT")
+	private.Handle("/email", s.requireScope(model.ScopeUserWrite, s.forbidImpersonation(s.handleEmailUpdate()))).Methods("PUT")
+	private.Handle("/account", s.requireScope(model.ScopeUserWrite, s.forbidImpersonation(s.handleAccountDelete()))).Methods("DELETE")
+	private.Handle("/2fa", s.requireScope(model.ScopeUserWrite, s.forbidImpersonation(s.handleTwoFactorEnroll()))).Methods("POST")
+	private.Handle("/2fa/confirm", s.requireScope(model.ScopeUserWrite, s.forbidImpersonation(s.handleTwoFactorConfirm()))).Methods("POST")
+	private.Handle("/2fa", s.requireScope(model.ScopeUserWrite, s.forbidImpersonation(s.handleTwoFactorDisable()))).Methods("DELETE")
+	private.Handle("/identities", s.requireScope(model.ScopeUserRead, s.handleIdentityList())).Methods("GET")
+	private.Handle("/invitations/accept", s.requireScope(model.ScopeOrgsWrite, s.handleInvitationAccept())).Methods("POST")
+
+	admin := s.router.PathPrefix("/admin").Subrouter()
+
+	admin.Use(s.authenticateUser)
+	admin.Handle("/users", s.requirePermission(model.PermissionUsersRead, s.requireScope(model.ScopeAdminRead, s.handleAdminUserList()))).Methods("GET")
+	admin.Handle("/roles", s.requirePermission(model.PermissionRolesRead, s.requireScope(model.ScopeAdminRead, s.handleRoleList()))).Methods("GET")
+	admin.Handle("/roles", s.requirePermission(model.PermissionRolesWrite, s.requireScope(model.ScopeAdminWrite, s.handleRoleCreate()))).Methods("POST")
+	admin.Handle("/users/{id:[0-9]+}/roles", s.requirePermission(model.PermissionRolesRead, s.requireScope(model.ScopeAdminRead, s.handleUserRoleList()))).Methods("GET")
+	admin.Handle("/users/{id:[0-9]+}/roles/{role}", s.requirePermission(model.PermissionRolesWrite, s.requireScope(model.ScopeAdminWrite, s.handleUserRoleAssign()))).Methods("PUT")
+	admin.Handle("/users/{id:[0-9]+}/roles/{role}", s.requirePermission(model.PermissionRolesWrite, s.requireScope(model.ScopeAdminWrite, s.handleUserRoleUnassign()))).Methods("DELETE")
+	admin.Handle("/users/{id:[0-9]+}/impersonate", s.forbidImpersonation(s.requirePermission(model.PermissionUsersImpersonate, s.requireScope(model.ScopeAdminWrite, s.handleImpersonationStart())))).Methods("POST")
+	admin.Handle("/invitations", s.requirePermission(model.PermissionUsersRead, s.requireScope(model.ScopeAdminRead, s.handleInvitationList()))).Methods("GET")
+	admin.Handle("/invitations", s.requirePermission(model.PermissionUsersWrite, s.requireScope(model.ScopeAdminWrite, s.handleInvitationCreate()))).Methods("POST")
+	admin.Handle("/invitations/{invitation:[0-9]+}", s.requirePermission(model.PermissionUsersWrite, s.requireScope(model.ScopeAdminWrite, s.handleInvitationRevoke()))).Methods("DELETE")
+	admin.Handle("/invitations/{invitation:[0-9]+}/resend", s.requirePermission(model.PermissionUsersWrite, s.requireScope(model.ScopeAdminWrite, s.handleInvitationResend()))).Methods("POST")
+	admin.Handle("/oauth-clients", s.requirePermission(model.PermissionClientsRead, s.requireScope(model.ScopeAdminRead, s.handleOAuthClientList()))).Methods("GET")
+	admin.Handle("/oauth-clients", s.requirePermission(model.PermissionClientsWrite, s.requireScope(model.ScopeAdminWrite, s.handleOAuthClientCreate()))).Methods("POST")
+	admin.Handle("/oauth-clients/{id:[0-9]+}", s.requirePermission(model.PermissionClientsWrite, s.requireScope(model.ScopeAdminWrite, s.handleOAuthClientDelete()))).Methods("DELETE")
+	admin.Handle("/audit-events", s.requirePermission(model.PermissionAuditRead, s.requireScope(model.ScopeAdminRead, s.handleAuditEventList()))).Methods("GET")
+
+	organizations := s.router.PathPrefix("/orgs").Subrouter()
+
+	organizations.Use(s.authenticateUser)
+	organizations.Handle("", s.requireScope(model.ScopeOrgsRead, s.handleOrganizationList())).Methods("GET")
+	organizations.Handle("", s.requireScope(model.ScopeOrgsWrite, s.handleOrganizationCreate())).Methods("POST")
+
+	organization := organizations.PathPrefix("/{id:[0-9]+}").Subrouter()
+
+	organization.Use(s.requireMembership)
+	organization.Handle("", s.requireScope(model.ScopeOrgsRead, s.handleOrganizationGet())).Methods("GET")
+	organization.Handle("", s.requireScope(model.ScopeOrgsWrite, s.requireOrganizationRole(model.OrganizationRoleOwner, s.handleOrganizationDelete()))).Methods("DELETE")
+	organization.Handle("/members", s.requireScope(model.ScopeOrgsRead, s.handleMemberList())).Methods("GET")
+	organization.Handle("/members", s.requireScope(model.ScopeOrgsWrite, s.requireOrganizationRole(model.OrganizationRoleAdmin, s.handleMemberAdd()))).Methods("POST")
+	organization.Handle("/members/{user:[0-9]+}", s.requireScope(model.ScopeOrgsWrite, s.requireOrganizationRole(model.OrganizationRoleAdmin, s.handleMemberUpdate()))).Methods("PUT")
+	organization.Handle("/members/{user:[0-9]+}", s.requireScope(model.ScopeOrgsWrite, s.handleMemberRemove())).Methods("DELETE")
+	organization.Handle("/invitations", s.requireScope(model.ScopeOrgsRead, s.requireOrganizationRole(model.OrganizationRoleAdmin, s.handleInvitationList()))).Methods("GET")
+	organization.Handle("/invitations", s.requireScope(model.ScopeOrgsWrite, s.requireOrganizationRole(model.OrganizationRoleAdmin, s.handleInvitationCreate()))).Methods("POST")
+	organization.Handle("/invitations/{invitation:[0-9]+}", s.requireScope(model.ScopeOrgsWrite, s.requireOrganizationRole(model.OrganizationRoleAdmin, s.handleInvitationRevoke()))).Methods("DELETE")
+	organization.Handle("/invitations/{invitation:[0-9]+}/resend", s.requireScope(model.ScopeOrgsWrite, s.requireOrganizationRole(model.OrganizationRoleAdmin, s.handleInvitationResend()))).Methods("POST")
+
+	provisioning := s.router.PathPrefix("/scim/v2").Subrouter()
+
+	provisioning.Use(s.requireBearer, s.authenticateUser)
+	provisioning.Handle("/ServiceProviderConfig", s.handleSCIMServiceProviderConfig()).Methods("GET")
+	provisioning.Handle("/Schemas", s.handleSCIMSchemaList()).Methods("GET")
+	provisioning.Handle("/Schemas/{id}", s.handleSCIMSchema()).Methods("GET")
+	provisioning.Handle("/Users", s.requirePermission(model.PermissionUsersRead, s.requireScope(model.ScopeAdminRead, s.handleSCIMUserList()))).Methods("GET")
+	provisioning.Handle("/Users", s.requirePermission(model.PermissionUsersWrite, s.requireScope(model.ScopeAdminWrite, s.handleSCIMUserCreate()))).Methods("POST")
+	provisioning.Handle("/Users/{id}", s.requirePermission(model.PermissionUsersRead, s.requireScope(model.ScopeAdminRead, s.handleSCIMUserGet()))).Methods("GET")
+	provisioning.Handle("/Users/{id}", s.requirePermission(model.PermissionUsersWrite, s.requireScope(model.ScopeAdminWrite, s.handleSCIMUserReplace()))).Methods("PUT")
+	provisioning.Handle("/Users/{id}", s.requirePermission(model.PermissionUsersWrite, s.requireScope(model.ScopeAdminWrite, s.handleSCIMUserPatch()))).Methods("PATCH")
+	provisioning.Handle("/Users/{id}", s.requirePermission(model.PermissionUsersWrite, s.requireScope(model.ScopeAdminWrite, s.handleSCIMUserDelete()))).Methods("DELETE")
 }
 
 func (s *server) setRequestID(next http.Handler) http.Handler {
@@ -76,61 +206,251 @@ func (s *server) setRequestID(next http.Handler) http.Handler {
 
 func (s *server) authenticateUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		session, err := s.sessionStore.Get(r, sessionName)
+		var (
+			u      *model.User
+			sess   *model.Session
+			scopes []string
+			err    error
+		)
+
+		if token, ok := bearerToken(r); ok {
+			u, scopes, err = s.authenticateBearer(token)
+		} else {
+			u, sess, err = s.authenticateSession(r)
+		}
+
+		if err == errorNotAuthenticated {
+			s.error(rw, r, http.StatusUnauthorized, err)
+			return
+		}
 
 		if err != nil {
 			s.error(rw, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		id, ok := session.Values["user_id"]
+		if entry, ok := r.Context().Value(contextKeyRequestLog).(*requestLog); ok {
+			entry.userID = u.ID
+		}
 
-		if !ok {
-			s.error(rw, r, http.StatusUnauthorized, errorNotAuthenticated)
+		ctx := context.WithValue(r.Context(), contextKeyUser, u)
+
+		if sess != nil {
+			ctx = context.WithValue(ctx, contextKeySession, sess)
 		}
 
-		u, err := s.store.User().Find(id.(int))
+		if scopes != nil {
+			ctx = context.WithValue(ctx, contextKeyScopes, scopes)
+		}
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// requireScope rejects requests whose credentials are restricted to scopes
+// that do not include scope. Unrestricted credentials always pass.
+func (s *server) requireScope(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if scopes, ok := r.Context().Value(contextKeyScopes).([]string); ok && !model.HasScope(scopes, scope) {
+			s.error(rw, r, http.StatusForbidden, errorInsufficientScope)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// requirePermission rejects requests of users none of whose roles grant
+// permission. Roles are looked up on every request, so that changes to them
+// apply without logging in again.
+func (s *server) requirePermission(permission string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		ok, err := s.store.Role().HasPermission(u.ID, permission)
 
 		if err != nil {
-			s.error(rw, r, http.StatusUnauthorized, errorNotAuthenticated)
+			s.error(rw, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), contextKeyUser, u)))
+		if !ok {
+			s.error(rw, r, http.StatusForbidden, errorPermissionDenied)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
 	})
 }
 
+// authenticateBearer resolves the user behind a bearer token, which is a
+// personal access token, a token issued to an OAuth client or a signed access
+// token. It also returns the scopes the token is restricted to, if any.
+func (s *server) authenticateBearer(token string) (*model.User, []string, error) {
+	if strings.HasPrefix(token, model.APITokenPrefix) {
+		return s.authenticateAPIToken(token)
+	}
+
+	if strings.HasPrefix(token, model.OAuthTokenPrefix) {
+		return s.authenticateOAuthToken(token)
+	}
+
+	u, err := s.authenticateAccessToken(token)
+
+	return u, nil, err
+}
+
+// authenticateSession resolves the user behind the session cookie of r. The
+// cookie is only honoured while its server-side session record is active.
+func (s *server) authenticateSession(r *http.Request) (*model.User, *model.Session, error) {
+	session, err := s.sessionStore.Get(r, sessionName)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, ok := session.Values["user_id"].(int)
+
+	if !ok {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	sessionID, ok := session.Values["session_id"].(string)
+
+	if !ok {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	sess, err := s.store.Session().Find(sessionID)
+
+	if err != nil || sess.IsRevoked() || sess.IsExpired() || sess.UserID != id {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	u, err := s.store.User().Find(id)
+
+	if err != nil {
+		return nil, nil, errorNotAuthenticated
+	}
+
+	if time.Since(sess.LastSeenAt) > sessionTouchInterval {
+		if err := s.store.Session().Touch(sess.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return u, sess, nil
+}
+
+// logRequest logs every request once it has been handled, subject to the
+// sampling configured for its route. Requests failing with a server error are
+// logged regardless.
 func (s *server) logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		loger := s.logger.WithFields(logrus.Fields{
+		route := r.URL.Path
+
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		sampled := s.logSampler.sample(route)
+		entry := &requestLog{}
+
+		fields := logrus.Fields{
+			"method":      r.Method,
+			"path":        r.URL.Path,
 			"remote_addr": r.RemoteAddr,
 			"request_id":  r.Context().Value(contextKeyRequestID),
-		})
-		loger.Infof("started %s %s", r.Method, r.RequestURI)
+		}
+
+		if sampled {
+			s.logger.WithFields(fields).Debug("started request")
+		}
 
 		start := time.Now()
-		customRW := &responseWriter{rw, http.StatusOK}
+		customRW := &responseWriter{rw, http.StatusOK, 0}
+
+		next.ServeHTTP(customRW, r.WithContext(context.WithValue(r.Context(), contextKeyRequestLog, entry)))
+
+		if !sampled && customRW.code < http.StatusInternalServerError {
+			return
+		}
 
-		next.ServeHTTP(customRW, r)
+		fields["status"] = customRW.code
+		fields["duration"] = time.Since(start)
+		fields["bytes"] = customRW.bytes
 
-		loger.Infof("completed with %d %s in %v",
-		customRW.code,
-		http.StatusText(customRW.code),
-		time.Since(start))
+		if entry.userID != 0 {
+			fields["user_id"] = entry.userID
+		}
+
+		logger := s.logger.WithFields(fields)
+
+		if customRW.code >= http.StatusInternalServerError {
+			logger.Error("completed request")
+			return
+		}
+
+		logger.Info("completed request")
 	})
 }
 
+// handleHealth reports that the server is up, for load balancers and
+// orchestrators.
+func (s *server) handleHealth() http.HandlerFunc {
+
+	type response struct {
+		Status string `json:"status"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		s.respond(rw, r, http.StatusOK, &response{Status: "ok"})
+	}
+}
+
+// handleWhoAmI responds with the current user. Requests made while an admin
+// impersonates the user also get the admin and the end of the impersonation.
 func (s *server) handleWhoAmI() http.HandlerFunc {
+
+	type impersonation struct {
+		Impersonator *model.User `json:"impersonator"`
+		ExpiresAt    *time.Time  `json:"expires_at"`
+	}
+
+	type response struct {
+		*model.User
+		Impersonation *impersonation `json:"impersonation,omitempty"`
+	}
+
 	return func(rw http.ResponseWriter, r *http.Request) {
-		s.respond(rw, r, http.StatusOK, r.Context().Value(contextKeyUser).(*model.User))
+		res := &response{User: r.Context().Value(contextKeyUser).(*model.User)}
+
+		if sess, ok := r.Context().Value(contextKeySession).(*model.Session); ok && sess.IsImpersonated() {
+			impersonator, err := s.store.User().Find(*sess.ImpersonatorID)
+
+			if err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			res.Impersonation = &impersonation{
+				Impersonator: impersonator,
+				ExpiresAt:    sess.ExpiresAt,
+			}
+		}
+
+		s.respond(rw, r, http.StatusOK, res)
 	}
 }
 
 func (s *server) handleUserCreate() http.HandlerFunc {
 
 	type request struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email      string `json:"email"`
+		Password   string `json:"password"`
+		Invitation string `json:"invitation"`
 	}
 
 	return func(rw http.ResponseWriter, r *http.Request) {
@@ -141,6 +461,31 @@ func (s *server) handleUserCreate() http.HandlerFunc {
 			return
 		}
 
+		var invitation *model.Invitation
+
+		if req.Invitation != "" {
+			i, err := s.findInvitation(req.Invitation)
+
+			if err == nil && !strings.EqualFold(i.Email, req.Email) {
+				err = errorInvitationEmail
+			}
+
+			if err == errorInvalidInvitation || err == errorInvitationEmail {
+				s.error(rw, r, http.StatusBadRequest, err)
+				return
+			}
+
+			if err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			invitation = i
+		} else if s.config.InviteOnly {
+			s.error(rw, r, http.StatusForbidden, errorInvitationRequired)
+			return
+		}
+
 		u := &model.User{
 			Email:    req.Email,
 			Password: req.Password,
@@ -151,6 +496,25 @@ func (s *server) handleUserCreate() http.HandlerFunc {
 			return
 		}
 
+		s.audit(r, model.AuditUserCreated, u.ID, u.ID, nil)
+
+		if invitation != nil {
+			// The invitation was mailed to the address, which confirms it.
+			now := time.Now()
+			u.EmailVerifiedAt = &now
+
+			if err := s.store.User().UpdateEmail(u); err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if err := s.acceptInvitation(r, u, invitation); err != nil {
+				s.logger.WithField("user_id", u.ID).Errorf("accepting invitation: %v", err)
+			}
+		} else if err := s.sendEmailVerification(u, u.Email); err != nil {
+			s.logger.WithField("user_id", u.ID).Errorf("sending email verification: %v", err)
+		}
+
 		u.Sanitize()
 
 		s.respond(rw, r, http.StatusCreated, u)
@@ -172,29 +536,235 @@ func (s *server) handleSessionCreate() http.HandlerFunc {
 			return
 		}
 
-		u, err := s.store.User().FindByEmail(req.Email)
+		u, ok := s.checkCredentials(rw, r, req.Email, req.Password)
+
+		if !ok {
+			return
+		}
+
+		if u.IsTOTPEnabled() {
+			if err := s.startTwoFactorChallenge(rw, r, u); err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.respond(rw, r, http.StatusAccepted, map[string]bool{"two_factor_required": true})
+			return
+		}
 
-		if err != nil || !u.ComparePassword(req.Password) {
-			s.error(rw, r, http.StatusUnauthorized, errorIncorrectEmailOrPassword)
+		if err := s.startSession(rw, r, u); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		session, err := s.sessionStore.Get(r, sessionName)
+		s.respond(rw, r, http.StatusOK, nil)
+	}
+}
+
+func (s *server) handleSessionDelete() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		sess, ok := r.Context().Value(contextKeySession).(*model.Session)
+
+		if !ok {
+			s.error(rw, r, http.StatusBadRequest, errorNoSession)
+			return
+		}
+
+		if err := s.store.Session().Revoke(sess.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.clearSession(rw, r); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditSessionRevoked, sess.UserID, sess.UserID, map[string]string{"scope": "current"})
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+func (s *server) handleSessionList() http.HandlerFunc {
+
+	type session struct {
+		*model.Session
+		Current bool `json:"current"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+		current := currentSessionID(r)
+
+		list, err := s.store.Session().FindByUser(u.ID)
 
 		if err != nil {
 			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := make([]*session, 0, len(list))
+
+		for _, sess := range list {
+			res = append(res, &session{sess, sess.ID == current})
+		}
+
+		s.respond(rw, r, http.StatusOK, res)
+	}
+}
+
+func (s *server) handleSessionRevoke() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+		id := mux.Vars(r)["id"]
+
+		if id == currentSessionID(r) {
+			s.error(rw, r, http.StatusBadRequest, errorCurrentSession)
+			return
 		}
 
-		session.Values["user_id"] = u.ID
+		sess, err := s.store.Session().Find(id)
 
-		if err := s.sessionStore.Save(r, rw, session); err != nil {
+		if err != nil || sess.UserID != u.ID || sess.IsRevoked() {
+			s.error(rw, r, http.StatusNotFound, store.ErrorRecordNotFound)
+			return
+		}
+
+		if err := s.store.Session().Revoke(sess.ID); err != nil {
 			s.error(rw, r, http.StatusInternalServerError, err)
+			return
 		}
 
-		s.respond(rw, r, http.StatusOK, nil)
+		s.audit(r, model.AuditSessionRevoked, u.ID, u.ID, map[string]string{"scope": "single"})
+
+		s.respond(rw, r, http.StatusNoContent, nil)
 	}
 }
 
+func (s *server) handleSessionRevokeOthers() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(contextKeyUser).(*model.User)
+		if err := s.revokeCredentials(u.ID, currentSessionID(r)); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditSessionRevoked, u.ID, u.ID, map[string]string{"scope": "others"})
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// loginAllowed reports why u may not obtain new credentials, if at all.
+func (s *server) loginAllowed(u *model.User) error {
+	if !s.config.AllowUnverifiedLogin && !u.IsEmailVerified() {
+		return errorEmailNotVerified
+	}
+
+	return nil
+}
+
+// revokeCredentials ends every session, refresh token family and access token
+// of a user, except for the session with the given ID, which may be empty.
+func (s *server) revokeCredentials(userID int, exceptSession string) error {
+	if err := s.store.Session().RevokeAllByUser(userID, exceptSession); err != nil {
+		return err
+	}
+
+	if err := s.store.RefreshToken().RevokeAllByUser(userID); err != nil {
+		return err
+	}
+
+	return s.store.User().RevokeTokens(userID)
+}
+
+// clearSession removes the user from the client cookie and expires it.
+func (s *server) clearSession(rw http.ResponseWriter, r *http.Request) error {
+	session, err := s.sessionStore.Get(r, sessionName)
+
+	if err != nil {
+		return err
+	}
+
+	delete(session.Values, "user_id")
+	delete(session.Values, "session_id")
+	delete(session.Values, "impersonator_session_id")
+	session.Options.MaxAge = -1
+
+	return s.sessionStore.Save(r, rw, session)
+}
+
+// startSession records a new server-side session for u and binds it to the
+// client cookie, so that it can be revoked independently of the cookie.
+func (s *server) startSession(rw http.ResponseWriter, r *http.Request, u *model.User) error {
+	session, err := s.sessionStore.Get(r, sessionName)
+
+	if err != nil {
+		return err
+	}
+
+	sess := &model.Session{
+		UserID:    u.ID,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	if err := s.store.Session().Create(sess); err != nil {
+		return err
+	}
+
+	session.Values["user_id"] = u.ID
+	session.Values["session_id"] = sess.ID
+	delete(session.Values, "impersonator_session_id")
+
+	if err := s.sessionStore.Save(r, rw, session); err != nil {
+		return err
+	}
+
+	s.audit(r, model.AuditLoginSucceeded, u.ID, u.ID, nil)
+
+	return nil
+}
+
+// currentSessionID returns the ID of the session record the request was
+// authenticated with, or an empty string for token authenticated requests.
+func currentSessionID(r *http.Request) string {
+	if sess, ok := r.Context().Value(contextKeySession).(*model.Session); ok {
+		return sess.ID
+	}
+
+	return ""
+}
+
+// clientIP returns the host part of the remote address of r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// runInBackground runs f off the request path, so that neither how long it
+// takes nor whether it fails can be told from the response. Its error is
+// logged.
+func (s *server) runInBackground(r *http.Request, f func() error) {
+	logger := s.logger.WithField("request_id", r.Context().Value(contextKeyRequestID))
+
+	s.background.Add(1)
+
+	go func() {
+		defer s.background.Done()
+
+		if err := f(); err != nil {
+			logger.Errorf("background task: %v", err)
+		}
+	}()
+}
+
 func (s *server) error(rw http.ResponseWriter, r *http.Request, code int, err error) {
 	s.respond(rw, r, code, map[string]string{"error": err.Error()})
 }
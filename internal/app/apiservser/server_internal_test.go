@@ -6,15 +6,24 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"webserver/internal/app/model"
 	"webserver/internal/app/store/teststore"
 
 	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func TestMain(m *testing.M) {
+	model.SetPasswordHasher(&model.BcryptHasher{Cost: bcrypt.MinCost})
+
+	os.Exit(m.Run())
+}
 
 func Test_AuthenticateUser(t *testing.T) {
 
@@ -24,6 +33,16 @@ func Test_AuthenticateUser(t *testing.T) {
 
 	store.User().Create(u)
 
+	sess := model.TestSession(t, u.ID)
+
+	store.Session().Create(sess)
+
+	revoked := model.TestSession(t, u.ID)
+
+	store.Session().Create(revoked)
+
+	store.Session().Revoke(revoked.ID)
+
 	testCases := []struct {
 		name string
 		cookieValue map[interface{}]interface{}
@@ -32,10 +51,34 @@ func Test_AuthenticateUser(t *testing.T) {
 		{
 			name: "authenticated",
 			cookieValue: map[interface{}]interface{}{
-				"user_id": u.ID,
+				"user_id":    u.ID,
+				"session_id": sess.ID,
 			},
 			expectedCode: http.StatusOK,
 		},
+		{
+			name: "without session record",
+			cookieValue: map[interface{}]interface{}{
+				"user_id": u.ID,
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "revoked session",
+			cookieValue: map[interface{}]interface{}{
+				"user_id":    u.ID,
+				"session_id": revoked.ID,
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "session of another user",
+			cookieValue: map[interface{}]interface{}{
+				"user_id":    u.ID + 1,
+				"session_id": sess.ID,
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
 		{
 			name: "not authenticated",
 			cookieValue: nil,
@@ -44,7 +87,7 @@ func Test_AuthenticateUser(t *testing.T) {
 	}
 
 	secretKey := []byte("secret")
-	s := newServer(store, sessions.NewCookieStore(secretKey))
+	s := newServer(store, sessions.NewCookieStore(secretKey), testConfig())
 	sc := securecookie.New(secretKey, nil)
 
 	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
@@ -64,7 +107,7 @@ func Test_AuthenticateUser(t *testing.T) {
 }
 func Test_HandleUserCreate(t *testing.T) {
 
-	srv := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")))
+	srv := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), testConfig())
 
 	testCases := []struct {
 		name         string
@@ -113,7 +156,7 @@ func Test_HandleSessionCreate(t *testing.T) {
 
 	store.User().Create(u)
 
-	srv := newServer(store, sessions.NewCookieStore([]byte("secret")))
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
 
 	testCases := []struct {
 		name         string
@@ -162,3 +205,322 @@ func Test_HandleSessionCreate(t *testing.T) {
 		})
 	}
 }
+
+func Test_HandleSessionDelete(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	secretKey := []byte("secret")
+	srv := newServer(store, sessions.NewCookieStore(secretKey), testConfig())
+	sc := securecookie.New(secretKey, nil)
+
+	sess := model.TestSession(t, u.ID)
+
+	store.Session().Create(sess)
+
+	cookieStr, _ := sc.Encode(sessionName, map[interface{}]interface{}{
+		"user_id":    u.ID,
+		"session_id": sess.ID,
+	})
+
+	testCases := []struct {
+		name         string
+		expectedCode int
+	}{
+		{
+			name:         "logout",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "revoked cookie",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, "/sessions", nil)
+			req.Header.Set("Cookie", fmt.Sprintf("%s=%s", sessionName, cookieStr))
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func Test_SessionFlowWithServerSideStore(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	sessionStore := teststore.NewSessionStore([]byte("secret"))
+	srv := newServer(store, sessionStore, testConfig())
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"email":    u.Email,
+		"password": u.Password,
+	})
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/sessions", b)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, sessionStore.Len())
+
+	cookie := rec.Header().Get("Set-Cookie")
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/private/whoami", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/sessions", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, 0, sessionStore.Len())
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/private/whoami", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func Test_HandleSessionList(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+	testLogin(t, srv, u.Email, u.Password)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/private/sessions", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+
+	res := []map[string]interface{}{}
+	json.NewDecoder(rec.Body).Decode(&res)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, res, 2)
+}
+
+func Test_HandleSessionRevoke(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+	otherCookie := testLogin(t, srv, u.Email, u.Password)
+
+	var current, other string
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/private/sessions", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+
+	list := []struct {
+		ID      string `json:"id"`
+		Current bool   `json:"current"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(&list)
+
+	for _, sess := range list {
+		if sess.Current {
+			current = sess.ID
+		} else {
+			other = sess.ID
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		id           string
+		expectedCode int
+	}{
+		{
+			name:         "current session",
+			id:           current,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown session",
+			id:           "unknown",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "other session",
+			id:           other,
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "already revoked",
+			id:           other,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, "/private/sessions/"+tc.id, nil)
+			req.Header.Set("Cookie", cookie)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, otherCookie))
+}
+
+func Test_HandleSessionRevokeOthers(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+	otherCookie := testLogin(t, srv, u.Email, u.Password)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/private/sessions", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, cookie))
+	assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, otherCookie))
+}
+
+func testConfig() *Config {
+	config := NewConfig()
+	config.SessionKey = "secret"
+	config.JWTSecret = "secret"
+
+	return config
+}
+
+func testLogin(t *testing.T, srv *server, email, password string) string {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"email":    email,
+		"password": password,
+	})
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/sessions", b)
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login failed with %d", rec.Code)
+	}
+
+	return rec.Header().Get("Set-Cookie")
+}
+
+// testRequest serves a request with the given headers and, unless it is nil,
+// the payload encoded as a JSON body.
+func testRequest(srv *server, method, path string, header http.Header, payload interface{}) *httptest.ResponseRecorder {
+	b := &bytes.Buffer{}
+
+	if payload != nil {
+		json.NewEncoder(b).Encode(payload)
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, b)
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+func testWhoAmI(t *testing.T, srv *server, cookie string) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+	req.Header.Set("Cookie", cookie)
+	srv.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestServer_LogRequest(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+
+	config := testConfig()
+	config.LogSampling = map[string]int{"/health": 0, "/private/whoami": 2}
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), config)
+	cookie := testLogin(t, srv, u.Email, "password")
+
+	logger, hook := test.NewNullLogger()
+	srv.logger = logger
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/health", nil)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, hook.AllEntries())
+
+	for i := 0; i < 2; i++ {
+		testWhoAmI(t, srv, cookie)
+	}
+
+	if assert.Len(t, hook.AllEntries(), 1) {
+		entry := hook.LastEntry()
+		assert.Equal(t, logrus.InfoLevel, entry.Level)
+		assert.Equal(t, http.MethodGet, entry.Data["method"])
+		assert.Equal(t, "/private/whoami", entry.Data["path"])
+		assert.Equal(t, http.StatusOK, entry.Data["status"])
+		assert.Equal(t, u.ID, entry.Data["user_id"])
+		assert.NotEmpty(t, entry.Data["request_id"])
+		assert.NotZero(t, entry.Data["bytes"])
+		assert.Contains(t, entry.Data, "duration")
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	config := testConfig()
+	config.LogLevel = "warn"
+	config.LogFormat = logFormatJSON
+
+	logger := newLogger(config)
+	assert.Equal(t, logrus.WarnLevel, logger.Level)
+	assert.IsType(t, &logrus.JSONFormatter{}, logger.Formatter)
+}
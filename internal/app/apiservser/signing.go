@@ -0,0 +1,28 @@
+package apiserver
+
+import (
+	"time"
+
+	"github.com/gorilla/securecookie"
+)
+
+// signToken serializes v into a URL-safe token signed with the session key.
+// The purpose is part of the signature, so a token issued for one flow can
+// not be replayed against another.
+func (s *server) signToken(purpose string, v interface{}) (string, error) {
+	return s.tokenCodec(0).Encode(purpose, v)
+}
+
+// verifyToken checks the signature and age of a token created by signToken
+// and decodes its payload into v.
+func (s *server) verifyToken(purpose string, ttl time.Duration, token string, v interface{}) error {
+	return s.tokenCodec(ttl).Decode(purpose, token, v)
+}
+
+func (s *server) tokenCodec(ttl time.Duration) *securecookie.SecureCookie {
+	sc := securecookie.New([]byte(s.config.SessionKey), nil)
+	sc.SetSerializer(securecookie.JSONEncoder{})
+	sc.MaxAge(int(ttl.Seconds()))
+
+	return sc
+}
@@ -0,0 +1,253 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	grantTypePassword     = "password"
+	grantTypeRefreshToken = "refresh_token"
+)
+
+var (
+	errorUnsupportedGrantType = errors.New("unsupported grant type")
+	errorInvalidRefreshToken  = errors.New("invalid refresh token")
+)
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (s *server) handleTokenCreate() http.HandlerFunc {
+
+	type request struct {
+		GrantType    string `json:"grant_type"`
+		Email        string `json:"email"`
+		Password     string `json:"password"`
+		RefreshToken string `json:"refresh_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		switch req.GrantType {
+		case grantTypePassword:
+			u, ok := s.checkCredentials(rw, r, req.Email, req.Password)
+
+			if !ok {
+				return
+			}
+
+			if u.IsTOTPEnabled() && !s.checkSecondFactor(rw, r, u, req.Code, req.RecoveryCode) {
+				return
+			}
+
+			res, err := s.issueTokens(u.ID, "")
+
+			if err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.audit(r, model.AuditLoginSucceeded, u.ID, u.ID, map[string]string{"grant_type": grantTypePassword})
+
+			s.respond(rw, r, http.StatusOK, res)
+		case grantTypeRefreshToken:
+			rt, err := s.rotateRefreshToken(req.RefreshToken)
+
+			if err == errorInvalidRefreshToken {
+				s.error(rw, r, http.StatusUnauthorized, err)
+				return
+			}
+
+			if err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			res, err := s.issueTokens(rt.UserID, rt.FamilyID)
+
+			if err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.respond(rw, r, http.StatusOK, res)
+		default:
+			s.error(rw, r, http.StatusBadRequest, errorUnsupportedGrantType)
+		}
+	}
+}
+
+// handleTokenRevoke revokes the whole family of the given refresh token. It
+// always succeeds, so that it cannot be used to probe for valid tokens.
+func (s *server) handleTokenRevoke() http.HandlerFunc {
+
+	type request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		rt, err := s.store.RefreshToken().FindByToken(req.RefreshToken)
+
+		if err == nil {
+			if err := s.store.RefreshToken().RevokeFamily(rt.FamilyID); err != nil {
+				s.error(rw, r, http.StatusInternalServerError, err)
+				return
+			}
+		} else if err != store.ErrorRecordNotFound {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// rotateRefreshToken consumes a refresh token. Presenting a token that has
+// already been rotated means it has leaked, so its whole family is revoked.
+func (s *server) rotateRefreshToken(token string) (*model.RefreshToken, error) {
+	rt, err := s.store.RefreshToken().FindByToken(token)
+
+	if err == store.ErrorRecordNotFound {
+		return nil, errorInvalidRefreshToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rt.IsRevoked() || rt.IsExpired() {
+		return nil, errorInvalidRefreshToken
+	}
+
+	err = s.store.RefreshToken().MarkUsed(rt.ID)
+
+	if err == store.ErrorRecordNotFound {
+		if err := s.store.RefreshToken().RevokeFamily(rt.FamilyID); err != nil {
+			return nil, err
+		}
+
+		return nil, errorInvalidRefreshToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rt, nil
+}
+
+// issueTokens signs a new access token for the user and stores a new refresh
+// token in the given family. An empty family starts a new one.
+func (s *server) issueTokens(userID int, familyID string) (*tokenResponse, error) {
+	now := time.Now()
+
+	claims := jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   strconv.Itoa(userID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenTTL.Duration)),
+	}
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.config.JWTSecret))
+
+	if err != nil {
+		return nil, err
+	}
+
+	rt := &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(s.config.RefreshTokenTTL.Duration),
+	}
+
+	if err := s.store.RefreshToken().Create(rt); err != nil {
+		return nil, err
+	}
+
+	return &tokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.config.AccessTokenTTL.Seconds()),
+		RefreshToken: rt.Token,
+	}, nil
+}
+
+// authenticateAccessToken resolves the user behind a signed access token.
+func (s *server) authenticateAccessToken(token string) (*model.User, error) {
+	claims := &jwt.RegisteredClaims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, errorNotAuthenticated
+		}
+
+		return []byte(s.config.JWTSecret), nil
+	})
+
+	if err != nil {
+		return nil, errorNotAuthenticated
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+
+	if err != nil {
+		return nil, errorNotAuthenticated
+	}
+
+	u, err := s.store.User().Find(id)
+
+	if err != nil {
+		return nil, errorNotAuthenticated
+	}
+
+	// Access tokens are not stored, so they are revoked all at once by the
+	// time of revocation. The issue time only has a precision of seconds,
+	// which makes tokens issued in the same second count as revoked too.
+	if u.TokensRevokedAt != nil && (claims.IssuedAt == nil || claims.IssuedAt.Unix() <= u.TokensRevokedAt.Unix()) {
+		return nil, errorNotAuthenticated
+	}
+
+	return u, nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+
+	h := r.Header.Get("Authorization")
+
+	if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return h[len(prefix):], true
+}
@@ -0,0 +1,253 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleTokenCreate(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "valid",
+			payload: map[string]string{
+				"grant_type": grantTypePassword,
+				"email":      u.Email,
+				"password":   u.Password,
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "unsupported grant type",
+			payload: map[string]string{
+				"grant_type": "implicit",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid password",
+			payload: map[string]string{
+				"grant_type": grantTypePassword,
+				"email":      u.Email,
+				"password":   "invalid",
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "invalid refresh token",
+			payload: map[string]string{
+				"grant_type":    grantTypeRefreshToken,
+				"refresh_token": "invalid",
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/tokens", b)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func Test_BearerAuthentication(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	tokens := testRequestTokens(t, srv, map[string]string{
+		"grant_type": grantTypePassword,
+		"email":      u.Email,
+		"password":   u.Password,
+	})
+
+	testCases := []struct {
+		name         string
+		header       string
+		expectedCode int
+	}{
+		{
+			name:         "valid token",
+			header:       "Bearer " + tokens.AccessToken,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid token",
+			header:       "Bearer invalid",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "refresh token as access token",
+			header:       "Bearer " + tokens.RefreshToken,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+			req.Header.Set("Authorization", tc.header)
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func Test_AccessTokenRevocation(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	grant := map[string]string{
+		"grant_type": grantTypePassword,
+		"email":      u.Email,
+		"password":   u.Password,
+	}
+
+	whoAmI := func(token string) int {
+		return testRequest(srv, http.MethodGet, "/private/whoami", http.Header{"Authorization": {"Bearer " + token}}, nil).Code
+	}
+
+	tokens := testRequestTokens(t, srv, grant)
+	assert.Equal(t, http.StatusOK, whoAmI(tokens.AccessToken))
+
+	cookie := testLogin(t, srv, u.Email, u.Password)
+	assert.Equal(t, http.StatusNoContent, testRequest(srv, http.MethodDelete, "/private/sessions", http.Header{"Cookie": {cookie}}, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, whoAmI(tokens.AccessToken))
+	assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, cookie))
+
+	// Tokens issued after the revocation are not affected.
+	revokedAt := time.Now().Add(-time.Minute)
+	u.TokensRevokedAt = &revokedAt
+
+	assert.Equal(t, http.StatusOK, whoAmI(testRequestTokens(t, srv, grant).AccessToken))
+}
+
+func Test_RefreshTokenRotation(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	first := testRequestTokens(t, srv, map[string]string{
+		"grant_type": grantTypePassword,
+		"email":      u.Email,
+		"password":   u.Password,
+	})
+
+	second := testRequestTokens(t, srv, map[string]string{
+		"grant_type":    grantTypeRefreshToken,
+		"refresh_token": first.RefreshToken,
+	})
+
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	reuse := func(token string) int {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{
+			"grant_type":    grantTypeRefreshToken,
+			"refresh_token": token,
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/tokens", b)
+		srv.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, reuse(first.RefreshToken))
+	assert.Equal(t, http.StatusUnauthorized, reuse(second.RefreshToken))
+}
+
+func Test_HandleTokenRevoke(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	tokens := testRequestTokens(t, srv, map[string]string{
+		"grant_type": grantTypePassword,
+		"email":      u.Email,
+		"password":   u.Password,
+	})
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"refresh_token": tokens.RefreshToken,
+	})
+	req, _ := http.NewRequest(http.MethodDelete, "/tokens", b)
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rt, _ := store.RefreshToken().FindByToken(tokens.RefreshToken)
+	assert.True(t, rt.IsRevoked())
+}
+
+func testRequestTokens(t *testing.T, srv *server, payload map[string]string) *tokenResponse {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(payload)
+	req, _ := http.NewRequest(http.MethodPost, "/tokens", b)
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token request failed with %d", rec.Code)
+	}
+
+	res := &tokenResponse{}
+	json.NewDecoder(rec.Body).Decode(res)
+
+	return res
+}
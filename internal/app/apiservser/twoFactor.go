@@ -0,0 +1,379 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/secret"
+	"webserver/internal/app/store"
+	"webserver/internal/app/totp"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	// totpSkew is the number of time steps a code may be off, to allow for
+	// clock drift.
+	totpSkew = 1
+)
+
+var (
+	errorTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	errorTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	errorTwoFactorDisabled    = errors.New("two-factor authentication is not enabled")
+	errorInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	errorNoTwoFactorChallenge = errors.New("no pending two-factor challenge")
+)
+
+// newSecretBox returns the box TOTP secrets are sealed with. Its key falls
+// back to the session key.
+func newSecretBox(config *Config) *secret.Box {
+	if config.TOTPKey != "" {
+		return secret.NewBox(config.TOTPKey)
+	}
+
+	return secret.NewBox(config.SessionKey)
+}
+
+// handleTwoFactorEnroll starts a TOTP enrollment. The secret is stored, but
+// only enforced once it has been confirmed with a valid code.
+func (s *server) handleTwoFactorEnroll() http.HandlerFunc {
+
+	type request struct {
+		Password string `json:"password"`
+	}
+
+	type response struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		if !u.ComparePassword(req.Password) {
+			s.error(rw, r, http.StatusForbidden, errorIncorrectPassword)
+			return
+		}
+
+		if u.IsTOTPEnabled() {
+			s.error(rw, r, http.StatusConflict, errorTwoFactorEnabled)
+			return
+		}
+
+		key, err := totp.GenerateSecret()
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		sealed, err := s.secrets.Seal(key)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u.EncryptedTOTPSecret = sealed
+		u.TOTPLastStep = 0
+
+		if err := s.store.User().UpdateTOTP(u); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, &response{
+			Secret: key,
+			URI:    totp.URI(s.config.TOTPIssuer, u.Email, key),
+		})
+	}
+}
+
+// handleTwoFactorConfirm enables TOTP once the client proves it can generate
+// codes, and returns a fresh set of recovery codes.
+func (s *server) handleTwoFactorConfirm() http.HandlerFunc {
+
+	type request struct {
+		Code string `json:"code"`
+	}
+
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		if u.IsTOTPEnabled() {
+			s.error(rw, r, http.StatusConflict, errorTwoFactorEnabled)
+			return
+		}
+
+		if u.EncryptedTOTPSecret == "" {
+			s.error(rw, r, http.StatusBadRequest, errorTwoFactorNotEnrolled)
+			return
+		}
+
+		ok, err := s.checkTOTP(u, req.Code)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !ok {
+			s.error(rw, r, http.StatusUnprocessableEntity, errorInvalidTwoFactorCode)
+			return
+		}
+
+		now := time.Now()
+		u.TOTPEnabledAt = &now
+
+		if err := s.store.User().UpdateTOTP(u); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		codes, err := model.GenerateRecoveryCodes(u.ID)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.RecoveryCode().Replace(u.ID, codes); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := &response{RecoveryCodes: make([]string, len(codes))}
+
+		for i, c := range codes {
+			res.RecoveryCodes[i] = c.Code
+		}
+
+		s.respond(rw, r, http.StatusOK, res)
+	}
+}
+
+func (s *server) handleTwoFactorDisable() http.HandlerFunc {
+
+	type request struct {
+		Password string `json:"password"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(contextKeyUser).(*model.User)
+
+		if !u.ComparePassword(req.Password) {
+			s.error(rw, r, http.StatusForbidden, errorIncorrectPassword)
+			return
+		}
+
+		if u.EncryptedTOTPSecret == "" {
+			s.error(rw, r, http.StatusBadRequest, errorTwoFactorDisabled)
+			return
+		}
+
+		u.EncryptedTOTPSecret = ""
+		u.TOTPEnabledAt = nil
+		u.TOTPLastStep = 0
+
+		if err := s.store.User().UpdateTOTP(u); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.RecoveryCode().DeleteByUser(u.ID); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleSessionTwoFactor completes a login that POST /sessions left pending
+// with either a TOTP code or a recovery code.
+func (s *server) handleSessionTwoFactor() http.HandlerFunc {
+
+	type request struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(rw, r, http.StatusBadRequest, err)
+			return
+		}
+
+		session, err := s.sessionStore.Get(r, sessionName)
+
+		if err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		id, _ := session.Values["pending_user_id"].(int)
+		at, _ := session.Values["pending_at"].(int64)
+
+		if id == 0 || time.Since(time.Unix(at, 0)) > twoFactorChallengeTTL {
+			s.error(rw, r, http.StatusUnauthorized, errorNoTwoFactorChallenge)
+			return
+		}
+
+		u, err := s.store.User().Find(id)
+
+		if err != nil {
+			s.error(rw, r, http.StatusUnauthorized, errorNoTwoFactorChallenge)
+			return
+		}
+
+		if !s.checkSecondFactor(rw, r, u, req.Code, req.RecoveryCode) {
+			return
+		}
+
+		delete(session.Values, "pending_user_id")
+		delete(session.Values, "pending_at")
+
+		if err := s.startSession(rw, r, u); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(rw, r, http.StatusOK, nil)
+	}
+}
+
+// startTwoFactorChallenge remembers in the client cookie that u has passed
+// the first factor.
+func (s *server) startTwoFactorChallenge(rw http.ResponseWriter, r *http.Request, u *model.User) error {
+	session, err := s.sessionStore.Get(r, sessionName)
+
+	if err != nil {
+		return err
+	}
+
+	session.Values["pending_user_id"] = u.ID
+	session.Values["pending_at"] = time.Now().Unix()
+
+	return s.sessionStore.Save(r, rw, session)
+}
+
+// checkSecondFactor verifies a TOTP code or a recovery code of u. Failures
+// count towards the login throttle of the account. It responds itself and
+// returns false when the attempt is rejected.
+func (s *server) checkSecondFactor(rw http.ResponseWriter, r *http.Request, u *model.User, code, recoveryCode string) bool {
+	account := accountKey(u.Email)
+
+	wait, err := s.accountThrottle.Check(account)
+
+	if err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return false
+	}
+
+	if wait > 0 {
+		s.tooManyAttempts(rw, r, wait)
+		return false
+	}
+
+	var ok bool
+
+	if recoveryCode != "" {
+		err = s.store.RecoveryCode().Use(u.ID, recoveryCode)
+		ok = err == nil
+
+		if err == store.ErrorRecordNotFound {
+			err = nil
+		}
+	} else {
+		ok, err = s.checkTOTP(u, code)
+	}
+
+	if err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return false
+	}
+
+	if !ok {
+		factor := "totp"
+
+		if recoveryCode != "" {
+			factor = "recovery_code"
+		}
+
+		s.audit(r, model.AuditLoginFailed, 0, u.ID, map[string]string{"factor": factor})
+
+		if _, err := s.accountThrottle.Fail(account); err != nil {
+			s.error(rw, r, http.StatusInternalServerError, err)
+			return false
+		}
+
+		s.error(rw, r, http.StatusUnauthorized, errorInvalidTwoFactorCode)
+		return false
+	}
+
+	if err := s.accountThrottle.Reset(account); err != nil {
+		s.error(rw, r, http.StatusInternalServerError, err)
+		return false
+	}
+
+	return true
+}
+
+// checkTOTP verifies code against the TOTP secret of u. A code is accepted
+// only once, so the step it belongs to is persisted.
+func (s *server) checkTOTP(u *model.User, code string) (bool, error) {
+	key, err := s.secrets.Open(u.EncryptedTOTPSecret)
+
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := totp.Validate(key, code, time.Now(), totpSkew)
+
+	if !ok {
+		return false, nil
+	}
+
+	// Claiming the step in the store, rather than comparing it with u, keeps
+	// concurrent requests from using the same code twice.
+	if err := s.store.User().UseTOTPStep(u.ID, step); err != nil {
+		if err == store.ErrorRecordNotFound {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	u.TOTPLastStep = step
+
+	return true, nil
+}
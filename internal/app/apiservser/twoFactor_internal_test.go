@@ -0,0 +1,146 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store/teststore"
+	"webserver/internal/app/totp"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleTwoFactor(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), testConfig())
+
+	do := func(method, path, cookie string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(srv, method, path, http.Header{"Cookie": {cookie}}, payload)
+	}
+
+	credentials := map[string]string{"email": u.Email, "password": "password"}
+
+	cookie := testLogin(t, srv, u.Email, "password")
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/private/2fa", cookie, map[string]string{"password": "invalid"}).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/private/2fa/confirm", cookie, map[string]string{"code": "123456"}).Code)
+
+	rec := do(http.MethodPost, "/private/2fa", cookie, map[string]string{"password": "password"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	enrollment := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&enrollment)
+	key := enrollment["secret"]
+	assert.Contains(t, enrollment["uri"], "otpauth://totp/")
+
+	found, _ := store.User().Find(u.ID)
+	assert.NotContains(t, found.EncryptedTOTPSecret, key)
+	assert.False(t, found.IsTOTPEnabled())
+
+	code := func(offset int64) string {
+		c, _ := totp.Code(key, totp.Step(time.Now())+offset)
+		return c
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/private/2fa/confirm", cookie, map[string]string{"code": "invalid"}).Code)
+
+	rec = do(http.MethodPost, "/private/2fa/confirm", cookie, map[string]string{"code": code(0)})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := map[string][]string{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	recoveryCodes := res["recovery_codes"]
+	assert.Len(t, recoveryCodes, 10)
+
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/private/2fa/confirm", cookie, map[string]string{"code": code(1)}).Code)
+
+	t.Run("challenge", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/sessions/2fa", "", map[string]string{"code": code(1)}).Code)
+
+		rec := do(http.MethodPost, "/sessions", "", credentials)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		pending := rec.Header().Get("Set-Cookie")
+		assert.Equal(t, http.StatusUnauthorized, testWhoAmI(t, srv, pending))
+
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/sessions/2fa", pending, map[string]string{"code": code(0)}).Code)
+
+		rec = do(http.MethodPost, "/sessions/2fa", pending, map[string]string{"code": code(1)})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, http.StatusOK, testWhoAmI(t, srv, rec.Header().Get("Set-Cookie")))
+
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/sessions/2fa", pending, map[string]string{"code": code(1)}).Code)
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		pending := do(http.MethodPost, "/sessions", "", credentials).Header().Get("Set-Cookie")
+
+		rec := do(http.MethodPost, "/sessions/2fa", pending, map[string]string{"recovery_code": recoveryCodes[0]})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		pending = do(http.MethodPost, "/sessions", "", credentials).Header().Get("Set-Cookie")
+
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/sessions/2fa", pending, map[string]string{"recovery_code": recoveryCodes[0]}).Code)
+	})
+
+	t.Run("password grant", func(t *testing.T) {
+		payload := map[string]string{
+			"grant_type": grantTypePassword,
+			"email":      u.Email,
+			"password":   "password",
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/tokens", "", payload).Code)
+
+		payload["recovery_code"] = recoveryCodes[1]
+
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/tokens", "", payload).Code)
+	})
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/private/2fa", cookie, map[string]string{"password": "invalid"}).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/private/2fa", cookie, map[string]string{"password": "password"}).Code)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/sessions", "", credentials).Code)
+}
+
+func Test_HandleSessionTwoFactor_Throttle(t *testing.T) {
+
+	u := model.TestUser(t)
+
+	store := teststore.New()
+
+	store.User().Create(u)
+
+	config := testConfig()
+	config.LoginLockoutThreshold = 2
+
+	srv := newServer(store, sessions.NewCookieStore([]byte("secret")), config)
+
+	now := time.Now()
+	u.EncryptedTOTPSecret, _ = srv.secrets.Seal("JBSWY3DPEHPK3PXP")
+	u.TOTPEnabledAt = &now
+	store.User().UpdateTOTP(u)
+
+	do := func(path, cookie string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(srv, http.MethodPost, path, http.Header{"Cookie": {cookie}}, payload)
+	}
+
+	credentials := map[string]string{"email": u.Email, "password": "password"}
+
+	for i := 0; i < 2; i++ {
+		rec := do("/sessions", "", credentials)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, http.StatusUnauthorized, do("/sessions/2fa", rec.Header().Get("Set-Cookie"), map[string]string{"code": "invalid"}).Code)
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, do("/sessions", "", credentials).Code)
+}
@@ -0,0 +1,121 @@
+// Package ldap authenticates users with a bind against an LDAP directory.
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var (
+	// ErrorInvalidCredentials is returned when the directory rejects the
+	// bind or does not know the user.
+	ErrorInvalidCredentials = errors.New("ldap: invalid credentials")
+	// ErrorNoEmail is returned when the entry of the user has no email
+	// address.
+	ErrorNoEmail = errors.New("ldap: entry has no email address")
+)
+
+// Config configures a directory.
+type Config struct {
+	// URL is an ldap:// or ldaps:// URL of the server.
+	URL string
+	// BindDN is the DN users bind as, with %s standing for the username,
+	// such as "uid=%s,ou=people,dc=example,dc=org".
+	BindDN string
+	// BaseDN is where the entry of a user is searched after the bind.
+	BaseDN string
+	// Filter selects the entry of a user below BaseDN, with %s standing for
+	// the username, such as "(uid=%s)".
+	Filter string
+	// EmailAttribute is the attribute holding the email address of a user.
+	EmailAttribute string
+	// Timeout limits connecting to and every request against the server.
+	// Zero means ten seconds.
+	Timeout time.Duration
+}
+
+// Entry is the directory entry of an authenticated user.
+type Entry struct {
+	DN    string
+	Email string
+}
+
+// Directory authenticates users against an LDAP server. Every call opens
+// its own connection, so a Directory is safe for concurrent use.
+type Directory struct {
+	config Config
+}
+
+func New(config Config) *Directory {
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	return &Directory{config: config}
+}
+
+// Authenticate binds as username with password and returns the entry of the
+// user. The username is escaped before it is put into the bind DN and the
+// search filter.
+func (d *Directory) Authenticate(username, password string) (*Entry, error) {
+	// An empty password would make the bind unauthenticated, which most
+	// servers accept for any DN.
+	if username == "" || password == "" {
+		return nil, ErrorInvalidCredentials
+	}
+
+	conn, err := goldap.DialURL(d.config.URL, goldap.DialWithDialer(&net.Dialer{Timeout: d.config.Timeout}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	conn.SetTimeout(d.config.Timeout)
+
+	if err := conn.Bind(fmt.Sprintf(d.config.BindDN, goldap.EscapeDN(username)), password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, ErrorInvalidCredentials
+		}
+
+		return nil, err
+	}
+
+	res, err := conn.Search(goldap.NewSearchRequest(
+		d.config.BaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		2,
+		int(d.config.Timeout.Seconds()),
+		false,
+		fmt.Sprintf(d.config.Filter, goldap.EscapeFilter(username)),
+		[]string{d.config.EmailAttribute},
+		nil,
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Entries) != 1 {
+		return nil, fmt.Errorf("ldap: %d entries match %q", len(res.Entries), username)
+	}
+
+	e := &Entry{
+		DN:    res.Entries[0].DN,
+		Email: res.Entries[0].GetAttributeValue(d.config.EmailAttribute),
+	}
+
+	if e.Email == "" {
+		return nil, ErrorNoEmail
+	}
+
+	return e, nil
+}
@@ -0,0 +1,92 @@
+package ldap_test
+
+import (
+	"testing"
+	"webserver/internal/app/ldap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectory_Authenticate(t *testing.T) {
+	ts := ldap.NewTestServer(t)
+	ts.AddUser("alice", "secret", "alice@example.org")
+	ts.AddUser("bob", "secret", "")
+	ts.AddUser("a,b", "secret", "ab@example.org")
+
+	d := ldap.New(ts.Config())
+
+	testCases := []struct {
+		name          string
+		username      string
+		password      string
+		expectedEmail string
+		expectedError error
+	}{
+		{
+			name:          "valid",
+			username:      "alice",
+			password:      "secret",
+			expectedEmail: "alice@example.org",
+		},
+		{
+			name:          "wrong password",
+			username:      "alice",
+			password:      "wrong",
+			expectedError: ldap.ErrorInvalidCredentials,
+		},
+		{
+			name:          "empty password",
+			username:      "alice",
+			password:      "",
+			expectedError: ldap.ErrorInvalidCredentials,
+		},
+		{
+			name:          "unknown user",
+			username:      "carol",
+			password:      "secret",
+			expectedError: ldap.ErrorInvalidCredentials,
+		},
+		{
+			name:          "no email",
+			username:      "bob",
+			password:      "secret",
+			expectedError: ldap.ErrorNoEmail,
+		},
+		{
+			name:          "special characters",
+			username:      "a,b",
+			password:      "secret",
+			expectedEmail: "ab@example.org",
+		},
+		{
+			name:          "injection",
+			username:      "alice,ou=people",
+			password:      "secret",
+			expectedError: ldap.ErrorInvalidCredentials,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := d.Authenticate(tc.username, tc.password)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedEmail, e.Email)
+		})
+	}
+}
+
+func TestDirectory_Unavailable(t *testing.T) {
+	ts := ldap.NewTestServer(t)
+	config := ts.Config()
+	config.URL = "ldap://127.0.0.1:1"
+
+	_, err := ldap.New(config).Authenticate("alice", "secret")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ldap.ErrorInvalidCredentials)
+}
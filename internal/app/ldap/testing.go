@@ -0,0 +1,299 @@
+package ldap
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	testBaseDN   = "dc=example,dc=org"
+	testPeopleDN = "ou=people," + testBaseDN
+)
+
+// TestServer is an in-process LDAP server. It understands simple binds,
+// searches with equality, presence, and, or filters, and unbinds, which is
+// all Directory needs. Searches require a successful bind.
+type TestServer struct {
+	// URL is the ldap:// URL the server listens on.
+	URL string
+
+	listener net.Listener
+	mu       sync.Mutex
+	entries  map[string]*testEntry
+}
+
+type testEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+func NewTestServer(t *testing.T) *TestServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &TestServer{
+		URL:      "ldap://" + l.Addr().String(),
+		listener: l,
+		entries:  make(map[string]*testEntry),
+	}
+
+	go s.serve()
+
+	t.Cleanup(func() {
+		l.Close()
+	})
+
+	return s
+}
+
+// Config returns a configuration for users added with AddUser.
+func (s *TestServer) Config() Config {
+	return Config{
+		URL:            s.URL,
+		BindDN:         "uid=%s," + testPeopleDN,
+		BaseDN:         testBaseDN,
+		Filter:         "(uid=%s)",
+		EmailAttribute: "mail",
+	}
+}
+
+// AddUser adds a person entry below ou=people. An empty email leaves out
+// the mail attribute.
+func (s *TestServer) AddUser(uid, password, email string) {
+	attributes := map[string][]string{
+		"objectclass": {"inetOrgPerson"},
+		"uid":         {uid},
+	}
+
+	if email != "" {
+		attributes["mail"] = []string{email}
+	}
+
+	dn := fmt.Sprintf("uid=%s,%s", goldap.EscapeDN(uid), testPeopleDN)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[strings.ToLower(dn)] = &testEntry{
+		dn:         dn,
+		password:   password,
+		attributes: attributes,
+	}
+}
+
+func (s *TestServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *TestServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	bound := false
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		id, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			bound = s.bind(op)
+			code := goldap.LDAPResultSuccess
+
+			if !bound {
+				code = goldap.LDAPResultInvalidCredentials
+			}
+
+			conn.Write(testResult(id, goldap.ApplicationBindResponse, code).Bytes())
+		case goldap.ApplicationSearchRequest:
+			if !bound {
+				conn.Write(testResult(id, goldap.ApplicationSearchResultDone, goldap.LDAPResultInsufficientAccessRights).Bytes())
+				continue
+			}
+
+			for _, e := range s.search(op) {
+				conn.Write(testMessage(id, e).Bytes())
+			}
+
+			conn.Write(testResult(id, goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess).Bytes())
+		case goldap.ApplicationUnbindRequest:
+			return
+		default:
+			conn.Write(testResult(id, op.Tag+1, goldap.LDAPResultUnwillingToPerform).Bytes())
+		}
+	}
+}
+
+func (s *TestServer) bind(op *ber.Packet) bool {
+	if len(op.Children) < 3 {
+		return false
+	}
+
+	dn := op.Children[1].Data.String()
+	password := op.Children[2].Data.String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[strings.ToLower(dn)]
+
+	return ok && password != "" && e.password == password
+}
+
+// search returns the result entries of a search request.
+func (s *TestServer) search(op *ber.Packet) []*ber.Packet {
+	if len(op.Children) < 8 {
+		return nil
+	}
+
+	base := strings.ToLower(op.Children[0].Data.String())
+	filter := op.Children[6]
+
+	var attributes []string
+
+	for _, a := range op.Children[7].Children {
+		attributes = append(attributes, strings.ToLower(a.Data.String()))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []*ber.Packet
+
+	for key, e := range s.entries {
+		if key != base && !strings.HasSuffix(key, ","+base) {
+			continue
+		}
+
+		if !testMatch(filter, e) {
+			continue
+		}
+
+		results = append(results, testSearchEntry(e, attributes))
+	}
+
+	return results
+}
+
+func testMatch(filter *ber.Packet, e *testEntry) bool {
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !testMatch(child, e) {
+				return false
+			}
+		}
+
+		return true
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			if testMatch(child, e) {
+				return true
+			}
+		}
+
+		return false
+	case goldap.FilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+
+		want := filter.Children[1].Data.String()
+
+		for _, v := range e.attributes[strings.ToLower(filter.Children[0].Data.String())] {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+
+		return false
+	case goldap.FilterPresent:
+		_, ok := e.attributes[strings.ToLower(filter.Data.String())]
+		return ok
+	default:
+		return false
+	}
+}
+
+func testMessage(id int64, op *ber.Packet) *ber.Packet {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	envelope.AppendChild(op)
+
+	return envelope
+}
+
+func testResult(id int64, tag ber.Tag, code int) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+
+	return testMessage(id, op)
+}
+
+// testSearchEntry encodes e with the requested attributes, or with all of
+// them when none were requested.
+func testSearchEntry(e *testEntry, attributes []string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "Object Name"))
+
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+
+	for name, values := range e.attributes {
+		if len(attributes) > 0 && !testContains(attributes, name) {
+			continue
+		}
+
+		a := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		a.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "Value"))
+		}
+
+		a.AppendChild(set)
+		list.AppendChild(a)
+	}
+
+	op.AppendChild(list)
+
+	return op
+}
+
+func testContains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,23 @@
+package mailer
+
+import "github.com/sirupsen/logrus"
+
+// Log writes messages to a logger instead of delivering them.
+type Log struct {
+	logger logrus.FieldLogger
+}
+
+func NewLog(logger logrus.FieldLogger) *Log {
+	return &Log{
+		logger: logger,
+	}
+}
+
+func (m *Log) Send(msg *Message) error {
+	m.logger.WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info(msg.Body)
+
+	return nil
+}
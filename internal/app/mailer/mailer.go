@@ -0,0 +1,13 @@
+package mailer
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages to their recipients.
+type Mailer interface {
+	Send(*Message) error
+}
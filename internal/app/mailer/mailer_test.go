@@ -0,0 +1,34 @@
+package mailer_test
+
+import (
+	"bytes"
+	"testing"
+	"webserver/internal/app/mailer"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Send(t *testing.T) {
+	m := mailer.NewMemory()
+
+	assert.Nil(t, m.Last("user@example.org"))
+
+	assert.NoError(t, m.Send(&mailer.Message{To: "user@example.org", Subject: "first"}))
+	assert.NoError(t, m.Send(&mailer.Message{To: "user@example.org", Subject: "second"}))
+	assert.NoError(t, m.Send(&mailer.Message{To: "other@example.org", Subject: "other"}))
+
+	assert.Len(t, m.Messages("user@example.org"), 2)
+	assert.Equal(t, "second", m.Last("user@example.org").Subject)
+}
+
+func TestLog_Send(t *testing.T) {
+	b := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(b)
+
+	m := mailer.NewLog(logger)
+
+	assert.NoError(t, m.Send(&mailer.Message{To: "user@example.org", Subject: "hello", Body: "body"}))
+	assert.Contains(t, b.String(), "user@example.org")
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import "sync"
+
+// Memory keeps sent messages in memory so that tests can inspect them.
+type Memory struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Send(msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages returns all messages sent to the given address.
+func (m *Memory) Messages(to string) []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := []*Message{}
+
+	for _, msg := range m.messages {
+		if msg.To == to {
+			res = append(res, msg)
+		}
+	}
+
+	return res
+}
+
+// Last returns the most recent message sent to the given address, or nil.
+func (m *Memory) Last(to string) *Message {
+	messages := m.Messages(to)
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return messages[len(messages)-1]
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTP delivers messages through an SMTP relay.
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP returns a mailer for the relay at addr. Authentication is only used
+// when username is not empty.
+func NewSMTP(addr, from, username, password string) *SMTP {
+	m := &SMTP{
+		addr: addr,
+		from: from,
+	}
+
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m
+}
+
+func (m *SMTP) Send(msg *Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, m.build(msg))
+}
+
+func (m *SMTP) build(msg *Message) []byte {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "From: %s\r\n", m.from)
+	fmt.Fprintf(b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(b, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(b, "\r\n%s\r\n", msg.Body)
+
+	return []byte(b.String())
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// APITokenPrefix marks personal access tokens so that they can be told apart
+// from signed access tokens in an Authorization header.
+const APITokenPrefix = "ebp_"
+
+const (
+	ScopeUserRead      = "user:read"
+	ScopeUserWrite     = "user:write"
+	ScopeSessionsRead  = "sessions:read"
+	ScopeSessionsWrite = "sessions:write"
+	ScopeTokensRead    = "tokens:read"
+	ScopeTokensWrite   = "tokens:write"
+	ScopeOrgsRead      = "orgs:read"
+	ScopeOrgsWrite     = "orgs:write"
+	ScopeAdminRead     = "admin:read"
+	ScopeAdminWrite    = "admin:write"
+)
+
+// Scopes lists every scope an API token can be restricted to.
+var Scopes = []interface{}{
+	ScopeUserRead,
+	ScopeUserWrite,
+	ScopeSessionsRead,
+	ScopeSessionsWrite,
+	ScopeTokensRead,
+	ScopeTokensWrite,
+	ScopeOrgsRead,
+	ScopeOrgsWrite,
+	ScopeAdminRead,
+	ScopeAdminWrite,
+}
+
+// APIToken is a named personal access token. A token without scopes has the
+// same access as its owner.
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Token      string     `json:"token,omitempty"`
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func (t *APIToken) Validate() error {
+	return validation.ValidateStruct(
+		t,
+		validation.Field(&t.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&t.Scopes, validation.Each(validation.In(Scopes...))),
+		validation.Field(&t.ExpiresAt, validation.By(inFuture)))
+}
+
+func (t *APIToken) BeforeCreate() error {
+	token, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	t.Token = APITokenPrefix + token
+	t.TokenHash = HashToken(t.Token)
+
+	if t.Scopes == nil {
+		t.Scopes = []string{}
+	}
+
+	return nil
+}
+
+func (t *APIToken) Sanitize() {
+	t.Token = ""
+}
+
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// HasScope reports whether the token grants scope.
+func (t *APIToken) HasScope(scope string) bool {
+	return len(t.Scopes) == 0 || HasScope(t.Scopes, scope)
+}
+
+// HasScope reports whether scopes contains scope.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScopes reports whether scopes contains every scope in want.
+func HasScopes(scopes, want []string) bool {
+	for _, scope := range want {
+		if !HasScope(scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,91 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIToken_Validate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name    string
+		token   func() *model.APIToken
+		isValid bool
+	}{
+		{
+			name: "valid",
+			token: func() *model.APIToken {
+				return model.TestAPIToken(t, 1)
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			token: func() *model.APIToken {
+				at := model.TestAPIToken(t, 1)
+				at.Name = ""
+				return at
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown scope",
+			token: func() *model.APIToken {
+				at := model.TestAPIToken(t, 1)
+				at.Scopes = []string{"admin"}
+				return at
+			},
+			isValid: false,
+		},
+		{
+			name: "expired",
+			token: func() *model.APIToken {
+				at := model.TestAPIToken(t, 1)
+				at.ExpiresAt = &past
+				return at
+			},
+			isValid: false,
+		},
+		{
+			name: "expires in future",
+			token: func() *model.APIToken {
+				at := model.TestAPIToken(t, 1)
+				at.ExpiresAt = &future
+				return at
+			},
+			isValid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.token().Validate())
+			} else {
+				assert.Error(t, tc.token().Validate())
+			}
+		})
+	}
+}
+
+func TestAPIToken_BeforeCreate(t *testing.T) {
+	at := model.TestAPIToken(t, 1)
+	assert.NoError(t, at.BeforeCreate())
+	assert.True(t, strings.HasPrefix(at.Token, model.APITokenPrefix))
+	assert.Equal(t, model.HashToken(at.Token), at.TokenHash)
+}
+
+func TestAPIToken_HasScope(t *testing.T) {
+	at := model.TestAPIToken(t, 1)
+	assert.True(t, at.HasScope(model.ScopeTokensWrite))
+
+	at.Scopes = []string{model.ScopeUserRead}
+	assert.True(t, at.HasScope(model.ScopeUserRead))
+	assert.False(t, at.HasScope(model.ScopeTokensWrite))
+}
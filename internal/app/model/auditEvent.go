@@ -0,0 +1,107 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	AuditUserCreated          = "user.created"
+	AuditLoginSucceeded       = "login.succeeded"
+	AuditLoginFailed          = "login.failed"
+	AuditPasswordChanged      = "password.changed"
+	AuditSessionRevoked       = "session.revoked"
+	AuditRoleAssigned         = "role.assigned"
+	AuditRoleUnassigned       = "role.unassigned"
+	AuditMemberRoleChanged    = "member.role_changed"
+	AuditImpersonationStarted = "impersonation.started"
+	AuditImpersonationEnded   = "impersonation.ended"
+)
+
+var (
+	auditKeyMu sync.RWMutex
+	auditKey   []byte
+)
+
+// SetAuditKey changes the key the hashes of audit events are computed with.
+// The key has to be kept outside the database, so that whoever can write to
+// the audit log can not compute the hashes of events they change.
+func SetAuditKey(key []byte) {
+	auditKeyMu.Lock()
+	defer auditKeyMu.Unlock()
+
+	auditKey = key
+}
+
+func currentAuditKey() []byte {
+	auditKeyMu.RLock()
+	defer auditKeyMu.RUnlock()
+
+	return auditKey
+}
+
+// AuditEvent is an entry of the audit log. The actor is the user who caused
+// the event and the target the user it concerns, either may be unknown.
+// Every event carries the hash of the event before it, so that changing or
+// removing an event breaks the chain of hashes from there on. The hashes are
+// keyed with the key set by SetAuditKey.
+type AuditEvent struct {
+	ID        int               `json:"id"`
+	Action    string            `json:"action"`
+	ActorID   *int              `json:"actor_id"`
+	TargetID  *int              `json:"target_id"`
+	IP        string            `json:"ip"`
+	RequestID string            `json:"request_id"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// Seal timestamps the event and chains it to the event before it, whose hash
+// is prev. The timestamp is rounded to microseconds, so that it survives a
+// round trip through the database unchanged.
+func (e *AuditEvent) Seal(prev string) {
+	e.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	e.PrevHash = prev
+	e.Hash = e.ComputeHash()
+}
+
+// ComputeHash returns the hex-encoded HMAC-SHA256 under the audit key of
+// every field of the event except its ID and hash.
+func (e *AuditEvent) ComputeHash() string {
+	b, _ := json.Marshal(&struct {
+		Action    string            `json:"action"`
+		ActorID   *int              `json:"actor_id"`
+		TargetID  *int              `json:"target_id"`
+		IP        string            `json:"ip"`
+		RequestID string            `json:"request_id"`
+		Data      map[string]string `json:"data,omitempty"`
+		CreatedAt string            `json:"created_at"`
+		PrevHash  string            `json:"prev_hash"`
+	}{
+		Action:    e.Action,
+		ActorID:   e.ActorID,
+		TargetID:  e.TargetID,
+		IP:        e.IP,
+		RequestID: e.RequestID,
+		Data:      e.Data,
+		CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		PrevHash:  e.PrevHash,
+	})
+
+	mac := hmac.New(sha256.New, currentAuditKey())
+	mac.Write(b)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the event follows the event whose hash is prev and
+// is unchanged since it was sealed.
+func (e *AuditEvent) Verify(prev string) bool {
+	return e.PrevHash == prev && hmac.Equal([]byte(e.Hash), []byte(e.ComputeHash()))
+}
@@ -0,0 +1,46 @@
+package model_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEvent_Verify(t *testing.T) {
+	first := model.TestAuditEvent(t, model.AuditUserCreated)
+	first.Seal("")
+
+	assert.True(t, first.Verify(""))
+
+	second := model.TestAuditEvent(t, model.AuditLoginSucceeded)
+	second.Data = map[string]string{"method": "password"}
+	second.Seal(first.Hash)
+
+	assert.True(t, second.Verify(first.Hash))
+	assert.False(t, second.Verify(""))
+
+	second.Data["method"] = "magic-link"
+
+	assert.False(t, second.Verify(first.Hash))
+
+	actorID := 1
+	first.ActorID = &actorID
+
+	assert.False(t, first.Verify(""))
+}
+
+func TestAuditEvent_VerifyKey(t *testing.T) {
+	t.Cleanup(func() { model.SetAuditKey(nil) })
+
+	model.SetAuditKey([]byte("key"))
+
+	e := model.TestAuditEvent(t, model.AuditUserCreated)
+	e.Seal("")
+
+	assert.True(t, e.Verify(""))
+
+	model.SetAuditKey([]byte("other key"))
+
+	assert.False(t, e.Verify(""))
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Identity links a user to an account at an external identity provider. The
+// subject is the provider's stable identifier of that account.
+type Identity struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
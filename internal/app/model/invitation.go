@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+)
+
+// Invitation invites an email address to sign up, or to join an
+// organization. Invitations to an organization carry the role of the new
+// member in it. Other invitations may name a Role that is assigned to the
+// user who accepts them.
+type Invitation struct {
+	ID             int        `json:"id"`
+	OrganizationID *int       `json:"organization_id,omitempty"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (i *Invitation) Validate() error {
+	roleRules := []validation.Rule{}
+
+	if i.OrganizationID != nil {
+		roleRules = append(roleRules, validation.Required, validation.In(OrganizationRoles...))
+	}
+
+	return validation.ValidateStruct(
+		i,
+		validation.Field(&i.Email, validation.Required, is.Email),
+		validation.Field(&i.Role, roleRules...))
+}
+
+func (i *Invitation) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
+
+// IsPending reports whether the invitation can still be accepted.
+func (i *Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && !i.IsExpired()
+}
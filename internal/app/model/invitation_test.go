@@ -0,0 +1,87 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitation_Validate(t *testing.T) {
+	organizationID := 1
+
+	testCases := []struct {
+		name    string
+		i       func() *model.Invitation
+		isValid bool
+	}{
+		{
+			name: "valid",
+			i: func() *model.Invitation {
+				return model.TestInvitation(t, nil)
+			},
+			isValid: true,
+		},
+		{
+			name: "valid with organization",
+			i: func() *model.Invitation {
+				return model.TestInvitation(t, &organizationID)
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid email",
+			i: func() *model.Invitation {
+				i := model.TestInvitation(t, nil)
+				i.Email = "invalid"
+				return i
+			},
+			isValid: false,
+		},
+		{
+			name: "organization without role",
+			i: func() *model.Invitation {
+				i := model.TestInvitation(t, &organizationID)
+				i.Role = ""
+				return i
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown organization role",
+			i: func() *model.Invitation {
+				i := model.TestInvitation(t, &organizationID)
+				i.Role = "superuser"
+				return i
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.i().Validate())
+			} else {
+				assert.Error(t, tc.i().Validate())
+			}
+		})
+	}
+}
+
+func TestInvitation_IsPending(t *testing.T) {
+	i := model.TestInvitation(t, nil)
+
+	assert.True(t, i.IsPending())
+
+	i.ExpiresAt = time.Now().Add(-time.Minute)
+
+	assert.False(t, i.IsPending())
+
+	i = model.TestInvitation(t, nil)
+	now := time.Now()
+	i.RevokedAt = &now
+
+	assert.False(t, i.IsPending())
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// MagicLink is a single-use, time-limited permission to log in without a
+// password. It is bound to the browser it was requested from through a nonce,
+// of which, like of its token, only the hash is stored.
+type MagicLink struct {
+	ID        int
+	UserID    int
+	Token     string
+	TokenHash string
+	NonceHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (l *MagicLink) BeforeCreate() error {
+	token, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	l.Token = token
+	l.TokenHash = HashToken(token)
+
+	return nil
+}
+
+func (l *MagicLink) IsUsed() bool {
+	return l.UsedAt != nil
+}
+
+func (l *MagicLink) IsExpired() bool {
+	return time.Now().After(l.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package model_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLink_BeforeCreate(t *testing.T) {
+	l := model.TestMagicLink(t, 1, "nonce")
+	assert.NoError(t, l.BeforeCreate())
+	assert.NotEmpty(t, l.Token)
+	assert.Equal(t, model.HashToken(l.Token), l.TokenHash)
+	assert.Equal(t, model.HashToken("nonce"), l.NonceHash)
+	assert.False(t, l.IsUsed())
+	assert.False(t, l.IsExpired())
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"crypto/subtle"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+)
+
+// OAuthClient is a third-party application that obtains tokens from this
+// server. Confidential clients authenticate with a secret, of which only the
+// hash is stored. Tokens issued through the client credentials grant act on
+// behalf of the user that registered the client.
+type OAuthClient struct {
+	ID           int       `json:"id"`
+	ClientID     string    `json:"client_id"`
+	Secret       string    `json:"client_secret,omitempty"`
+	SecretHash   string    `json:"-"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	UserID       int       `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (c *OAuthClient) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.RedirectURIs, validation.By(requiredIf(!c.Confidential)), validation.Each(is.URL)),
+		validation.Field(&c.Scopes, validation.Required, validation.Each(validation.In(Scopes...))))
+}
+
+func (c *OAuthClient) BeforeCreate() error {
+	id, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	c.ClientID = id
+
+	if c.Confidential {
+		secret, err := GenerateToken()
+
+		if err != nil {
+			return err
+		}
+
+		c.Secret = secret
+		c.SecretHash = HashToken(secret)
+	}
+
+	if c.RedirectURIs == nil {
+		c.RedirectURIs = []string{}
+	}
+
+	return nil
+}
+
+func (c *OAuthClient) Sanitize() {
+	c.Secret = ""
+}
+
+// CompareSecret reports whether secret is the secret of a confidential
+// client.
+func (c *OAuthClient) CompareSecret(secret string) bool {
+	return c.SecretHash != "" && subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(c.SecretHash)) == 1
+}
+
+// HasRedirectURI reports whether uri exactly matches a registered redirect
+// URI.
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,105 @@
+package model_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClient_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		client  func() *model.OAuthClient
+		isValid bool
+	}{
+		{
+			name: "valid",
+			client: func() *model.OAuthClient {
+				return model.TestOAuthClient(t, 1)
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			client: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t, 1)
+				c.Name = ""
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid redirect uri",
+			client: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t, 1)
+				c.RedirectURIs = []string{"not a url"}
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "confidential without redirect uri",
+			client: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t, 1)
+				c.RedirectURIs = nil
+				return c
+			},
+			isValid: true,
+		},
+		{
+			name: "public without redirect uri",
+			client: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t, 1)
+				c.RedirectURIs = nil
+				c.Confidential = false
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "no scopes",
+			client: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t, 1)
+				c.Scopes = nil
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown scope",
+			client: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t, 1)
+				c.Scopes = []string{"admin"}
+				return c
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.client().Validate())
+			} else {
+				assert.Error(t, tc.client().Validate())
+			}
+		})
+	}
+}
+
+func TestOAuthClient_BeforeCreate(t *testing.T) {
+	c := model.TestOAuthClient(t, 1)
+	assert.NoError(t, c.BeforeCreate())
+	assert.NotEmpty(t, c.ClientID)
+	assert.True(t, c.CompareSecret(c.Secret))
+	assert.False(t, c.CompareSecret("wrong"))
+	assert.True(t, c.HasRedirectURI("https://partner.example.org/callback"))
+	assert.False(t, c.HasRedirectURI("https://partner.example.org/callback/other"))
+
+	public := model.TestOAuthClient(t, 1)
+	public.Confidential = false
+	assert.NoError(t, public.BeforeCreate())
+	assert.Empty(t, public.Secret)
+	assert.False(t, public.CompareSecret(""))
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// OAuthCode is a single-use authorization code. It is bound to the client,
+// the redirect URI and the PKCE challenge of the authorization request.
+type OAuthCode struct {
+	ID          int
+	ClientID    int
+	UserID      int
+	Code        string
+	CodeHash    string
+	RedirectURI string
+	Scopes      []string
+	Challenge   string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+}
+
+func (c *OAuthCode) BeforeCreate() error {
+	code, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	c.Code = code
+	c.CodeHash = HashToken(code)
+
+	if c.Scopes == nil {
+		c.Scopes = []string{}
+	}
+
+	return nil
+}
+
+func (c *OAuthCode) IsUsed() bool {
+	return c.UsedAt != nil
+}
+
+func (c *OAuthCode) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
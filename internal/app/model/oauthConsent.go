@@ -0,0 +1,14 @@
+package model
+
+// OAuthConsent records the scopes a user has granted to a client, so that
+// the user is not asked again for them.
+type OAuthConsent struct {
+	UserID   int
+	ClientID int
+	Scopes   []string
+}
+
+// Covers reports whether every scope in scopes has been granted.
+func (c *OAuthConsent) Covers(scopes []string) bool {
+	return HasScopes(c.Scopes, scopes)
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// OAuthTokenPrefix marks access tokens issued to OAuth clients.
+const OAuthTokenPrefix = "ebo_"
+
+// OAuthToken is an opaque access token issued to an OAuth client. It is
+// always restricted to its scopes.
+type OAuthToken struct {
+	ID        int
+	ClientID  int
+	UserID    int
+	Scopes    []string
+	Token     string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+func (t *OAuthToken) BeforeCreate() error {
+	token, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	t.Token = OAuthTokenPrefix + token
+	t.TokenHash = HashToken(t.Token)
+
+	if t.Scopes == nil {
+		t.Scopes = []string{}
+	}
+
+	return nil
+}
+
+func (t *OAuthToken) IsActive() bool {
+	return t.RevokedAt == nil && time.Now().Before(t.ExpiresAt)
+}
@@ -0,0 +1,36 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthToken_BeforeCreate(t *testing.T) {
+	tok := model.TestOAuthToken(t, 1, 1)
+	assert.NoError(t, tok.BeforeCreate())
+	assert.True(t, strings.HasPrefix(tok.Token, model.OAuthTokenPrefix))
+	assert.Equal(t, model.HashToken(tok.Token), tok.TokenHash)
+	assert.True(t, tok.IsActive())
+
+	tok.ExpiresAt = time.Now().Add(-time.Second)
+	assert.False(t, tok.IsActive())
+}
+
+func TestOAuthCode_BeforeCreate(t *testing.T) {
+	c := model.TestOAuthCode(t, 1, 1)
+	assert.NoError(t, c.BeforeCreate())
+	assert.Equal(t, model.HashToken(c.Code), c.CodeHash)
+	assert.False(t, c.IsUsed())
+	assert.False(t, c.IsExpired())
+}
+
+func TestOAuthConsent_Covers(t *testing.T) {
+	c := &model.OAuthConsent{Scopes: []string{model.ScopeUserRead, model.ScopeSessionsRead}}
+	assert.True(t, c.Covers([]string{model.ScopeUserRead}))
+	assert.True(t, c.Covers(nil))
+	assert.False(t, c.Covers([]string{model.ScopeUserRead, model.ScopeUserWrite}))
+}
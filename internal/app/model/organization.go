@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	OrganizationRoleMember = "member"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleOwner  = "owner"
+)
+
+// OrganizationRoles lists the roles a member can have in an organization,
+// from the least to the most privileged.
+var OrganizationRoles = []interface{}{
+	OrganizationRoleMember,
+	OrganizationRoleAdmin,
+	OrganizationRoleOwner,
+}
+
+// Organization is a group of users, such as a company or a team. Users
+// belong to organizations through memberships.
+type Organization struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (o *Organization) Validate() error {
+	return validation.ValidateStruct(
+		o,
+		validation.Field(&o.Name, validation.Required, validation.Length(1, 100)))
+}
+
+// Membership gives a user a role in an organization. Roles of different
+// organizations are independent of each other and of the roles in Role.
+type Membership struct {
+	OrganizationID int       `json:"organization_id"`
+	UserID         int       `json:"user_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (m *Membership) Validate() error {
+	return validation.ValidateStruct(
+		m,
+		validation.Field(&m.Role, validation.Required, validation.In(OrganizationRoles...)))
+}
+
+// HasRole reports whether the member has role or a more privileged one.
+func (m *Membership) HasRole(role string) bool {
+	want := organizationRoleRank(role)
+
+	return want >= 0 && organizationRoleRank(m.Role) >= want
+}
+
+func organizationRoleRank(role string) int {
+	for i, r := range OrganizationRoles {
+		if r == role {
+			return i
+		}
+	}
+
+	return -1
+}
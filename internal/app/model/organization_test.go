@@ -0,0 +1,73 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganization_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		o       func() *model.Organization
+		isValid bool
+	}{
+		{
+			name: "valid",
+			o: func() *model.Organization {
+				return model.TestOrganization(t)
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			o: func() *model.Organization {
+				o := model.TestOrganization(t)
+				o.Name = ""
+				return o
+			},
+			isValid: false,
+		},
+		{
+			name: "long name",
+			o: func() *model.Organization {
+				o := model.TestOrganization(t)
+				o.Name = strings.Repeat("a", 101)
+				return o
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.o().Validate())
+			} else {
+				assert.Error(t, tc.o().Validate())
+			}
+		})
+	}
+}
+
+func TestMembership_Validate(t *testing.T) {
+	m := model.TestMembership(t, 1, 1)
+
+	assert.NoError(t, m.Validate())
+
+	m.Role = "superuser"
+
+	assert.Error(t, m.Validate())
+}
+
+func TestMembership_HasRole(t *testing.T) {
+	m := model.TestMembership(t, 1, 1)
+	m.Role = model.OrganizationRoleAdmin
+
+	assert.True(t, m.HasRole(model.OrganizationRoleMember))
+	assert.True(t, m.HasRole(model.OrganizationRoleAdmin))
+	assert.False(t, m.HasRole(model.OrganizationRoleOwner))
+	assert.False(t, m.HasRole("unknown"))
+}
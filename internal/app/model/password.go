@@ -0,0 +1,171 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is the bcrypt cost passwords are hashed with unless
+// another hasher is set.
+const DefaultBcryptCost = 12
+
+var (
+	errorUnknownHashFormat = errors.New("unknown password hash format")
+
+	hasherMu sync.RWMutex
+	hasher   PasswordHasher = &BcryptHasher{Cost: DefaultBcryptCost}
+)
+
+// PasswordHasher hashes passwords into a self-describing format, which
+// records the algorithm and its parameters next to the hash.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether hash was produced by another algorithm or
+	// with other parameters than the hasher would use now.
+	NeedsRehash(hash string) bool
+}
+
+// SetPasswordHasher changes the hasher used for new passwords. Existing
+// hashes of any supported format keep working.
+func SetPasswordHasher(h PasswordHasher) {
+	hasherMu.Lock()
+	defer hasherMu.Unlock()
+
+	hasher = h
+}
+
+func currentHasher() PasswordHasher {
+	hasherMu.RLock()
+	defer hasherMu.RUnlock()
+
+	return hasher
+}
+
+// ComparePasswordHash reports whether password matches hash, which may be in
+// any supported format.
+func ComparePasswordHash(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		p, salt, key, err := decodeArgon2id(hash)
+
+		if err != nil {
+			return false
+		}
+
+		return subtle.ConstantTimeCompare(key, p.key(password, salt)) == 1
+	default:
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+}
+
+// BcryptHasher produces bcrypt hashes in the $2a$ format.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+
+	return err != nil || cost != h.Cost
+}
+
+// Argon2idHasher produces argon2id hashes in the PHC string format,
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>.
+type Argon2idHasher struct {
+	// Memory is in KiB.
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.Memory,
+		h.Time,
+		h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(h.key(password, salt))), nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	p, salt, key, err := decodeArgon2id(hash)
+
+	if err != nil {
+		return true
+	}
+
+	return p.Memory != h.Memory ||
+		p.Time != h.Time ||
+		p.Threads != h.Threads ||
+		uint32(len(salt)) != h.SaltLen ||
+		uint32(len(key)) != h.KeyLen
+}
+
+func (h *Argon2idHasher) key(password string, salt []byte) []byte {
+	return argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+}
+
+func decodeArgon2id(hash string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errorUnknownHashFormat
+	}
+
+	var version int
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errorUnknownHashFormat
+	}
+
+	p := &Argon2idHasher{}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return nil, nil, nil, errorUnknownHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+
+	if err != nil {
+		return nil, nil, nil, errorUnknownHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if err != nil {
+		return nil, nil, nil, errorUnknownHashFormat
+	}
+
+	p.SaltLen = uint32(len(salt))
+	p.KeyLen = uint32(len(key))
+
+	return p, salt, key, nil
+}
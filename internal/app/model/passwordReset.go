@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// PasswordReset is a single-use, time-limited permission to set a new
+// password. Only the hash of its token is stored.
+type PasswordReset struct {
+	ID        int
+	UserID    int
+	Token     string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (p *PasswordReset) BeforeCreate() error {
+	token, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	p.Token = token
+	p.TokenHash = HashToken(token)
+
+	return nil
+}
+
+func (p *PasswordReset) IsUsed() bool {
+	return p.UsedAt != nil
+}
+
+func (p *PasswordReset) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
@@ -0,0 +1,17 @@
+package model_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordReset_BeforeCreate(t *testing.T) {
+	p := model.TestPasswordReset(t, 1)
+	assert.NoError(t, p.BeforeCreate())
+	assert.NotEmpty(t, p.Token)
+	assert.Equal(t, model.HashToken(p.Token), p.TokenHash)
+	assert.False(t, p.IsUsed())
+	assert.False(t, p.IsExpired())
+}
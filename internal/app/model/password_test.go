@@ -0,0 +1,72 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	bcryptHasher := &model.BcryptHasher{Cost: 4}
+	argon2idHasher := &model.Argon2idHasher{
+		Memory:  1024,
+		Time:    1,
+		Threads: 1,
+		SaltLen: 16,
+		KeyLen:  32,
+	}
+
+	testCases := []struct {
+		name   string
+		hasher model.PasswordHasher
+		prefix string
+	}{
+		{
+			name:   "bcrypt",
+			hasher: bcryptHasher,
+			prefix: "$2a$04$",
+		},
+		{
+			name:   "argon2id",
+			hasher: argon2idHasher,
+			prefix: "$argon2id$v=19$m=1024,t=1,p=1$",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := tc.hasher.Hash("password")
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tc.prefix))
+			assert.True(t, model.ComparePasswordHash(hash, "password"))
+			assert.False(t, model.ComparePasswordHash(hash, "invalid"))
+			assert.False(t, tc.hasher.NeedsRehash(hash))
+		})
+	}
+
+	bcryptHash, _ := bcryptHasher.Hash("password")
+	argon2idHash, _ := argon2idHasher.Hash("password")
+
+	assert.True(t, argon2idHasher.NeedsRehash(bcryptHash))
+	assert.True(t, bcryptHasher.NeedsRehash(argon2idHash))
+	assert.True(t, (&model.BcryptHasher{Cost: 5}).NeedsRehash(bcryptHash))
+	assert.True(t, (&model.Argon2idHasher{Memory: 2048, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}).NeedsRehash(argon2idHash))
+
+	assert.False(t, model.ComparePasswordHash("$argon2id$v=19$invalid", "password"))
+}
+
+func TestUser_NeedsRehash(t *testing.T) {
+	u := model.TestUser(t)
+
+	assert.NoError(t, u.BeforeCreate())
+	assert.False(t, u.NeedsRehash())
+
+	model.SetPasswordHasher(&model.BcryptHasher{Cost: 5})
+	defer model.SetPasswordHasher(&model.BcryptHasher{Cost: 4})
+
+	assert.True(t, u.NeedsRehash())
+	assert.True(t, u.ComparePassword("password"))
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RecoveryCode is a single-use replacement for a TOTP code. Only the hash of
+// its normalized code is stored.
+type RecoveryCode struct {
+	ID       int
+	UserID   int
+	Code     string
+	CodeHash string
+	UsedAt   *time.Time
+}
+
+func (c *RecoveryCode) BeforeCreate() error {
+	if c.Code == "" {
+		b := make([]byte, 5)
+
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		c.Code = code[:4] + "-" + code[4:]
+	}
+
+	c.CodeHash = HashRecoveryCode(c.Code)
+
+	return nil
+}
+
+func (c *RecoveryCode) IsUsed() bool {
+	return c.UsedAt != nil
+}
+
+// GenerateRecoveryCodes returns a fresh set of recovery codes for a user.
+func GenerateRecoveryCodes(userID int) ([]*RecoveryCode, error) {
+	codes := make([]*RecoveryCode, recoveryCodeCount)
+
+	for i := range codes {
+		codes[i] = &RecoveryCode{UserID: userID}
+
+		if err := codes[i].BeforeCreate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
+
+// HashRecoveryCode hashes code, ignoring case, spaces and dashes.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	return HashToken(code)
+}
@@ -0,0 +1,30 @@
+package model_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := model.GenerateRecoveryCodes(1)
+
+	assert.NoError(t, err)
+	assert.Len(t, codes, 10)
+
+	seen := map[string]bool{}
+
+	for _, c := range codes {
+		assert.Len(t, c.Code, 9)
+		assert.Equal(t, 1, c.UserID)
+		assert.Equal(t, model.HashRecoveryCode(c.Code), c.CodeHash)
+		assert.False(t, seen[c.Code])
+		seen[c.Code] = true
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	assert.Equal(t, model.HashRecoveryCode("abcd-efgh"), model.HashRecoveryCode("ABCD EFGH"))
+	assert.NotEqual(t, model.HashRecoveryCode("abcd-efgh"), model.HashRecoveryCode("abcd-efgi"))
+}
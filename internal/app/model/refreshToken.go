@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a single-use token that is exchanged for a new access token
+// and a new refresh token of the same family.
+type RefreshToken struct {
+	ID        string
+	UserID    int
+	FamilyID  string
+	Token     string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func (t *RefreshToken) BeforeCreate() error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+
+	if t.FamilyID == "" {
+		t.FamilyID = uuid.New().String()
+	}
+
+	token, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	t.Token = token
+	t.TokenHash = HashToken(token)
+
+	return nil
+}
+
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package model_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken_BeforeCreate(t *testing.T) {
+	rt := model.TestRefreshToken(t, 1)
+	assert.NoError(t, rt.BeforeCreate())
+	assert.NotEmpty(t, rt.ID)
+	assert.NotEmpty(t, rt.FamilyID)
+	assert.NotEmpty(t, rt.Token)
+	assert.Equal(t, model.HashToken(rt.Token), rt.TokenHash)
+	assert.False(t, rt.IsExpired())
+}
@@ -0,0 +1,50 @@
+package model
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesWrite       = "roles:write"
+	PermissionClientsRead      = "clients:read"
+	PermissionClientsWrite     = "clients:write"
+	PermissionAuditRead        = "audit:read"
+	PermissionUsersImpersonate = "users:impersonate"
+)
+
+// Permissions lists every permission a role can grant.
+var Permissions = []interface{}{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionClientsRead,
+	PermissionClientsWrite,
+	PermissionAuditRead,
+	PermissionUsersImpersonate,
+}
+
+// Role is a named set of permissions that can be assigned to users.
+type Role struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+func (r *Role) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 64)),
+		validation.Field(&r.Permissions, validation.Each(validation.In(Permissions...))))
+}
+
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
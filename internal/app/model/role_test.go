@@ -0,0 +1,68 @@
+package model_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		role    func() *model.Role
+		isValid bool
+	}{
+		{
+			name: "valid",
+			role: func() *model.Role {
+				return model.TestRole(t)
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			role: func() *model.Role {
+				r := model.TestRole(t)
+				r.Name = ""
+				return r
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown permission",
+			role: func() *model.Role {
+				r := model.TestRole(t)
+				r.Permissions = []string{"everything"}
+				return r
+			},
+			isValid: false,
+		},
+		{
+			name: "no permissions",
+			role: func() *model.Role {
+				r := model.TestRole(t)
+				r.Permissions = nil
+				return r
+			},
+			isValid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.role().Validate())
+			} else {
+				assert.Error(t, tc.role().Validate())
+			}
+		})
+	}
+}
+
+func TestRole_HasPermission(t *testing.T) {
+	r := model.TestRole(t)
+
+	assert.True(t, r.HasPermission(model.PermissionUsersRead))
+	assert.False(t, r.HasPermission(model.PermissionRolesWrite))
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a server-side login of a user. Sessions started by an admin
+// impersonating the user carry the ID of the admin and expire on their own.
+type Session struct {
+	ID             string     `json:"id"`
+	UserID         int        `json:"-"`
+	ImpersonatorID *int       `json:"impersonator_id,omitempty"`
+	IP             string     `json:"ip"`
+	UserAgent      string     `json:"user_agent"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokedAt      *time.Time `json:"-"`
+}
+
+func (s *Session) BeforeCreate() {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+}
+
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+func (s *Session) IsExpired() bool {
+	return s.ExpiresAt != nil && time.Now().After(*s.ExpiresAt)
+}
+
+func (s *Session) IsImpersonated() bool {
+	return s.ImpersonatorID != nil
+}
@@ -0,0 +1,37 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_BeforeCreate(t *testing.T) {
+	s := model.TestSession(t, 1)
+	s.BeforeCreate()
+	assert.NotEmpty(t, s.ID)
+	assert.False(t, s.IsRevoked())
+}
+
+func TestSession_IsExpired(t *testing.T) {
+	s := model.TestSession(t, 1)
+	assert.False(t, s.IsExpired())
+
+	expiresAt := time.Now().Add(-time.Minute)
+	s.ExpiresAt = &expiresAt
+	assert.True(t, s.IsExpired())
+
+	expiresAt = time.Now().Add(time.Minute)
+	assert.False(t, s.IsExpired())
+}
+
+func TestSession_IsImpersonated(t *testing.T) {
+	s := model.TestSession(t, 1)
+	assert.False(t, s.IsImpersonated())
+
+	impersonatorID := 2
+	s.ImpersonatorID = &impersonatorID
+	assert.True(t, s.IsImpersonated())
+}
@@ -1,10 +1,130 @@
 package model
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestUser(t *testing.T) *User {
 	return &User{
 		Email: "e@gmail.com",
 		Password: "password",
 	}
-}
\ No newline at end of file
+}
+
+func TestSession(t *testing.T, userID int) *Session {
+	return &Session{
+		UserID: userID,
+	}
+}
+
+func TestRefreshToken(t *testing.T, userID int) *RefreshToken {
+	return &RefreshToken{
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestAPIToken(t *testing.T, userID int) *APIToken {
+	return &APIToken{
+		UserID: userID,
+		Name:   "ci",
+	}
+}
+
+func TestPasswordReset(t *testing.T, userID int) *PasswordReset {
+	return &PasswordReset{
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestMagicLink(t *testing.T, userID int, nonce string) *MagicLink {
+	return &MagicLink{
+		UserID:    userID,
+		NonceHash: HashToken(nonce),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestIdentity(t *testing.T, userID int) *Identity {
+	return &Identity{
+		UserID:   userID,
+		Provider: "example",
+		Subject:  "subject",
+		Email:    "e@gmail.com",
+	}
+}
+
+func TestOAuthClient(t *testing.T, userID int) *OAuthClient {
+	return &OAuthClient{
+		Name:         "partner",
+		RedirectURIs: []string{"https://partner.example.org/callback"},
+		Scopes:       []string{ScopeUserRead},
+		Confidential: true,
+		UserID:       userID,
+	}
+}
+
+func TestOAuthCode(t *testing.T, clientID, userID int) *OAuthCode {
+	return &OAuthCode{
+		ClientID:    clientID,
+		UserID:      userID,
+		RedirectURI: "https://partner.example.org/callback",
+		Scopes:      []string{ScopeUserRead},
+		Challenge:   "challenge",
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+}
+
+func TestOAuthToken(t *testing.T, clientID, userID int) *OAuthToken {
+	return &OAuthToken{
+		ClientID:  clientID,
+		UserID:    userID,
+		Scopes:    []string{ScopeUserRead},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestRole(t *testing.T) *Role {
+	return &Role{
+		Name:        "editor",
+		Permissions: []string{PermissionUsersRead},
+	}
+}
+
+func TestOrganization(t *testing.T) *Organization {
+	return &Organization{
+		Name: "Example",
+	}
+}
+
+func TestMembership(t *testing.T, organizationID, userID int) *Membership {
+	return &Membership{
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           OrganizationRoleMember,
+	}
+}
+
+func TestInvitation(t *testing.T, organizationID *int) *Invitation {
+	i := &Invitation{
+		OrganizationID: organizationID,
+		Email:          "invitee@example.org",
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	if organizationID != nil {
+		i.Role = OrganizationRoleMember
+	}
+
+	return i
+}
+
+func TestAuditEvent(t *testing.T, action string) *AuditEvent {
+	return &AuditEvent{
+		Action:    action,
+		IP:        "192.0.2.1",
+		RequestID: "request",
+	}
+}
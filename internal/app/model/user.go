@@ -1,16 +1,24 @@
 package model
 
 import (
+	"time"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID                int    `json:"id"`
-	Email             string `json:"email"`
-	Password          string `json:"password,omitempty"`
-	EncryptedPassword string `json:"-"`
+	ID                  int        `json:"id"`
+	Email               string     `json:"email"`
+	Password            string     `json:"password,omitempty"`
+	EncryptedPassword   string     `json:"-"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	DeletedAt           *time.Time `json:"deleted_at,omitempty"`
+	EncryptedTOTPSecret string     `json:"-"`
+	TOTPEnabledAt       *time.Time `json:"totp_enabled_at,omitempty"`
+	TOTPLastStep        int64      `json:"-"`
+	TokensRevokedAt     *time.Time `json:"-"`
 }
 
 func (u *User) Validate() error {
@@ -34,20 +42,56 @@ func (u *User) BeforeCreate() error {
 	return nil
 }
 
+// SetPassword replaces the encrypted password of u, applying the same rules
+// as Validate and BeforeCreate do for new users.
+func (u *User) SetPassword(password string) error {
+	tmp := &User{
+		Email:    u.Email,
+		Password: password,
+	}
+
+	if err := tmp.Validate(); err != nil {
+		return err
+	}
+
+	if err := tmp.BeforeCreate(); err != nil {
+		return err
+	}
+
+	u.Password = password
+	u.EncryptedPassword = tmp.EncryptedPassword
+
+	return nil
+}
+
 func (u *User) Sanitize() {
 	u.Password = ""
 }
 
-func (u *User) ComparePassword(password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(u.EncryptedPassword), []byte(password)) == nil
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
-func encryptString(s string) (string, error) {
-	b, err := bcrypt.GenerateFromPassword([]byte(s), bcrypt.MinCost)
+// IsTOTPEnabled reports whether u has confirmed a TOTP secret. The secret is
+// stored from the start of the enrollment, but only enforced once confirmed.
+func (u *User) IsTOTPEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
 
-	if err != nil {
-		return "", err
-	}
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
 
-	return string(b), nil
+func (u *User) ComparePassword(password string) bool {
+	return ComparePasswordHash(u.EncryptedPassword, password)
+}
+
+// NeedsRehash reports whether the encrypted password of u is outdated and
+// should be replaced on the next successful login.
+func (u *User) NeedsRehash() bool {
+	return currentHasher().NeedsRehash(u.EncryptedPassword)
+}
+
+func encryptString(s string) (string, error) {
+	return currentHasher().Hash(s)
 }
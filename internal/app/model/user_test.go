@@ -73,4 +73,18 @@ func TestUser_BeforeCreate(t *testing.T) {
 	u := model.TestUser(t)
 	assert.NoError(t, u.BeforeCreate())
 	assert.NotEmpty(t, u.EncryptedPassword)
-}
\ No newline at end of file
+}
+func TestUser_SetPassword(t *testing.T) {
+	u := model.TestUser(t)
+	assert.NoError(t, u.BeforeCreate())
+
+	encrypted := u.EncryptedPassword
+
+	assert.Error(t, u.SetPassword(""))
+	assert.Error(t, u.SetPassword("1234"))
+	assert.Equal(t, encrypted, u.EncryptedPassword)
+
+	assert.NoError(t, u.SetPassword("new-password"))
+	assert.True(t, u.ComparePassword("new-password"))
+	assert.False(t, u.ComparePassword("password"))
+}
@@ -1,6 +1,11 @@
 package model
 
-import validation "github.com/go-ozzo/ozzo-validation"
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
 
 func requiredIf(cond bool) validation.RuleFunc {
 	return func(value interface{}) error {
@@ -11,4 +16,13 @@ func requiredIf(cond bool) validation.RuleFunc {
 
 		return nil
 	}
-}	
\ No newline at end of file
+}	
+func inFuture(value interface{}) error {
+	t, ok := value.(*time.Time)
+
+	if ok && t != nil && !t.After(time.Now()) {
+		return errors.New("must be in the future")
+	}
+
+	return nil
+}
@@ -0,0 +1,413 @@
+// Package oidc is a relying party for OpenID Connect providers. It implements
+// discovery, the authorization code flow with PKCE and the verification of
+// RS256 signed ID tokens against the provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrorInvalidIDToken = errors.New("invalid id token")
+	errorIssuerMismatch = errors.New("discovered issuer does not match the configured one")
+	errorNoIDToken      = errors.New("token response contains no id_token")
+	errorUnknownKey     = errors.New("id token is signed with an unknown key")
+)
+
+const (
+	defaultTimeout            = 10 * time.Second
+	defaultKeyRefreshInterval = time.Minute
+)
+
+// Config identifies this server as a client of a provider.
+// KeyRefreshInterval is the least time between two fetches of the provider's
+// keys, one minute when it is zero.
+type Config struct {
+	Issuer             string
+	ClientID           string
+	ClientSecret       string
+	RedirectURL        string
+	Scopes             []string
+	KeyRefreshInterval time.Duration
+}
+
+// Metadata is the part of the provider's discovery document the flow needs.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the claims of an ID token.
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// Provider talks to a single OpenID provider. Its metadata and keys are
+// fetched on first use, and the keys are fetched again when a token is
+// signed with one that is not known yet, at most once per
+// Config.KeyRefreshInterval.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu          sync.Mutex
+	metadata    *Metadata
+	discovering chan struct{}
+	keys        map[string]*rsa.PublicKey
+	refreshed   time.Time
+	refreshing  chan struct{}
+}
+
+// New returns a provider for config. A nil client means a client that gives
+// up on requests after ten seconds.
+func New(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email"}
+	}
+
+	if config.KeyRefreshInterval <= 0 {
+		config.KeyRefreshInterval = defaultKeyRefreshInterval
+	}
+
+	return &Provider{
+		config: config,
+		client: client,
+	}
+}
+
+// AuthCodeURL returns the URL to send the user to. The state and nonce are
+// echoed back in the redirect and the ID token, and challenge is the PKCE
+// challenge of a verifier that has to be passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	m, err := p.discover(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(m.AuthorizationEndpoint)
+
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.config.ClientID)
+	q.Set("redirect_uri", p.config.RedirectURL)
+	q.Set("scope", strings.Join(p.config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the raw ID token of the
+// response. The token still has to be passed to Verify.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	m, err := p.discover(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	res := struct {
+		IDToken string `json:"id_token"`
+	}{}
+
+	if err := p.do(req, &res); err != nil {
+		return "", err
+	}
+
+	if res.IDToken == "" {
+		return "", errorNoIDToken
+	}
+
+	return res.IDToken, nil
+}
+
+// Verify checks the signature, issuer, audience, expiry and nonce of an ID
+// token and returns its claims.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	m, err := p.discover(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+
+	if _, err := jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return p.key(ctx, m, kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()})); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidIDToken, err)
+	}
+
+	switch {
+	case claims.Issuer != m.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrorInvalidIDToken, claims.Issuer)
+	case !claims.VerifyAudience(p.config.ClientID, true):
+		return nil, fmt.Errorf("%w: unexpected audience", ErrorInvalidIDToken)
+	case claims.ExpiresAt == nil:
+		return nil, fmt.Errorf("%w: missing expiry", ErrorInvalidIDToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrorInvalidIDToken)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrorInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// discover returns the metadata of the provider, fetching it on first use.
+// Callers that find a fetch in flight wait for it rather than fetching again,
+// and fetch themselves if it failed.
+func (p *Provider) discover(ctx context.Context) (*Metadata, error) {
+	for {
+		p.mu.Lock()
+
+		if m := p.metadata; m != nil {
+			p.mu.Unlock()
+			return m, nil
+		}
+
+		discovering := p.discovering
+
+		if discovering == nil {
+			discovering = make(chan struct{})
+			p.discovering = discovering
+			p.mu.Unlock()
+
+			m, err := p.fetchMetadata(ctx)
+
+			p.mu.Lock()
+			if err == nil {
+				p.metadata = m
+			}
+			p.discovering = nil
+			p.mu.Unlock()
+			close(discovering)
+
+			return m, err
+		}
+
+		p.mu.Unlock()
+
+		select {
+		case <-discovering:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *Provider) fetchMetadata(ctx context.Context) (*Metadata, error) {
+	issuer := strings.TrimRight(p.config.Issuer, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Metadata{}
+
+	if err := p.do(req, m); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimRight(m.Issuer, "/") != issuer {
+		return nil, errorIssuerMismatch
+	}
+
+	return m, nil
+}
+
+// key returns the public key with the given ID, refreshing the key set if it
+// is unknown, so that key rotation at the provider is picked up. Refreshes
+// are rate limited, as the ID comes from the unverified token, and callers
+// that find a refresh in flight wait for it rather than fetching again.
+func (p *Provider) key(ctx context.Context, m *Metadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+
+	if key, ok := p.keys[kid]; ok {
+		p.mu.Unlock()
+		return key, nil
+	}
+
+	refreshing := p.refreshing
+
+	if refreshing == nil {
+		if time.Since(p.refreshed) < p.config.KeyRefreshInterval {
+			p.mu.Unlock()
+			return nil, errorUnknownKey
+		}
+
+		refreshing = make(chan struct{})
+		p.refreshing = refreshing
+		p.refreshed = time.Now()
+		p.mu.Unlock()
+
+		keys, err := p.fetchKeys(ctx, m.JWKSURI)
+
+		p.mu.Lock()
+		if err == nil {
+			p.keys = keys
+		}
+		p.refreshing = nil
+		p.mu.Unlock()
+		close(refreshing)
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p.mu.Unlock()
+
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, errorUnknownKey
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	set := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+
+	if err := p.do(req, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+
+		if err != nil {
+			continue
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	res, err := p.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL, res.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// GenerateVerifier returns a random PKCE code verifier.
+func GenerateVerifier() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge returns the S256 PKCE challenge of verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,204 @@
+package oidc_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"webserver/internal/app/oidc"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_Flow(t *testing.T) {
+	tp := oidc.NewTestProvider(t)
+	p := oidc.New(tp.Config("http://localhost/callback"), nil)
+	ctx := context.Background()
+
+	verifier, err := oidc.GenerateVerifier()
+	assert.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", oidc.Challenge(verifier))
+	assert.NoError(t, err)
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(authURL)
+	assert.NoError(t, err)
+	res.Body.Close()
+
+	redirect, err := url.Parse(res.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "state", redirect.Query().Get("state"))
+
+	code := redirect.Query().Get("code")
+
+	_, err = p.Exchange(ctx, code, "wrong verifier")
+	assert.Error(t, err)
+
+	res, _ = client.Get(authURL)
+	res.Body.Close()
+	redirect, _ = url.Parse(res.Header.Get("Location"))
+	code = redirect.Query().Get("code")
+
+	raw, err := p.Exchange(ctx, code, verifier)
+	assert.NoError(t, err)
+
+	_, err = p.Exchange(ctx, code, verifier)
+	assert.Error(t, err)
+
+	claims, err := p.Verify(ctx, raw, "nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, tp.Subject, claims.Subject)
+	assert.Equal(t, tp.Email, claims.Email)
+	assert.True(t, claims.EmailVerified)
+
+	_, err = p.Verify(ctx, raw, "other nonce")
+	assert.ErrorIs(t, err, oidc.ErrorInvalidIDToken)
+}
+
+func TestProvider_Verify(t *testing.T) {
+	tp := oidc.NewTestProvider(t)
+	p := oidc.New(tp.Config("http://localhost/callback"), nil)
+	other := oidc.NewTestProvider(t)
+
+	testCases := []struct {
+		name    string
+		token   func() string
+		isValid bool
+	}{
+		{
+			name: "valid",
+			token: func() string {
+				return tp.SignIDToken(tp.Claims("nonce"))
+			},
+			isValid: true,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				c := tp.Claims("nonce")
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return tp.SignIDToken(c)
+			},
+			isValid: false,
+		},
+		{
+			name: "no expiry",
+			token: func() string {
+				c := tp.Claims("nonce")
+				c.ExpiresAt = nil
+				return tp.SignIDToken(c)
+			},
+			isValid: false,
+		},
+		{
+			name: "other audience",
+			token: func() string {
+				c := tp.Claims("nonce")
+				c.Audience = jwt.ClaimStrings{"other"}
+				return tp.SignIDToken(c)
+			},
+			isValid: false,
+		},
+		{
+			name: "other issuer",
+			token: func() string {
+				c := tp.Claims("nonce")
+				c.Issuer = other.URL
+				return tp.SignIDToken(c)
+			},
+			isValid: false,
+		},
+		{
+			name: "foreign key",
+			token: func() string {
+				c := tp.Claims("nonce")
+				return other.SignIDToken(c)
+			},
+			isValid: false,
+		},
+		{
+			name: "unsigned",
+			token: func() string {
+				s, _ := jwt.NewWithClaims(jwt.SigningMethodNone, tp.Claims("nonce")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				return s
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := p.Verify(context.Background(), tc.token(), "nonce")
+
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestProvider_KeyRefresh(t *testing.T) {
+	tp := oidc.NewTestProvider(t)
+	config := tp.Config("http://localhost/callback")
+	config.KeyRefreshInterval = 100 * time.Millisecond
+	p := oidc.New(config, nil)
+	ctx := context.Background()
+
+	_, err := p.Verify(ctx, tp.SignIDToken(tp.Claims("nonce")), "nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tp.KeyRequests())
+
+	for i := 0; i < 5; i++ {
+		tp.KeyID = fmt.Sprintf("unknown-%d", i)
+		_, err := p.Verify(ctx, tp.SignIDToken(tp.Claims("nonce")), "nonce")
+		assert.ErrorIs(t, err, oidc.ErrorInvalidIDToken)
+	}
+
+	assert.Equal(t, 1, tp.KeyRequests())
+
+	time.Sleep(config.KeyRefreshInterval)
+
+	tp.KeyID = "rotated"
+	_, err = p.Verify(ctx, tp.SignIDToken(tp.Claims("nonce")), "nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tp.KeyRequests())
+}
+
+func TestProvider_Discover(t *testing.T) {
+	tp := oidc.NewTestProvider(t)
+	p := oidc.New(tp.Config("http://localhost/callback"), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := p.AuthCodeURL(ctx, "state", "nonce", "challenge")
+	assert.Error(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 1, tp.DiscoveryRequests())
+}
@@ -0,0 +1,224 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TestProvider is an in-process OpenID provider. Its authorization endpoint
+// immediately approves the request for the identity in its Subject, Email and
+// EmailVerified fields, which may be changed between flows. Changing KeyID
+// rotates the ID of its signing key.
+type TestProvider struct {
+	*httptest.Server
+	ClientID      string
+	ClientSecret  string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	KeyID         string
+
+	key               *rsa.PrivateKey
+	mu                sync.Mutex
+	codes             map[string]*testGrant
+	keyRequests       int
+	discoveryRequests int
+}
+
+type testGrant struct {
+	redirectURI string
+	challenge   string
+	claims      *Claims
+}
+
+func NewTestProvider(t *testing.T) *TestProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &TestProvider{
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		Subject:       "subject",
+		Email:         "oidc@example.org",
+		EmailVerified: true,
+		KeyID:         "key",
+		key:           key,
+		codes:         make(map[string]*testGrant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("/jwks", p.handleJWKS)
+	mux.HandleFunc("/authorize", p.handleAuthorize)
+	mux.HandleFunc("/token", p.handleToken)
+
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Server.Close)
+
+	return p
+}
+
+// Config returns the client configuration for the provider.
+func (p *TestProvider) Config(redirectURL string) Config {
+	return Config{
+		Issuer:       p.URL,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  redirectURL,
+	}
+}
+
+// Claims returns valid ID token claims for the current identity.
+func (p *TestProvider) Claims(nonce string) *Claims {
+	now := time.Now()
+
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.URL,
+			Subject:   p.Subject,
+			Audience:  jwt.ClaimStrings{p.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+		Nonce:         nonce,
+		Email:         p.Email,
+		EmailVerified: p.EmailVerified,
+	}
+}
+
+// SignIDToken signs claims with the key of the provider.
+func (p *TestProvider) SignIDToken(claims *Claims) string {
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = p.KeyID
+
+	s, _ := t.SignedString(p.key)
+
+	return s
+}
+
+// DiscoveryRequests returns how often the metadata has been fetched.
+func (p *TestProvider) DiscoveryRequests() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.discoveryRequests
+}
+
+func (p *TestProvider) handleDiscovery(rw http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.discoveryRequests++
+	p.mu.Unlock()
+
+	json.NewEncoder(rw).Encode(&Metadata{
+		Issuer:                p.URL,
+		AuthorizationEndpoint: p.URL + "/authorize",
+		TokenEndpoint:         p.URL + "/token",
+		JWKSURI:               p.URL + "/jwks",
+	})
+}
+
+// KeyRequests returns how often the key set has been fetched.
+func (p *TestProvider) KeyRequests() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.keyRequests
+}
+
+func (p *TestProvider) handleJWKS(rw http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.keyRequests++
+	p.mu.Unlock()
+
+	json.NewEncoder(rw).Encode(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": p.KeyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func (p *TestProvider) handleAuthorize(rw http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		http.Error(rw, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code, _ := GenerateVerifier()
+
+	p.mu.Lock()
+	p.codes[code] = &testGrant{
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		claims:      p.Claims(q.Get("nonce")),
+	}
+	p.mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+
+	if err != nil {
+		http.Error(rw, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	rq := redirect.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+
+	http.Redirect(rw, r, redirect.String(), http.StatusFound)
+}
+
+func (p *TestProvider) handleToken(rw http.ResponseWriter, r *http.Request) {
+	id, secret, _ := r.BasicAuth()
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+
+	if id != p.ClientID || secret != p.ClientSecret {
+		http.Error(rw, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	p.mu.Lock()
+	g, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	if !ok ||
+		r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != g.redirectURI ||
+		Challenge(r.PostFormValue("code_verifier")) != g.challenge {
+		http.Error(rw, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(rw).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     p.SignIDToken(g.claims),
+	})
+}
@@ -0,0 +1,149 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Filter is a single attribute comparison of RFC 7644, section 3.4.2.2,
+// such as `userName eq "alice@example.org"`. Logical operators and value
+// paths are not supported.
+type Filter struct {
+	// Attribute is the attribute path, without the schema URN.
+	Attribute string
+	// Operator is one of eq, ne, co, sw, ew, gt, ge, lt, le and pr, in
+	// lower case.
+	Operator string
+	// Value is a string, a bool, a float64 or nil.
+	Value interface{}
+}
+
+var operators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true, "pr": true,
+}
+
+// ParseFilter parses the filter query parameter.
+func ParseFilter(s string) (*Filter, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), " ", 3)
+
+	if len(parts) < 2 {
+		return nil, invalidFilter(s)
+	}
+
+	f := &Filter{
+		Attribute: trimSchema(parts[0]),
+		Operator:  strings.ToLower(parts[1]),
+	}
+
+	if !operators[f.Operator] {
+		return nil, invalidFilter(s)
+	}
+
+	if f.Operator == "pr" {
+		if len(parts) != 2 {
+			return nil, invalidFilter(s)
+		}
+
+		return f, nil
+	}
+
+	if len(parts) != 3 {
+		return nil, invalidFilter(s)
+	}
+
+	if err := json.Unmarshal([]byte(strings.TrimSpace(parts[2])), &f.Value); err != nil {
+		return nil, invalidFilter(s)
+	}
+
+	switch f.Value.(type) {
+	case string, bool, float64, nil:
+	default:
+		return nil, invalidFilter(s)
+	}
+
+	return f, nil
+}
+
+// Is reports whether the filter is on attribute, ignoring case as attribute
+// names do.
+func (f *Filter) Is(attribute string) bool {
+	return strings.EqualFold(f.Attribute, attribute)
+}
+
+// MatchString applies the filter to a string attribute, ignoring case.
+func (f *Filter) MatchString(v string) bool {
+	if f.Operator == "pr" {
+		return v != ""
+	}
+
+	want, ok := f.Value.(string)
+
+	if !ok {
+		return false
+	}
+
+	v, want = strings.ToLower(v), strings.ToLower(want)
+
+	switch f.Operator {
+	case "eq":
+		return v == want
+	case "ne":
+		return v != want
+	case "co":
+		return strings.Contains(v, want)
+	case "sw":
+		return strings.HasPrefix(v, want)
+	case "ew":
+		return strings.HasSuffix(v, want)
+	case "gt":
+		return v > want
+	case "ge":
+		return v >= want
+	case "lt":
+		return v < want
+	case "le":
+		return v <= want
+	}
+
+	return false
+}
+
+// MatchBool applies the filter to a boolean attribute, which only supports
+// eq, ne and pr.
+func (f *Filter) MatchBool(v bool) bool {
+	if f.Operator == "pr" {
+		return true
+	}
+
+	want, ok := f.Value.(bool)
+
+	if !ok {
+		return false
+	}
+
+	switch f.Operator {
+	case "eq":
+		return v == want
+	case "ne":
+		return v != want
+	}
+
+	return false
+}
+
+func invalidFilter(s string) *Error {
+	return NewError(http.StatusBadRequest, ErrorTypeInvalidFilter, "unsupported filter "+strconv.Quote(s))
+}
+
+// trimSchema removes the URN of the User schema from an attribute path, as
+// in "urn:ietf:params:scim:schemas:core:2.0:User:userName".
+func trimSchema(path string) string {
+	if len(path) > len(SchemaUser) && strings.EqualFold(path[:len(SchemaUser)+1], SchemaUser+":") {
+		return path[len(SchemaUser)+1:]
+	}
+
+	return path
+}
@@ -0,0 +1,110 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PatchRequest is the body of a PATCH request, RFC 7644, section 3.5.2.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies the operations to u. The operation names are matched
+// ignoring case, since some clients capitalize them. Add and replace are the
+// same for the single-valued attributes of User. Without a path, the value is
+// an object of attributes to replace. Attributes the server does not store
+// are ignored, like they are when a resource is created or replaced.
+func (p *PatchRequest) Apply(u *User) error {
+	for _, op := range p.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			if op.Path != "" {
+				if err := setAttribute(u, op.Path, op.Value); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			values := map[string]json.RawMessage{}
+
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "value must be an object when path is omitted")
+			}
+
+			for path, v := range values {
+				if err := setAttribute(u, path, v); err != nil {
+					return err
+				}
+			}
+		case "remove":
+			if op.Path == "" {
+				return NewError(http.StatusBadRequest, ErrorTypeNoTarget, "remove requires a path")
+			}
+
+			if strings.EqualFold(trimSchema(op.Path), "userName") {
+				return NewError(http.StatusBadRequest, ErrorTypeMutability, "userName is required")
+			}
+
+			if strings.EqualFold(trimSchema(op.Path), "active") {
+				u.Active = nil
+			}
+		default:
+			return NewError(http.StatusBadRequest, ErrorTypeInvalidSyntax, "unsupported operation "+strconv.Quote(op.Op))
+		}
+	}
+
+	return nil
+}
+
+func setAttribute(u *User, path string, raw json.RawMessage) error {
+	invalid := NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "invalid value for "+path)
+
+	switch strings.ToLower(trimSchema(path)) {
+	case "username":
+		if err := json.Unmarshal(raw, &u.UserName); err != nil {
+			return invalid
+		}
+	case "password":
+		if err := json.Unmarshal(raw, &u.Password); err != nil {
+			return invalid
+		}
+	case "active":
+		var v interface{}
+
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return invalid
+		}
+
+		// Some clients send booleans as strings, such as "False".
+		if s, ok := v.(string); ok {
+			b, err := strconv.ParseBool(strings.ToLower(s))
+
+			if err != nil {
+				return invalid
+			}
+
+			v = b
+		}
+
+		b, ok := v.(bool)
+
+		if !ok {
+			return invalid
+		}
+
+		u.Active = &b
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+// Package scim contains the resources and messages of SCIM 2.0 as defined by
+// RFC 7643 and RFC 7644, limited to the User resource.
+package scim
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ContentType is the media type of SCIM requests and responses.
+const ContentType = "application/scim+json"
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Error types of RFC 7644, section 3.12.
+const (
+	ErrorTypeInvalidFilter = "invalidFilter"
+	ErrorTypeUniqueness    = "uniqueness"
+	ErrorTypeInvalidSyntax = "invalidSyntax"
+	ErrorTypeInvalidPath   = "invalidPath"
+	ErrorTypeNoTarget      = "noTarget"
+	ErrorTypeInvalidValue  = "invalidValue"
+	ErrorTypeMutability    = "mutability"
+)
+
+// User is the core User resource. Only the attributes the server stores are
+// part of it, others are ignored when decoding.
+type User struct {
+	Schemas  []string `json:"schemas"`
+	ID       string   `json:"id,omitempty"`
+	UserName string   `json:"userName"`
+	Emails   []Email  `json:"emails,omitempty"`
+	Active   *bool    `json:"active,omitempty"`
+	Password string   `json:"password,omitempty"`
+	Meta     *Meta    `json:"meta,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// IsActive reports whether u is active, which it is unless stated otherwise.
+func (u *User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+// ListResponse is the response to a query, RFC 7644, section 3.4.2.
+type ListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+func NewListResponse(resources interface{}, total, startIndex, itemsPerPage int) *ListResponse {
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	}
+}
+
+// Error is an error response. It implements error, so that functions of
+// this package can return it as is.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// StatusCode returns the HTTP status of e.
+func (e *Error) StatusCode() int {
+	code, err := strconv.Atoi(e.Status)
+
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+
+	return code
+}
+
+// ServiceProviderConfig describes the features of the server, RFC 7643,
+// section 5. Bulk operations, sorting and ETags are not supported.
+func ServiceProviderConfig(maxResults int) map[string]interface{} {
+	unsupported := map[string]bool{"supported": false}
+
+	return map[string]interface{}{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": maxResults},
+		"changePassword": map[string]bool{"supported": true},
+		"sort":           unsupported,
+		"etag":           unsupported,
+		"authenticationSchemes": []map[string]interface{}{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication with a personal access token or an OAuth access token",
+				"primary":     true,
+			},
+		},
+		"meta": map[string]string{"resourceType": "ServiceProviderConfig"},
+	}
+}
+
+// UserSchema describes the attributes of User, RFC 7643, section 7.
+var UserSchema = map[string]interface{}{
+	"schemas":     []string{SchemaSchema},
+	"id":          SchemaUser,
+	"name":        "User",
+	"description": "User Account",
+	"attributes": []map[string]interface{}{
+		schemaAttribute("userName", "string", true, "readWrite", "always", "server"),
+		{
+			"name":        "emails",
+			"type":        "complex",
+			"multiValued": true,
+			"required":    false,
+			"mutability":  "readOnly",
+			"returned":    "default",
+			"uniqueness":  "none",
+			"description": "The email address of the user, which is its userName.",
+			"subAttributes": []map[string]interface{}{
+				schemaAttribute("value", "string", false, "readOnly", "default", "none"),
+				schemaAttribute("type", "string", false, "readOnly", "default", "none"),
+				schemaAttribute("primary", "boolean", false, "readOnly", "default", "none"),
+			},
+		},
+		schemaAttribute("active", "boolean", false, "readWrite", "default", "none"),
+		schemaAttribute("password", "string", false, "writeOnly", "never", "none"),
+	},
+	"meta": map[string]string{"resourceType": "Schema"},
+}
+
+func schemaAttribute(name, typ string, required bool, mutability, returned, uniqueness string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"type":        typ,
+		"multiValued": false,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    returned,
+		"uniqueness":  uniqueness,
+	}
+}
@@ -0,0 +1,149 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"testing"
+	"webserver/internal/app/scim"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filter   string
+		expected *scim.Filter
+	}{
+		{
+			name:     "equal",
+			filter:   `userName eq "alice@example.org"`,
+			expected: &scim.Filter{Attribute: "userName", Operator: "eq", Value: "alice@example.org"},
+		},
+		{
+			name:     "schema urn and upper case operator",
+			filter:   `urn:ietf:params:scim:schemas:core:2.0:User:userName EQ "a b"`,
+			expected: &scim.Filter{Attribute: "userName", Operator: "eq", Value: "a b"},
+		},
+		{
+			name:     "boolean",
+			filter:   `active eq false`,
+			expected: &scim.Filter{Attribute: "active", Operator: "eq", Value: false},
+		},
+		{
+			name:     "present",
+			filter:   `emails pr`,
+			expected: &scim.Filter{Attribute: "emails", Operator: "pr"},
+		},
+		{
+			name:   "unknown operator",
+			filter: `userName like "a"`,
+		},
+		{
+			name:   "unquoted string",
+			filter: `userName eq alice`,
+		},
+		{
+			name:   "logical operator",
+			filter: `userName eq "a" and active eq true`,
+		},
+		{
+			name:   "empty",
+			filter: ``,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := scim.ParseFilter(tc.filter)
+
+			if tc.expected == nil {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, f)
+		})
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	f, _ := scim.ParseFilter(`userName sw "Alice"`)
+	assert.True(t, f.MatchString("alice@example.org"))
+	assert.False(t, f.MatchString("bob@example.org"))
+
+	f, _ = scim.ParseFilter(`userName eq "alice@example.org"`)
+	assert.True(t, f.MatchString("Alice@Example.org"))
+	assert.False(t, f.MatchBool(true))
+
+	f, _ = scim.ParseFilter(`active ne true`)
+	assert.True(t, f.MatchBool(false))
+	assert.False(t, f.MatchString("true"))
+}
+
+func TestPatchRequest_Apply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected scim.User
+		isValid  bool
+	}{
+		{
+			name:     "replace with path",
+			body:     `{"Operations":[{"op":"replace","path":"userName","value":"new@example.org"}]}`,
+			expected: scim.User{UserName: "new@example.org"},
+			isValid:  true,
+		},
+		{
+			name:     "replace without path",
+			body:     `{"Operations":[{"op":"Replace","value":{"active":false,"name":{"givenName":"Alice"}}}]}`,
+			expected: scim.User{UserName: "alice@example.org", Active: new(bool)},
+			isValid:  true,
+		},
+		{
+			name:     "active as string",
+			body:     `{"Operations":[{"op":"replace","path":"active","value":"False"}]}`,
+			expected: scim.User{UserName: "alice@example.org", Active: new(bool)},
+			isValid:  true,
+		},
+		{
+			name:     "password",
+			body:     `{"Operations":[{"op":"add","path":"password","value":"secret"}]}`,
+			expected: scim.User{UserName: "alice@example.org", Password: "secret"},
+			isValid:  true,
+		},
+		{
+			name:    "remove user name",
+			body:    `{"Operations":[{"op":"remove","path":"userName"}]}`,
+			isValid: false,
+		},
+		{
+			name:    "invalid value",
+			body:    `{"Operations":[{"op":"replace","path":"active","value":"maybe"}]}`,
+			isValid: false,
+		},
+		{
+			name:    "unknown operation",
+			body:    `{"Operations":[{"op":"move","path":"active","value":true}]}`,
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &scim.PatchRequest{}
+			assert.NoError(t, json.Unmarshal([]byte(tc.body), p))
+
+			u := &scim.User{UserName: "alice@example.org"}
+			err := p.Apply(u)
+
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, *u)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Package secret encrypts small values, such as TOTP secrets, before they
+// are stored.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var (
+	errorMalformed = errors.New("malformed sealed value")
+)
+
+// Box seals values with AES-256-GCM. Sealed values are base64 encoded and
+// carry their nonce.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox returns a box whose key is derived from passphrase.
+func NewBox(passphrase string) *Box {
+	key := sha256.Sum256([]byte(passphrase))
+
+	block, err := aes.NewCipher(key[:])
+
+	if err != nil {
+		panic(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return &Box{
+		aead: aead,
+	}
+}
+
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawStdEncoding.EncodeToString(b.aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func (b *Box) Open(sealed string) (string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", errorMalformed
+	}
+
+	n := b.aead.NonceSize()
+
+	plaintext, err := b.aead.Open(nil, data[:n], data[n:], nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
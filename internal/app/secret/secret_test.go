@@ -0,0 +1,34 @@
+package secret_test
+
+import (
+	"testing"
+	"webserver/internal/app/secret"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBox(t *testing.T) {
+	b := secret.NewBox("passphrase")
+
+	sealed, err := b.Seal("JBSWY3DPEHPK3PXP")
+
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	other, _ := b.Seal("JBSWY3DPEHPK3PXP")
+
+	assert.NotEqual(t, sealed, other)
+
+	plaintext, err := b.Open(sealed)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", plaintext)
+
+	_, err = secret.NewBox("other").Open(sealed)
+
+	assert.Error(t, err)
+
+	_, err = b.Open("invalid")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"fmt"
+	"webserver/internal/app/model"
+)
+
+// AuditChainError reports the first event of the audit log that does not
+// match its hash or is not chained to the event before it.
+type AuditChainError struct {
+	ID int
+}
+
+func (e *AuditChainError) Error() string {
+	return fmt.Sprintf("audit log chain is broken at event %d", e.ID)
+}
+
+// VerifyAuditChain checks the hash chain of the whole audit log and returns
+// the number of events checked. It fails with an *AuditChainError when the
+// chain is broken.
+func VerifyAuditChain(r AuditRepository) (int, error) {
+	prev, n := "", 0
+
+	err := r.Walk(func(e *model.AuditEvent) error {
+		if !e.Verify(prev) {
+			return &AuditChainError{ID: e.ID}
+		}
+
+		prev = e.Hash
+		n++
+
+		return nil
+	})
+
+	return n, err
+}
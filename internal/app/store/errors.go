@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrorRecordNotFound = errors.New("record not found")
-)
\ No newline at end of file
+	ErrorInvalidCursor  = errors.New("invalid cursor")
+	ErrorInvalidSort    = errors.New("invalid sort column")
+	ErrorEmailTaken     = errors.New("email is already taken")
+)
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+	"webserver/internal/app/model"
+)
+
+const (
+	SortByID        = "id"
+	SortByEmail     = "email"
+	SortByCreatedAt = "created_at"
+
+	DefaultListLimit = 50
+	MaxListLimit     = 100
+)
+
+// ListOptions narrows down and orders the users returned by
+// UserRepository.List. Zero values mean no filter.
+type ListOptions struct {
+	ID int
+	// Email matches users whose address contains it, ignoring case.
+	Email string
+	// CreatedAfter and CreatedBefore bound the creation time of users. The
+	// lower bound is inclusive, the upper one exclusive.
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	IncludeDeleted bool
+	// OnlyDeleted matches soft deleted users only, whether or not
+	// IncludeDeleted is set.
+	OnlyDeleted bool
+	Sort        string
+	Desc        bool
+	Limit       int
+	// Cursor continues a previous listing after the position it marks.
+	Cursor *Cursor
+}
+
+// Normalize fills in the default sort column and limit and validates the
+// options.
+func (o *ListOptions) Normalize() error {
+	if o.Sort == "" {
+		o.Sort = SortByID
+	}
+
+	switch o.Sort {
+	case SortByID, SortByEmail:
+	case SortByCreatedAt:
+		if o.Cursor != nil {
+			if _, err := time.Parse(time.RFC3339Nano, o.Cursor.Value); err != nil {
+				return ErrorInvalidCursor
+			}
+		}
+	default:
+		return ErrorInvalidSort
+	}
+
+	if o.Limit <= 0 {
+		o.Limit = DefaultListLimit
+	}
+
+	if o.Limit > MaxListLimit {
+		o.Limit = MaxListLimit
+	}
+
+	return nil
+}
+
+// AuditFilter narrows down the events returned by AuditRepository.List,
+// which lists the newest events first. Zero values mean no filter.
+type AuditFilter struct {
+	Action   string
+	ActorID  int
+	TargetID int
+	// Since and Until bound the time of events. The lower bound is
+	// inclusive, the upper one exclusive.
+	Since time.Time
+	Until time.Time
+	Limit int
+	// Cursor continues a previous listing after the position it marks.
+	Cursor *Cursor
+}
+
+// Normalize fills in the default limit.
+func (f *AuditFilter) Normalize() {
+	if f.Limit <= 0 {
+		f.Limit = DefaultListLimit
+	}
+
+	if f.Limit > MaxListLimit {
+		f.Limit = MaxListLimit
+	}
+}
+
+// Cursor is an opaque position in a sorted listing: the value of the sort
+// column and the ID of the last record of the previous page.
+type Cursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// NewUserCursor returns the cursor that continues a listing sorted by sort
+// after u.
+func NewUserCursor(u *model.User, sort string) *Cursor {
+	c := &Cursor{ID: u.ID}
+
+	switch sort {
+	case SortByEmail:
+		c.Value = u.Email
+	case SortByCreatedAt:
+		c.Value = u.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		c.Value = strconv.Itoa(u.ID)
+	}
+
+	return c
+}
+
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, ErrorInvalidCursor
+	}
+
+	c := &Cursor{}
+
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, ErrorInvalidCursor
+	}
+
+	return c, nil
+}
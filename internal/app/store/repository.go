@@ -1,10 +1,143 @@
 package store
 
-import "webserver/internal/app/model"
+import (
+	"context"
+	"webserver/internal/app/model"
+)
 
 type UserRepository interface {
 	Create(*model.User) error
 	FindByEmail(string) (*model.User, error)
 	GetAll() ([]*model.User, error)
+	List(context.Context, ListOptions) ([]*model.User, *Cursor, error)
+	Count(context.Context, ListOptions) (int, error)
 	Find(int) (*model.User, error)
+	FindIncludingDeleted(int) (*model.User, error)
+	UpdateEmail(*model.User) error
+	UpdatePassword(*model.User) error
+	Update(*model.User) error
+	Delete(int) error
+	SoftDelete(int) error
+	Restore(int) error
+	UpdateTOTP(*model.User) error
+	UseTOTPStep(id int, step int64) error
+	RevokeTokens(int) error
+}
+
+type SessionRepository interface {
+	Create(*model.Session) error
+	Find(string) (*model.Session, error)
+	FindByUser(int) ([]*model.Session, error)
+	Touch(string) error
+	Revoke(string) error
+	RevokeAllByUser(userID int, except string) error
+}
+
+type RefreshTokenRepository interface {
+	Create(*model.RefreshToken) error
+	FindByToken(string) (*model.RefreshToken, error)
+	MarkUsed(string) error
+	RevokeFamily(string) error
+	RevokeAllByUser(int) error
+}
+
+type APITokenRepository interface {
+	Create(*model.APIToken) error
+	Find(int) (*model.APIToken, error)
+	FindByToken(string) (*model.APIToken, error)
+	FindByUser(int) ([]*model.APIToken, error)
+	Touch(int) error
+	Delete(int) error
+}
+
+type PasswordResetRepository interface {
+	Create(*model.PasswordReset) error
+	FindByToken(string) (*model.PasswordReset, error)
+	MarkUsed(int) error
+	MarkUsedByUser(int) error
+}
+
+type MagicLinkRepository interface {
+	Create(*model.MagicLink) error
+	FindByToken(string) (*model.MagicLink, error)
+	MarkUsed(int) error
+}
+
+type IdentityRepository interface {
+	Create(*model.Identity) error
+	FindBySubject(provider, subject string) (*model.Identity, error)
+	FindByUser(int) ([]*model.Identity, error)
+}
+
+type OAuthClientRepository interface {
+	Create(*model.OAuthClient) error
+	Find(int) (*model.OAuthClient, error)
+	FindByClientID(string) (*model.OAuthClient, error)
+	GetAll() ([]*model.OAuthClient, error)
+	Delete(int) error
+}
+
+type OAuthCodeRepository interface {
+	Create(*model.OAuthCode) error
+	FindByCode(string) (*model.OAuthCode, error)
+	MarkUsed(int) error
+}
+
+type OAuthTokenRepository interface {
+	Create(*model.OAuthToken) error
+	FindByToken(string) (*model.OAuthToken, error)
+	Revoke(int) error
+}
+
+type OAuthConsentRepository interface {
+	Find(userID, clientID int) (*model.OAuthConsent, error)
+	Save(*model.OAuthConsent) error
+}
+
+type RoleRepository interface {
+	Create(*model.Role) error
+	Find(int) (*model.Role, error)
+	FindByName(string) (*model.Role, error)
+	GetAll() ([]*model.Role, error)
+	FindByUser(int) ([]*model.Role, error)
+	Assign(userID, roleID int) error
+	Unassign(userID, roleID int) error
+	HasPermission(userID int, permission string) (bool, error)
+}
+
+type OrganizationRepository interface {
+	Create(*model.Organization) error
+	Find(int) (*model.Organization, error)
+	FindByUser(int) ([]*model.Organization, error)
+	Delete(int) error
+}
+
+type MembershipRepository interface {
+	Create(*model.Membership) error
+	Find(organizationID, userID int) (*model.Membership, error)
+	FindByOrganization(int) ([]*model.Membership, error)
+	UpdateRole(*model.Membership) error
+	Delete(organizationID, userID int) error
+}
+
+type InvitationRepository interface {
+	Create(*model.Invitation) error
+	Find(int) (*model.Invitation, error)
+	FindByOrganization(int) ([]*model.Invitation, error)
+	FindWithoutOrganization() ([]*model.Invitation, error)
+	Renew(*model.Invitation) error
+	Revoke(int) error
+	MarkAccepted(int) error
+}
+
+type AuditRepository interface {
+	Append(*model.AuditEvent) error
+	List(context.Context, AuditFilter) ([]*model.AuditEvent, *Cursor, error)
+	Walk(func(*model.AuditEvent) error) error
+}
+
+type RecoveryCodeRepository interface {
+	Replace(userID int, codes []*model.RecoveryCode) error
+	Use(userID int, code string) error
+	DeleteByUser(int) error
 }
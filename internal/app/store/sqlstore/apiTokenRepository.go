@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+type APITokenRepository struct {
+	store *Store
+}
+
+const apiTokenColumns = "id, user_id, name, scopes, token_hash, created_at, expires_at, last_used_at"
+
+func (r *APITokenRepository) Create(t *model.APIToken) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO api_tokens (user_id, name, scopes, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		t.UserID,
+		t.Name,
+		pq.Array(t.Scopes),
+		t.TokenHash,
+		t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *APITokenRepository) Find(id int) (*model.APIToken, error) {
+	return r.scan(r.store.db.QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE id = $1", id))
+}
+
+// FindByToken looks a token up by its plaintext value.
+func (r *APITokenRepository) FindByToken(token string) (*model.APIToken, error) {
+	return r.scan(r.store.db.QueryRow(
+		"SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = $1",
+		model.HashToken(token)))
+}
+
+func (r *APITokenRepository) FindByUser(userID int) ([]*model.APIToken, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = $1 ORDER BY id ASC",
+		userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tokens := []*model.APIToken{}
+
+	for rows.Next() {
+		t, err := r.scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *APITokenRepository) Touch(id int) error {
+	res, err := r.store.db.Exec("UPDATE api_tokens SET last_used_at = now() WHERE id = $1", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *APITokenRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM api_tokens WHERE id = $1", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *APITokenRepository) scan(row scanner) (*model.APIToken, error) {
+	t := &model.APIToken{}
+
+	if err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		pq.Array(&t.Scopes),
+		&t.TokenHash,
+		&t.CreatedAt,
+		&t.ExpiresAt,
+		&t.LastUsedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,144 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokenRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("api_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	at := model.TestAPIToken(t, u.ID)
+	at.Scopes = []string{model.ScopeUserRead}
+
+	assert.NoError(t, s.APIToken().Create(at))
+
+	assert.NotEmpty(t, at.Token)
+}
+
+func TestAPITokenRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("api_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	_, err := s.APIToken().Find(1)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	at := model.TestAPIToken(t, u.ID)
+	at.Scopes = []string{model.ScopeUserRead}
+
+	s.APIToken().Create(at)
+
+	found, err := s.APIToken().Find(at.ID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, at.Scopes, found.Scopes)
+}
+
+func TestAPITokenRepository_FindByToken(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("api_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	_, err := s.APIToken().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	at := model.TestAPIToken(t, u.ID)
+
+	s.APIToken().Create(at)
+
+	found, err := s.APIToken().FindByToken(at.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, at.ID, found.ID)
+}
+
+func TestAPITokenRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("api_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	s.APIToken().Create(model.TestAPIToken(t, u.ID))
+
+	tokens, err := s.APIToken().FindByUser(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, tokens, 1)
+}
+
+func TestAPITokenRepository_Touch(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("api_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	at := model.TestAPIToken(t, u.ID)
+
+	s.APIToken().Create(at)
+
+	assert.NoError(t, s.APIToken().Touch(at.ID))
+
+	found, _ := s.APIToken().Find(at.ID)
+
+	assert.NotNil(t, found.LastUsedAt)
+}
+
+func TestAPITokenRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("api_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	at := model.TestAPIToken(t, u.ID)
+
+	s.APIToken().Create(at)
+
+	assert.NoError(t, s.APIToken().Delete(at.ID))
+
+	assert.EqualError(t, s.APIToken().Delete(at.ID), store.ErrorRecordNotFound.Error())
+}
@@ -0,0 +1,197 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type AuditRepository struct {
+	store *Store
+}
+
+const auditColumns = "id, action, actor_id, target_id, ip, request_id, data, created_at, prev_hash, hash"
+
+// Append seals an event and adds it to the end of the log. Appends are
+// serialized with a table lock, so that every event is chained to the event
+// appended right before it.
+func (r *AuditRepository) Append(e *model.AuditEvent) error {
+	tx, err := r.store.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("LOCK TABLE audit_events IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	var prev string
+
+	if err := tx.QueryRow("SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1").Scan(&prev); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	e.Seal(prev)
+
+	data, err := json.Marshal(e.Data)
+
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow(
+		`INSERT INTO audit_events (action, actor_id, target_id, ip, request_id, data, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		e.Action,
+		e.ActorID,
+		e.TargetID,
+		e.IP,
+		e.RequestID,
+		string(data),
+		e.CreatedAt,
+		e.PrevHash,
+		e.Hash).Scan(&e.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AuditRepository) List(ctx context.Context, f store.AuditFilter) ([]*model.AuditEvent, *store.Cursor, error) {
+	f.Normalize()
+
+	where := []string{}
+	args := []interface{}{}
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.Action != "" {
+		where = append(where, "action = "+arg(f.Action))
+	}
+
+	if f.ActorID != 0 {
+		where = append(where, "actor_id = "+arg(f.ActorID))
+	}
+
+	if f.TargetID != 0 {
+		where = append(where, "target_id = "+arg(f.TargetID))
+	}
+
+	if !f.Since.IsZero() {
+		where = append(where, "created_at >= "+arg(f.Since))
+	}
+
+	if !f.Until.IsZero() {
+		where = append(where, "created_at < "+arg(f.Until))
+	}
+
+	if f.Cursor != nil {
+		where = append(where, "id < "+arg(f.Cursor.ID))
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_events"
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	query += " ORDER BY id DESC LIMIT " + arg(f.Limit+1)
+
+	rows, err := r.store.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	events := []*model.AuditEvent{}
+
+	for rows.Next() {
+		e, err := r.scan(rows)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(events) <= f.Limit {
+		return events, nil, nil
+	}
+
+	events = events[:f.Limit]
+
+	return events, &store.Cursor{ID: events[len(events)-1].ID}, nil
+}
+
+// Walk calls fn with every event, oldest first, until fn fails.
+func (r *AuditRepository) Walk(fn func(*model.AuditEvent) error) error {
+	rows, err := r.store.db.Query("SELECT " + auditColumns + " FROM audit_events ORDER BY id ASC")
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := r.scan(rows)
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *AuditRepository) scan(row scanner) (*model.AuditEvent, error) {
+	e := &model.AuditEvent{}
+
+	var data []byte
+
+	if err := row.Scan(
+		&e.ID,
+		&e.Action,
+		&e.ActorID,
+		&e.TargetID,
+		&e.IP,
+		&e.RequestID,
+		&data,
+		&e.CreatedAt,
+		&e.PrevHash,
+		&e.Hash); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &e.Data); err != nil {
+		return nil, err
+	}
+
+	if len(e.Data) == 0 {
+		e.Data = nil
+	}
+
+	return e, nil
+}
@@ -0,0 +1,85 @@
+package sqlstore_test
+
+import (
+	"context"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRepository_Append(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("audit_events")
+
+	s := sqlstore.New(db)
+
+	first := model.TestAuditEvent(t, model.AuditUserCreated)
+	first.Data = map[string]string{"method": "password"}
+
+	assert.NoError(t, s.Audit().Append(first))
+
+	second := model.TestAuditEvent(t, model.AuditLoginSucceeded)
+
+	assert.NoError(t, s.Audit().Append(second))
+
+	assert.Equal(t, first.Hash, second.PrevHash)
+
+	n, err := store.VerifyAuditChain(s.Audit())
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, n)
+
+	_, err = db.Exec("UPDATE audit_events SET ip = '198.51.100.1' WHERE id = $1", first.ID)
+
+	assert.Error(t, err)
+
+	_, err = db.Exec("DELETE FROM audit_events WHERE id = $1", first.ID)
+
+	assert.Error(t, err)
+}
+
+func TestAuditRepository_List(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("audit_events")
+
+	s := sqlstore.New(db)
+
+	userID := 1
+
+	for i := 0; i < 3; i++ {
+		e := model.TestAuditEvent(t, model.AuditLoginFailed)
+		e.TargetID = &userID
+
+		s.Audit().Append(e)
+	}
+
+	s.Audit().Append(model.TestAuditEvent(t, model.AuditUserCreated))
+
+	events, cursor, err := s.Audit().List(context.Background(), store.AuditFilter{TargetID: userID, Limit: 2})
+
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 2)
+
+	assert.NotNil(t, cursor)
+
+	events, cursor, err = s.Audit().List(context.Background(), store.AuditFilter{TargetID: userID, Limit: 2, Cursor: cursor})
+
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 1)
+
+	assert.Nil(t, cursor)
+
+	events, _, err = s.Audit().List(context.Background(), store.AuditFilter{Action: model.AuditUserCreated})
+
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 1)
+}
@@ -0,0 +1,66 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"time"
+	"webserver/internal/app/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrorRecordNotFound
+	}
+
+	return nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// startCleanup runs fn every interval until quit is closed or written to.
+// Errors of fn are logged, and fn runs again at the next tick.
+func startCleanup(interval time.Duration, fn func() error, logger logrus.FieldLogger) (chan<- struct{}, <-chan struct{}) {
+	quit, done := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+
+		defer func() {
+			ticker.Stop()
+			close(done)
+		}()
+
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				if err := fn(); err != nil {
+					logger.Errorf("cleanup: %v", err)
+				}
+			}
+		}
+	}()
+
+	return quit, done
+}
+
+// stopCleanup stops the routine started by startCleanup and waits for it to
+// finish.
+func stopCleanup(quit chan<- struct{}, done <-chan struct{}) {
+	quit <- struct{}{}
+	<-done
+}
@@ -0,0 +1,33 @@
+package sqlstore
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartCleanup(t *testing.T) {
+	b := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(b)
+
+	ran := make(chan struct{}, 1)
+
+	quit, done := startCleanup(time.Millisecond, func() error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+
+		return errors.New("database is unavailable")
+	}, logger)
+
+	<-ran
+	stopCleanup(quit, done)
+
+	assert.Contains(t, b.String(), "database is unavailable")
+}
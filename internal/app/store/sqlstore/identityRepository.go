@@ -0,0 +1,75 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type IdentityRepository struct {
+	store *Store
+}
+
+const identityColumns = "id, user_id, provider, subject, email, created_at"
+
+func (r *IdentityRepository) Create(i *model.Identity) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		i.UserID,
+		i.Provider,
+		i.Subject,
+		i.Email).Scan(&i.ID, &i.CreatedAt)
+}
+
+func (r *IdentityRepository) FindBySubject(provider, subject string) (*model.Identity, error) {
+	return r.scan(r.store.db.QueryRow(
+		"SELECT "+identityColumns+" FROM identities WHERE provider = $1 AND subject = $2",
+		provider,
+		subject))
+}
+
+func (r *IdentityRepository) FindByUser(userID int) ([]*model.Identity, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+identityColumns+" FROM identities WHERE user_id = $1 ORDER BY id ASC",
+		userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	identities := []*model.Identity{}
+
+	for rows.Next() {
+		i, err := r.scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, i)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *IdentityRepository) scan(row scanner) (*model.Identity, error) {
+	i := &model.Identity{}
+
+	if err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Provider,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return i, nil
+}
@@ -0,0 +1,76 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("identities", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	i := model.TestIdentity(t, u.ID)
+
+	assert.NoError(t, s.Identity().Create(i))
+
+	assert.NotZero(t, i.ID)
+
+	assert.Error(t, s.Identity().Create(model.TestIdentity(t, u.ID)))
+}
+
+func TestIdentityRepository_FindBySubject(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("identities", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	i := model.TestIdentity(t, u.ID)
+
+	s.Identity().Create(i)
+
+	_, err := s.Identity().FindBySubject("other", i.Subject)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	found, err := s.Identity().FindBySubject(i.Provider, i.Subject)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, u.ID, found.UserID)
+}
+
+func TestIdentityRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("identities", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	s.Identity().Create(model.TestIdentity(t, u.ID))
+
+	identities, err := s.Identity().FindByUser(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, identities, 1)
+}
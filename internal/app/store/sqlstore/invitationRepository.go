@@ -0,0 +1,133 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type InvitationRepository struct {
+	store *Store
+}
+
+const invitationColumns = "id, organization_id, email, role, created_at, expires_at, accepted_at, revoked_at"
+
+func (r *InvitationRepository) Create(i *model.Invitation) error {
+	if err := i.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO invitations (organization_id, email, role, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		i.OrganizationID,
+		i.Email,
+		i.Role,
+		i.ExpiresAt).Scan(&i.ID, &i.CreatedAt)
+}
+
+func (r *InvitationRepository) Find(id int) (*model.Invitation, error) {
+	return r.scan(r.store.db.QueryRow(
+		"SELECT "+invitationColumns+" FROM invitations WHERE id = $1",
+		id))
+}
+
+func (r *InvitationRepository) FindByOrganization(organizationID int) ([]*model.Invitation, error) {
+	return r.findAll(
+		"SELECT "+invitationColumns+" FROM invitations WHERE organization_id = $1 ORDER BY id ASC",
+		organizationID)
+}
+
+// FindWithoutOrganization returns the invitations to sign up that are not
+// tied to an organization.
+func (r *InvitationRepository) FindWithoutOrganization() ([]*model.Invitation, error) {
+	return r.findAll("SELECT " + invitationColumns + " FROM invitations WHERE organization_id IS NULL ORDER BY id ASC")
+}
+
+// Renew stores the new expiry of an invitation. It fails with
+// store.ErrorRecordNotFound if the invitation has been accepted or revoked.
+func (r *InvitationRepository) Renew(i *model.Invitation) error {
+	res, err := r.store.db.Exec(
+		"UPDATE invitations SET expires_at = $1 WHERE id = $2 AND accepted_at IS NULL AND revoked_at IS NULL",
+		i.ExpiresAt,
+		i.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// Revoke withdraws an invitation. It fails with store.ErrorRecordNotFound if
+// the invitation has been accepted or revoked.
+func (r *InvitationRepository) Revoke(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE invitations SET revoked_at = now() WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// MarkAccepted consumes an invitation. It fails with
+// store.ErrorRecordNotFound if the invitation has been accepted or revoked.
+func (r *InvitationRepository) MarkAccepted(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE invitations SET accepted_at = now() WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *InvitationRepository) findAll(query string, args ...interface{}) ([]*model.Invitation, error) {
+	rows, err := r.store.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	invitations := []*model.Invitation{}
+
+	for rows.Next() {
+		i, err := r.scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		invitations = append(invitations, i)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (r *InvitationRepository) scan(row scanner) (*model.Invitation, error) {
+	i := &model.Invitation{}
+
+	if err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.RevokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return i, nil
+}
@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type MagicLinkRepository struct {
+	store *Store
+}
+
+func (r *MagicLinkRepository) Create(l *model.MagicLink) error {
+	if err := l.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO magic_links (user_id, token_hash, nonce_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		l.UserID,
+		l.TokenHash,
+		l.NonceHash,
+		l.ExpiresAt).Scan(&l.ID, &l.CreatedAt)
+}
+
+// FindByToken looks a link up by its plaintext token.
+func (r *MagicLinkRepository) FindByToken(token string) (*model.MagicLink, error) {
+	l := &model.MagicLink{}
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, token_hash, nonce_hash, created_at, expires_at, used_at FROM magic_links WHERE token_hash = $1",
+		model.HashToken(token)).Scan(
+		&l.ID,
+		&l.UserID,
+		&l.TokenHash,
+		&l.NonceHash,
+		&l.CreatedAt,
+		&l.ExpiresAt,
+		&l.UsedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// MarkUsed consumes a link. It fails with store.ErrorRecordNotFound if the
+// link has already been used.
+func (r *MagicLinkRepository) MarkUsed(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE magic_links SET used_at = now() WHERE id = $1 AND used_at IS NULL",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
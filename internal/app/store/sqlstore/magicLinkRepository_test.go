@@ -0,0 +1,74 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLinkRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("magic_links", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	l := model.TestMagicLink(t, u.ID, "nonce")
+
+	assert.NoError(t, s.MagicLink().Create(l))
+
+	assert.NotEmpty(t, l.Token)
+}
+
+func TestMagicLinkRepository_FindByToken(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("magic_links", "users")
+
+	s := sqlstore.New(db)
+
+	_, err := s.MagicLink().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	l := model.TestMagicLink(t, u.ID, "nonce")
+
+	s.MagicLink().Create(l)
+
+	found, err := s.MagicLink().FindByToken(l.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, l.ID, found.ID)
+}
+
+func TestMagicLinkRepository_MarkUsed(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("magic_links", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	l := model.TestMagicLink(t, u.ID, "nonce")
+
+	s.MagicLink().Create(l)
+
+	assert.NoError(t, s.MagicLink().MarkUsed(l.ID))
+
+	assert.EqualError(t, s.MagicLink().MarkUsed(l.ID), store.ErrorRecordNotFound.Error())
+}
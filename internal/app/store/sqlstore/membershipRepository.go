@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type MembershipRepository struct {
+	store *Store
+}
+
+// membershipQuery selects memberships together with the email address of
+// the member. It is completed with a WHERE clause on memberships m.
+const membershipQuery = "SELECT m.organization_id, m.user_id, u.email, m.role, m.created_at FROM memberships m JOIN users u ON u.id = m.user_id"
+
+func (r *MembershipRepository) Create(m *model.Membership) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		`WITH m AS (
+			INSERT INTO memberships (organization_id, user_id, role) VALUES ($1, $2, $3) RETURNING user_id, created_at
+		) SELECT u.email, m.created_at FROM m JOIN users u ON u.id = m.user_id`,
+		m.OrganizationID,
+		m.UserID,
+		m.Role).Scan(&m.Email, &m.CreatedAt)
+}
+
+func (r *MembershipRepository) Find(organizationID, userID int) (*model.Membership, error) {
+	return r.scan(r.store.db.QueryRow(
+		membershipQuery+" WHERE m.organization_id = $1 AND m.user_id = $2",
+		organizationID,
+		userID))
+}
+
+func (r *MembershipRepository) FindByOrganization(organizationID int) ([]*model.Membership, error) {
+	rows, err := r.store.db.Query(
+		membershipQuery+" WHERE m.organization_id = $1 ORDER BY m.user_id ASC",
+		organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	memberships := []*model.Membership{}
+
+	for rows.Next() {
+		m, err := r.scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		memberships = append(memberships, m)
+	}
+
+	return memberships, rows.Err()
+}
+
+func (r *MembershipRepository) UpdateRole(m *model.Membership) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE memberships SET role = $1 WHERE organization_id = $2 AND user_id = $3",
+		m.Role,
+		m.OrganizationID,
+		m.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *MembershipRepository) Delete(organizationID, userID int) error {
+	res, err := r.store.db.Exec(
+		"DELETE FROM memberships WHERE organization_id = $1 AND user_id = $2",
+		organizationID,
+		userID)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *MembershipRepository) scan(row scanner) (*model.Membership, error) {
+	m := &model.Membership{}
+
+	if err := row.Scan(
+		&m.OrganizationID,
+		&m.UserID,
+		&m.Email,
+		&m.Role,
+		&m.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,101 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+type OAuthClientRepository struct {
+	store *Store
+}
+
+const oauthClientColumns = "id, client_id, secret_hash, name, redirect_uris, scopes, confidential, user_id, created_at"
+
+func (r *OAuthClientRepository) Create(c *model.OAuthClient) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, confidential, user_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at",
+		c.ClientID,
+		c.SecretHash,
+		c.Name,
+		pq.Array(c.RedirectURIs),
+		pq.Array(c.Scopes),
+		c.Confidential,
+		c.UserID).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *OAuthClientRepository) Find(id int) (*model.OAuthClient, error) {
+	return r.scan(r.store.db.QueryRow("SELECT "+oauthClientColumns+" FROM oauth_clients WHERE id = $1", id))
+}
+
+func (r *OAuthClientRepository) FindByClientID(clientID string) (*model.OAuthClient, error) {
+	return r.scan(r.store.db.QueryRow("SELECT "+oauthClientColumns+" FROM oauth_clients WHERE client_id = $1", clientID))
+}
+
+func (r *OAuthClientRepository) GetAll() ([]*model.OAuthClient, error) {
+	rows, err := r.store.db.Query("SELECT " + oauthClientColumns + " FROM oauth_clients ORDER BY id ASC")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	clients := []*model.OAuthClient{}
+
+	for rows.Next() {
+		c, err := r.scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, c)
+	}
+
+	return clients, rows.Err()
+}
+
+// Delete removes a client together with its codes, tokens and consents.
+func (r *OAuthClientRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM oauth_clients WHERE id = $1", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *OAuthClientRepository) scan(row scanner) (*model.OAuthClient, error) {
+	c := &model.OAuthClient{}
+
+	if err := row.Scan(
+		&c.ID,
+		&c.ClientID,
+		&c.SecretHash,
+		&c.Name,
+		pq.Array(&c.RedirectURIs),
+		pq.Array(&c.Scopes),
+		&c.Confidential,
+		&c.UserID,
+		&c.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
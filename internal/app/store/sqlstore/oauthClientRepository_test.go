@@ -0,0 +1,85 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClientRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	c := model.TestOAuthClient(t, u.ID)
+
+	assert.NoError(t, s.OAuthClient().Create(c))
+
+	assert.NotEmpty(t, c.ClientID)
+
+	c = model.TestOAuthClient(t, u.ID)
+	c.Name = ""
+
+	assert.Error(t, s.OAuthClient().Create(c))
+}
+
+func TestOAuthClientRepository_FindByClientID(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	_, err := s.OAuthClient().FindByClientID("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	c := model.TestOAuthClient(t, u.ID)
+
+	s.OAuthClient().Create(c)
+
+	found, err := s.OAuthClient().FindByClientID(c.ClientID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, c.ID, found.ID)
+}
+
+func TestOAuthClientRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	c := model.TestOAuthClient(t, u.ID)
+
+	s.OAuthClient().Create(c)
+
+	assert.NoError(t, s.OAuthClient().Delete(c.ID))
+
+	assert.EqualError(t, s.OAuthClient().Delete(c.ID), store.ErrorRecordNotFound.Error())
+
+	clients, err := s.OAuthClient().GetAll()
+
+	assert.NoError(t, err)
+
+	assert.Empty(t, clients)
+}
@@ -0,0 +1,70 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+type OAuthCodeRepository struct {
+	store *Store
+}
+
+func (r *OAuthCodeRepository) Create(c *model.OAuthCode) error {
+	if err := c.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO oauth_codes (client_id, user_id, code_hash, redirect_uri, scopes, challenge, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at",
+		c.ClientID,
+		c.UserID,
+		c.CodeHash,
+		c.RedirectURI,
+		pq.Array(c.Scopes),
+		c.Challenge,
+		c.ExpiresAt).Scan(&c.ID, &c.CreatedAt)
+}
+
+// FindByCode looks a code up by its plaintext value.
+func (r *OAuthCodeRepository) FindByCode(code string) (*model.OAuthCode, error) {
+	c := &model.OAuthCode{}
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, client_id, user_id, code_hash, redirect_uri, scopes, challenge, created_at, expires_at, used_at FROM oauth_codes WHERE code_hash = $1",
+		model.HashToken(code)).Scan(
+		&c.ID,
+		&c.ClientID,
+		&c.UserID,
+		&c.CodeHash,
+		&c.RedirectURI,
+		pq.Array(&c.Scopes),
+		&c.Challenge,
+		&c.CreatedAt,
+		&c.ExpiresAt,
+		&c.UsedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// MarkUsed consumes a code. It fails with store.ErrorRecordNotFound if the
+// code has already been used.
+func (r *OAuthCodeRepository) MarkUsed(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE oauth_codes SET used_at = now() WHERE id = $1 AND used_at IS NULL",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,64 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthCodeRepository_FindByCode(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_codes", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	client := model.TestOAuthClient(t, u.ID)
+
+	s.OAuthClient().Create(client)
+
+	_, err := s.OAuthCode().FindByCode("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	c := model.TestOAuthCode(t, client.ID, u.ID)
+
+	assert.NoError(t, s.OAuthCode().Create(c))
+
+	found, err := s.OAuthCode().FindByCode(c.Code)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, c.ID, found.ID)
+}
+
+func TestOAuthCodeRepository_MarkUsed(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_codes", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	client := model.TestOAuthClient(t, u.ID)
+
+	s.OAuthClient().Create(client)
+
+	c := model.TestOAuthCode(t, client.ID, u.ID)
+
+	s.OAuthCode().Create(c)
+
+	assert.NoError(t, s.OAuthCode().MarkUsed(c.ID))
+
+	assert.EqualError(t, s.OAuthCode().MarkUsed(c.ID), store.ErrorRecordNotFound.Error())
+}
@@ -0,0 +1,44 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+type OAuthConsentRepository struct {
+	store *Store
+}
+
+func (r *OAuthConsentRepository) Find(userID, clientID int) (*model.OAuthConsent, error) {
+	c := &model.OAuthConsent{
+		UserID:   userID,
+		ClientID: clientID,
+	}
+
+	if err := r.store.db.QueryRow(
+		"SELECT scopes FROM oauth_consents WHERE user_id = $1 AND client_id = $2",
+		userID,
+		clientID).Scan(pq.Array(&c.Scopes)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Save creates or replaces the consent of a user to a client.
+func (r *OAuthConsentRepository) Save(c *model.OAuthConsent) error {
+	_, err := r.store.db.Exec(
+		"INSERT INTO oauth_consents (user_id, client_id, scopes) VALUES ($1, $2, $3) ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = excluded.scopes",
+		c.UserID,
+		c.ClientID,
+		pq.Array(c.Scopes))
+
+	return err
+}
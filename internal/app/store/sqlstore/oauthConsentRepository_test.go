@@ -0,0 +1,39 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthConsentRepository_Save(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_consents", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	client := model.TestOAuthClient(t, u.ID)
+
+	s.OAuthClient().Create(client)
+
+	_, err := s.OAuthConsent().Find(u.ID, client.ID)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	assert.NoError(t, s.OAuthConsent().Save(&model.OAuthConsent{UserID: u.ID, ClientID: client.ID, Scopes: []string{model.ScopeUserRead}}))
+	assert.NoError(t, s.OAuthConsent().Save(&model.OAuthConsent{UserID: u.ID, ClientID: client.ID, Scopes: []string{model.ScopeUserRead, model.ScopeUserWrite}}))
+
+	c, err := s.OAuthConsent().Find(u.ID, client.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, c.Scopes, 2)
+}
@@ -0,0 +1,65 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+type OAuthTokenRepository struct {
+	store *Store
+}
+
+func (r *OAuthTokenRepository) Create(t *model.OAuthToken) error {
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO oauth_tokens (client_id, user_id, scopes, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		t.ClientID,
+		t.UserID,
+		pq.Array(t.Scopes),
+		t.TokenHash,
+		t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+// FindByToken looks a token up by its plaintext value.
+func (r *OAuthTokenRepository) FindByToken(token string) (*model.OAuthToken, error) {
+	t := &model.OAuthToken{}
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, client_id, user_id, scopes, token_hash, created_at, expires_at, revoked_at FROM oauth_tokens WHERE token_hash = $1",
+		model.HashToken(token)).Scan(
+		&t.ID,
+		&t.ClientID,
+		&t.UserID,
+		pq.Array(&t.Scopes),
+		&t.TokenHash,
+		&t.CreatedAt,
+		&t.ExpiresAt,
+		&t.RevokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Revoke ends a token. Revoking a token twice is not an error.
+func (r *OAuthTokenRepository) Revoke(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE oauth_tokens SET revoked_at = coalesce(revoked_at, now()) WHERE id = $1",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
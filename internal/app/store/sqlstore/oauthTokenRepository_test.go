@@ -0,0 +1,69 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthTokenRepository_FindByToken(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_tokens", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	client := model.TestOAuthClient(t, u.ID)
+
+	s.OAuthClient().Create(client)
+
+	_, err := s.OAuthToken().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	tok := model.TestOAuthToken(t, client.ID, u.ID)
+
+	assert.NoError(t, s.OAuthToken().Create(tok))
+
+	found, err := s.OAuthToken().FindByToken(tok.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, tok.ID, found.ID)
+}
+
+func TestOAuthTokenRepository_Revoke(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("oauth_tokens", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	client := model.TestOAuthClient(t, u.ID)
+
+	s.OAuthClient().Create(client)
+
+	tok := model.TestOAuthToken(t, client.ID, u.ID)
+
+	s.OAuthToken().Create(tok)
+
+	assert.NoError(t, s.OAuthToken().Revoke(tok.ID))
+	assert.NoError(t, s.OAuthToken().Revoke(tok.ID))
+
+	found, _ := s.OAuthToken().FindByToken(tok.Token)
+
+	assert.False(t, found.IsActive())
+
+	assert.EqualError(t, s.OAuthToken().Revoke(100), store.ErrorRecordNotFound.Error())
+}
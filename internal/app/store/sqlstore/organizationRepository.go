@@ -0,0 +1,78 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type OrganizationRepository struct {
+	store *Store
+}
+
+const organizationColumns = "o.id, o.name, o.created_at"
+
+func (r *OrganizationRepository) Create(o *model.Organization) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO organizations (name) VALUES ($1) RETURNING id, created_at",
+		o.Name).Scan(&o.ID, &o.CreatedAt)
+}
+
+func (r *OrganizationRepository) Find(id int) (*model.Organization, error) {
+	o := &model.Organization{}
+
+	if err := r.store.db.QueryRow(
+		"SELECT "+organizationColumns+" FROM organizations o WHERE o.id = $1",
+		id).Scan(&o.ID, &o.Name, &o.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// FindByUser returns the organizations the user is a member of.
+func (r *OrganizationRepository) FindByUser(userID int) ([]*model.Organization, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+organizationColumns+" FROM organizations o JOIN memberships m ON m.organization_id = o.id WHERE m.user_id = $1 ORDER BY o.id ASC",
+		userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	organizations := []*model.Organization{}
+
+	for rows.Next() {
+		o := &model.Organization{}
+
+		if err := rows.Scan(&o.ID, &o.Name, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		organizations = append(organizations, o)
+	}
+
+	return organizations, rows.Err()
+}
+
+// Delete removes an organization together with its memberships and
+// invitations.
+func (r *OrganizationRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM organizations WHERE id = $1", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,87 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("organizations")
+
+	s := sqlstore.New(db)
+
+	o := model.TestOrganization(t)
+
+	assert.NoError(t, s.Organization().Create(o))
+
+	assert.NotZero(t, o.ID)
+
+	o = model.TestOrganization(t)
+	o.Name = ""
+
+	assert.Error(t, s.Organization().Create(o))
+}
+
+func TestOrganizationRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("memberships", "organizations", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	s.Organization().Create(model.TestOrganization(t))
+
+	s.Membership().Create(model.TestMembership(t, o.ID, u.ID))
+
+	organizations, err := s.Organization().FindByUser(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, organizations, 1)
+
+	assert.Equal(t, o.Name, organizations[0].Name)
+}
+
+func TestOrganizationRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("memberships", "organizations", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	s.Membership().Create(model.TestMembership(t, o.ID, u.ID))
+
+	assert.NoError(t, s.Organization().Delete(o.ID))
+
+	_, err := s.Organization().Find(o.ID)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	_, err = s.Membership().Find(o.ID, u.ID)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	assert.EqualError(t, s.Organization().Delete(o.ID), store.ErrorRecordNotFound.Error())
+}
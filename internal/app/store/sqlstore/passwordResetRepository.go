@@ -0,0 +1,69 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type PasswordResetRepository struct {
+	store *Store
+}
+
+func (r *PasswordResetRepository) Create(p *model.PasswordReset) error {
+	if err := p.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id, created_at",
+		p.UserID,
+		p.TokenHash,
+		p.ExpiresAt).Scan(&p.ID, &p.CreatedAt)
+}
+
+// FindByToken looks a reset up by its plaintext token.
+func (r *PasswordResetRepository) FindByToken(token string) (*model.PasswordReset, error) {
+	p := &model.PasswordReset{}
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, token_hash, created_at, expires_at, used_at FROM password_resets WHERE token_hash = $1",
+		model.HashToken(token)).Scan(
+		&p.ID,
+		&p.UserID,
+		&p.TokenHash,
+		&p.CreatedAt,
+		&p.ExpiresAt,
+		&p.UsedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// MarkUsed consumes a reset. It fails with store.ErrorRecordNotFound if the
+// reset has already been used.
+func (r *PasswordResetRepository) MarkUsed(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE password_resets SET used_at = now() WHERE id = $1 AND used_at IS NULL",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// MarkUsedByUser consumes every reset of the user that has not been used yet.
+func (r *PasswordResetRepository) MarkUsedByUser(userID int) error {
+	_, err := r.store.db.Exec(
+		"UPDATE password_resets SET used_at = now() WHERE user_id = $1 AND used_at IS NULL",
+		userID)
+
+	return err
+}
@@ -0,0 +1,107 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("password_resets", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	p := model.TestPasswordReset(t, u.ID)
+
+	assert.NoError(t, s.PasswordReset().Create(p))
+
+	assert.NotEmpty(t, p.Token)
+}
+
+func TestPasswordResetRepository_FindByToken(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("password_resets", "users")
+
+	s := sqlstore.New(db)
+
+	_, err := s.PasswordReset().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	p := model.TestPasswordReset(t, u.ID)
+
+	s.PasswordReset().Create(p)
+
+	found, err := s.PasswordReset().FindByToken(p.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, p.ID, found.ID)
+}
+
+func TestPasswordResetRepository_MarkUsed(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("password_resets", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	p := model.TestPasswordReset(t, u.ID)
+
+	s.PasswordReset().Create(p)
+
+	assert.NoError(t, s.PasswordReset().MarkUsed(p.ID))
+
+	assert.EqualError(t, s.PasswordReset().MarkUsed(p.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestPasswordResetRepository_MarkUsedByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("password_resets", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+	other := model.TestUser(t)
+	other.Email = "other@example.org"
+
+	s.User().Create(u)
+	s.User().Create(other)
+
+	p1 := model.TestPasswordReset(t, u.ID)
+	p2 := model.TestPasswordReset(t, u.ID)
+	p3 := model.TestPasswordReset(t, other.ID)
+
+	s.PasswordReset().Create(p1)
+	s.PasswordReset().Create(p2)
+	s.PasswordReset().Create(p3)
+
+	assert.NoError(t, s.PasswordReset().MarkUsedByUser(u.ID))
+
+	for _, token := range []string{p1.Token, p2.Token} {
+		found, _ := s.PasswordReset().FindByToken(token)
+		assert.True(t, found.IsUsed())
+	}
+
+	found, _ := s.PasswordReset().FindByToken(p3.Token)
+	assert.False(t, found.IsUsed())
+}
@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"webserver/internal/app/model"
+)
+
+type RecoveryCodeRepository struct {
+	store *Store
+}
+
+// Replace discards the recovery codes of a user in favour of codes.
+func (r *RecoveryCodeRepository) Replace(userID int, codes []*model.RecoveryCode) error {
+	tx, err := r.store.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	for _, c := range codes {
+		if err := c.BeforeCreate(); err != nil {
+			return err
+		}
+
+		c.UserID = userID
+
+		if err := tx.QueryRow(
+			"INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2) RETURNING id",
+			c.UserID,
+			c.CodeHash).Scan(&c.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Use marks an unused recovery code of a user as used. It returns
+// store.ErrorRecordNotFound when there is no such code.
+func (r *RecoveryCodeRepository) Use(userID int, code string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+		userID,
+		model.HashRecoveryCode(code))
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *RecoveryCodeRepository) DeleteByUser(userID int) error {
+	_, err := r.store.db.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID)
+
+	return err
+}
@@ -0,0 +1,42 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryCodeRepository_Use(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("recovery_codes", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	codes, _ := model.GenerateRecoveryCodes(u.ID)
+
+	assert.NoError(t, s.RecoveryCode().Replace(u.ID, codes))
+
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID, "invalid"), store.ErrorRecordNotFound.Error())
+
+	assert.NoError(t, s.RecoveryCode().Use(u.ID, codes[0].Code))
+
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID, codes[0].Code), store.ErrorRecordNotFound.Error())
+
+	fresh, _ := model.GenerateRecoveryCodes(u.ID)
+
+	assert.NoError(t, s.RecoveryCode().Replace(u.ID, fresh))
+
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID, codes[1].Code), store.ErrorRecordNotFound.Error())
+
+	assert.NoError(t, s.RecoveryCode().DeleteByUser(u.ID))
+
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID, fresh[0].Code), store.ErrorRecordNotFound.Error())
+}
@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+func (r *RefreshTokenRepository) Create(t *model.RefreshToken) error {
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING created_at",
+		t.ID,
+		t.UserID,
+		t.FamilyID,
+		t.TokenHash,
+		t.ExpiresAt).Scan(&t.CreatedAt)
+}
+
+// FindByToken looks a refresh token up by its plaintext value.
+func (r *RefreshTokenRepository) FindByToken(token string) (*model.RefreshToken, error) {
+	t := &model.RefreshToken{}
+
+	if err := r.store.db.QueryRow(
+		`SELECT id, user_id, family_id, token_hash, created_at, expires_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1`,
+		model.HashToken(token)).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.FamilyID,
+		&t.TokenHash,
+		&t.CreatedAt,
+		&t.ExpiresAt,
+		&t.UsedAt,
+		&t.RevokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// MarkUsed flags a token as rotated. It fails with store.ErrorRecordNotFound
+// if the token has already been used, so concurrent rotations can be told
+// apart from the first one.
+func (r *RefreshTokenRepository) MarkUsed(id string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE refresh_tokens SET used_at = now() WHERE id = $1 AND used_at IS NULL",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	_, err := r.store.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL",
+		familyID)
+
+	return err
+}
+
+func (r *RefreshTokenRepository) RevokeAllByUser(userID int) error {
+	_, err := r.store.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL",
+		userID)
+
+	return err
+}
@@ -0,0 +1,123 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	rt := model.TestRefreshToken(t, u.ID)
+
+	assert.NoError(t, s.RefreshToken().Create(rt))
+
+	assert.NotEmpty(t, rt.Token)
+}
+
+func TestRefreshTokenRepository_FindByToken(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	_, err := s.RefreshToken().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	rt := model.TestRefreshToken(t, u.ID)
+
+	s.RefreshToken().Create(rt)
+
+	found, err := s.RefreshToken().FindByToken(rt.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, rt.ID, found.ID)
+}
+
+func TestRefreshTokenRepository_MarkUsed(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	rt := model.TestRefreshToken(t, u.ID)
+
+	s.RefreshToken().Create(rt)
+
+	assert.NoError(t, s.RefreshToken().MarkUsed(rt.ID))
+
+	assert.EqualError(t, s.RefreshToken().MarkUsed(rt.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	first := model.TestRefreshToken(t, u.ID)
+
+	s.RefreshToken().Create(first)
+
+	second := model.TestRefreshToken(t, u.ID)
+	second.FamilyID = first.FamilyID
+
+	s.RefreshToken().Create(second)
+
+	assert.NoError(t, s.RefreshToken().RevokeFamily(first.FamilyID))
+
+	found, _ := s.RefreshToken().FindByToken(second.Token)
+
+	assert.True(t, found.IsRevoked())
+}
+
+func TestRefreshTokenRepository_RevokeAllByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	rt := model.TestRefreshToken(t, u.ID)
+
+	s.RefreshToken().Create(rt)
+
+	assert.NoError(t, s.RefreshToken().RevokeAllByUser(u.ID))
+
+	found, _ := s.RefreshToken().FindByToken(rt.Token)
+
+	assert.True(t, found.IsRevoked())
+}
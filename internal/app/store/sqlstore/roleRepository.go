@@ -0,0 +1,133 @@
+package sqlstore
+
+import (
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+
+	"github.com/lib/pq"
+)
+
+type RoleRepository struct {
+	store *Store
+}
+
+// roleQuery selects roles together with their permissions. It is completed
+// with a WHERE clause on roles r.
+const roleQuery = `SELECT r.id, r.name, coalesce(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}')
+	FROM roles r LEFT JOIN role_permissions p ON p.role_id = r.id`
+
+func (r *RoleRepository) Create(role *model.Role) error {
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := r.store.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("INSERT INTO roles (name) VALUES ($1) RETURNING id", role.Name).Scan(&role.ID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO role_permissions (role_id, permission) SELECT $1, unnest($2::varchar[])",
+		role.ID,
+		pq.Array(role.Permissions)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *RoleRepository) Find(id int) (*model.Role, error) {
+	return r.findOne(roleQuery+" WHERE r.id = $1 GROUP BY r.id", id)
+}
+
+func (r *RoleRepository) FindByName(name string) (*model.Role, error) {
+	return r.findOne(roleQuery+" WHERE r.name = $1 GROUP BY r.id", name)
+}
+
+func (r *RoleRepository) GetAll() ([]*model.Role, error) {
+	return r.findAll(roleQuery + " GROUP BY r.id ORDER BY r.name ASC")
+}
+
+func (r *RoleRepository) FindByUser(userID int) ([]*model.Role, error) {
+	return r.findAll(
+		roleQuery+" WHERE r.id IN (SELECT role_id FROM user_roles WHERE user_id = $1) GROUP BY r.id ORDER BY r.name ASC",
+		userID)
+}
+
+// Assign grants a role to a user. Assigning a role twice is not an error.
+func (r *RoleRepository) Assign(userID, roleID int) error {
+	_, err := r.store.db.Exec(
+		"INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		userID,
+		roleID)
+
+	return err
+}
+
+func (r *RoleRepository) Unassign(userID, roleID int) error {
+	res, err := r.store.db.Exec("DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2", userID, roleID)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// HasPermission reports whether any role of the user grants permission.
+func (r *RoleRepository) HasPermission(userID int, permission string) (bool, error) {
+	var ok bool
+
+	err := r.store.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM user_roles u JOIN role_permissions p ON p.role_id = u.role_id
+		WHERE u.user_id = $1 AND p.permission = $2)`,
+		userID,
+		permission).Scan(&ok)
+
+	return ok, err
+}
+
+func (r *RoleRepository) findOne(query string, args ...interface{}) (*model.Role, error) {
+	roles, err := r.findAll(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return roles[0], nil
+}
+
+func (r *RoleRepository) findAll(query string, args ...interface{}) ([]*model.Role, error) {
+	rows, err := r.store.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	roles := []*model.Role{}
+
+	for rows.Next() {
+		role := &model.Role{}
+
+		if err := rows.Scan(&role.ID, &role.Name, pq.Array(&role.Permissions)); err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
@@ -0,0 +1,91 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_roles")
+
+	s := sqlstore.New(db)
+
+	role := model.TestRole(t)
+
+	assert.NoError(t, s.Role().Create(role))
+
+	defer db.Exec("DELETE FROM roles WHERE id = $1", role.ID)
+
+	assert.NotZero(t, role.ID)
+
+	role = model.TestRole(t)
+	role.Permissions = []string{"everything"}
+
+	assert.Error(t, s.Role().Create(role))
+}
+
+func TestRoleRepository_FindByName(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown()
+
+	s := sqlstore.New(db)
+
+	_, err := s.Role().FindByName("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	role, err := s.Role().FindByName("admin")
+
+	assert.NoError(t, err)
+
+	assert.True(t, role.HasPermission(model.PermissionRolesWrite))
+}
+
+func TestRoleRepository_Assign(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_roles", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	role, _ := s.Role().FindByName("support")
+
+	ok, err := s.Role().HasPermission(u.ID, model.PermissionUsersRead)
+
+	assert.NoError(t, err)
+
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Role().Assign(u.ID, role.ID))
+
+	assert.NoError(t, s.Role().Assign(u.ID, role.ID))
+
+	roles, err := s.Role().FindByUser(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, roles, 1)
+
+	ok, _ = s.Role().HasPermission(u.ID, model.PermissionUsersRead)
+
+	assert.True(t, ok)
+
+	ok, _ = s.Role().HasPermission(u.ID, model.PermissionRolesWrite)
+
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Role().Unassign(u.ID, role.ID))
+
+	assert.EqualError(t, s.Role().Unassign(u.ID, role.ID), store.ErrorRecordNotFound.Error())
+}
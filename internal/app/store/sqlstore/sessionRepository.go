@@ -0,0 +1,119 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type SessionRepository struct {
+	store *Store
+}
+
+func (r *SessionRepository) Create(s *model.Session) error {
+	s.BeforeCreate()
+
+	return r.store.db.QueryRow(
+		"INSERT INTO user_sessions (id, user_id, impersonator_id, ip, user_agent, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at, last_seen_at",
+		s.ID,
+		s.UserID,
+		s.ImpersonatorID,
+		s.IP,
+		s.UserAgent,
+		s.ExpiresAt).Scan(&s.CreatedAt, &s.LastSeenAt)
+}
+
+func (r *SessionRepository) Find(id string) (*model.Session, error) {
+	s := &model.Session{}
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, impersonator_id, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at FROM user_sessions WHERE id = $1",
+		id).Scan(
+		&s.ID,
+		&s.UserID,
+		&s.ImpersonatorID,
+		&s.IP,
+		&s.UserAgent,
+		&s.CreatedAt,
+		&s.LastSeenAt,
+		&s.ExpiresAt,
+		&s.RevokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// FindByUser returns the active sessions of a user, most recently used first.
+func (r *SessionRepository) FindByUser(userID int) ([]*model.Session, error) {
+	rows, err := r.store.db.Query(
+		`SELECT id, user_id, impersonator_id, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL ORDER BY last_seen_at DESC`,
+		userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := []*model.Session{}
+
+	for rows.Next() {
+		s := &model.Session{}
+
+		if err := rows.Scan(
+			&s.ID,
+			&s.UserID,
+			&s.ImpersonatorID,
+			&s.IP,
+			&s.UserAgent,
+			&s.CreatedAt,
+			&s.LastSeenAt,
+			&s.ExpiresAt,
+			&s.RevokedAt); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *SessionRepository) Touch(id string) error {
+	res, err := r.store.db.Exec("UPDATE user_sessions SET last_seen_at = now() WHERE id = $1", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *SessionRepository) Revoke(id string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE user_sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL",
+		id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// RevokeAllByUser revokes every active session of a user except the one with
+// the given ID, which may be empty.
+func (r *SessionRepository) RevokeAllByUser(userID int, except string) error {
+	_, err := r.store.db.Exec(
+		"UPDATE user_sessions SET revoked_at = now() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL",
+		userID,
+		except)
+
+	return err
+}
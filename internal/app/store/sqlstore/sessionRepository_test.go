@@ -0,0 +1,186 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_sessions", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	sess := model.TestSession(t, u.ID)
+
+	assert.NoError(t, s.Session().Create(sess))
+
+	assert.NotEmpty(t, sess.ID)
+}
+
+func TestSessionRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_sessions", "users")
+
+	s := sqlstore.New(db)
+
+	_, err := s.Session().Find("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	sess := model.TestSession(t, u.ID)
+
+	s.Session().Create(sess)
+
+	found, err := s.Session().Find(sess.ID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, u.ID, found.UserID)
+}
+
+func TestSessionRepository_Revoke(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_sessions", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	sess := model.TestSession(t, u.ID)
+
+	s.Session().Create(sess)
+
+	assert.NoError(t, s.Session().Revoke(sess.ID))
+
+	found, _ := s.Session().Find(sess.ID)
+
+	assert.True(t, found.IsRevoked())
+
+	assert.EqualError(t, s.Session().Revoke(sess.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestSessionRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_sessions", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	active := model.TestSession(t, u.ID)
+	revoked := model.TestSession(t, u.ID)
+
+	s.Session().Create(active)
+	s.Session().Create(revoked)
+	s.Session().Revoke(revoked.ID)
+
+	sessions, err := s.Session().FindByUser(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, sessions, 1)
+
+	assert.Equal(t, active.ID, sessions[0].ID)
+}
+
+func TestSessionRepository_Touch(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_sessions", "users")
+
+	s := sqlstore.New(db)
+
+	assert.EqualError(t, s.Session().Touch("unknown"), store.ErrorRecordNotFound.Error())
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	sess := model.TestSession(t, u.ID)
+
+	s.Session().Create(sess)
+
+	assert.NoError(t, s.Session().Touch(sess.ID))
+}
+
+func TestSessionRepository_RevokeAllByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_sessions", "users")
+
+	s := sqlstore.New(db)
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	current := model.TestSession(t, u.ID)
+	another := model.TestSession(t, u.ID)
+
+	s.Session().Create(current)
+	s.Session().Create(another)
+
+	assert.NoError(t, s.Session().RevokeAllByUser(u.ID, current.ID))
+
+	sessions, _ := s.Session().FindByUser(u.ID)
+
+	assert.Len(t, sessions, 1)
+
+	assert.Equal(t, current.ID, sessions[0].ID)
+}
+
+func TestSessionRepository_CreateImpersonated(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("user_sessions", "users")
+
+	s := sqlstore.New(db)
+
+	admin := model.TestUser(t)
+	admin.Email = "admin@example.org"
+
+	u := model.TestUser(t)
+
+	s.User().Create(admin)
+	s.User().Create(u)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	sess := model.TestSession(t, u.ID)
+	sess.ImpersonatorID = &admin.ID
+	sess.ExpiresAt = &expiresAt
+
+	assert.NoError(t, s.Session().Create(sess))
+
+	found, err := s.Session().Find(sess.ID)
+
+	assert.NoError(t, err)
+
+	assert.True(t, found.IsImpersonated())
+
+	assert.Equal(t, admin.ID, *found.ImpersonatorID)
+
+	assert.WithinDuration(t, expiresAt, *found.ExpiresAt, time.Second)
+}
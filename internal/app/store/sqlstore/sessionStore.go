@@ -0,0 +1,174 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionStore is a sessions.Store that keeps session values in the sessions
+// table. The client cookie only carries the signed session ID.
+type SessionStore struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+	db      *sql.DB
+}
+
+func NewSessionStore(db *sql.DB, keyPairs ...[]byte) *SessionStore {
+	s := &SessionStore{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		db: db,
+	}
+
+	s.MaxAge(s.Options.MaxAge)
+
+	return s
+}
+
+func (s *SessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *SessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+
+	if err != nil {
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		return session, err
+	}
+
+	found, err := s.load(session)
+
+	if err != nil {
+		return session, err
+	}
+
+	if !found {
+		// The row has expired or was removed, start over with a fresh session.
+		session.ID = ""
+		return session, nil
+	}
+
+	session.IsNew = false
+
+	return session, nil
+}
+
+func (s *SessionStore) Save(r *http.Request, rw http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if _, err := s.db.Exec("DELETE FROM sessions WHERE id = $1", session.ID); err != nil {
+			return err
+		}
+
+		http.SetCookie(rw, sessions.NewCookie(session.Name(), "", session.Options))
+
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = strings.TrimRight(
+			base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
+	}
+
+	if err := s.save(session); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(rw, sessions.NewCookie(session.Name(), encoded, session.Options))
+
+	return nil
+}
+
+// MaxAge sets the maximum age for new sessions and for the underlying codecs.
+func (s *SessionStore) MaxAge(age int) {
+	s.Options.MaxAge = age
+
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// DeleteExpired removes all sessions whose expiry is in the past.
+func (s *SessionStore) DeleteExpired() error {
+	_, err := s.db.Exec("DELETE FROM sessions WHERE expires_at < now()")
+
+	return err
+}
+
+// StartCleanup runs DeleteExpired every interval until quit is closed or
+// written to, logging its errors to logger. StopCleanup should be used to
+// shut the routine down.
+func (s *SessionStore) StartCleanup(interval time.Duration, logger logrus.FieldLogger) (chan<- struct{}, <-chan struct{}) {
+	return startCleanup(interval, s.DeleteExpired, logger)
+}
+
+// StopCleanup stops the routine started by StartCleanup and waits for it to
+// finish.
+func (s *SessionStore) StopCleanup(quit chan<- struct{}, done <-chan struct{}) {
+	stopCleanup(quit, done)
+}
+
+func (s *SessionStore) save(session *sessions.Session) error {
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second)
+
+	_, err = s.db.Exec(
+		`INSERT INTO sessions (id, data, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at`,
+		session.ID,
+		encoded,
+		expiresAt)
+
+	return err
+}
+
+func (s *SessionStore) load(session *sessions.Session) (bool, error) {
+	var data string
+
+	if err := s.db.QueryRow(
+		"SELECT data FROM sessions WHERE id = $1 AND expires_at > now()",
+		session.ID).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if err := securecookie.DecodeMulti(session.Name(), data, &session.Values, s.Codecs...); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,82 @@
+package sqlstore_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionStore_SaveAndLoad(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("sessions")
+
+	s := sqlstore.NewSessionStore(db, []byte("secret"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	session, err := s.New(req, "test")
+
+	assert.NoError(t, err)
+
+	assert.True(t, session.IsNew)
+
+	session.Values["user_id"] = 1
+
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, s.Save(req, rec, session))
+
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", rec.Header().Get("Set-Cookie"))
+
+	session, err = s.New(req, "test")
+
+	assert.NoError(t, err)
+
+	assert.False(t, session.IsNew)
+
+	assert.Equal(t, 1, session.Values["user_id"])
+}
+
+func TestSessionStore_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("sessions")
+
+	s := sqlstore.NewSessionStore(db, []byte("secret"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	session, _ := s.New(req, "test")
+
+	rec := httptest.NewRecorder()
+
+	s.Save(req, rec, session)
+
+	session.Options.MaxAge = -1
+
+	assert.NoError(t, s.Save(req, httptest.NewRecorder(), session))
+
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", rec.Header().Get("Set-Cookie"))
+
+	session, err := s.New(req, "test")
+
+	assert.NoError(t, err)
+
+	assert.True(t, session.IsNew)
+}
+
+func TestSessionStore_DeleteExpired(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("sessions")
+
+	s := sqlstore.NewSessionStore(db, []byte("secret"))
+
+	assert.NoError(t, s.DeleteExpired())
+}
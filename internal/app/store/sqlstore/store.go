@@ -7,8 +7,24 @@ import (
 )
 
 type Store struct {
-	db             *sql.DB
-	userRepository *UserRepository
+	db                      *sql.DB
+	userRepository          *UserRepository
+	organizationRepository  *OrganizationRepository
+	membershipRepository    *MembershipRepository
+	invitationRepository    *InvitationRepository
+	sessionRepository       *SessionRepository
+	refreshTokenRepository  *RefreshTokenRepository
+	apiTokenRepository      *APITokenRepository
+	passwordResetRepository *PasswordResetRepository
+	magicLinkRepository     *MagicLinkRepository
+	identityRepository      *IdentityRepository
+	oauthClientRepository   *OAuthClientRepository
+	oauthCodeRepository     *OAuthCodeRepository
+	oauthTokenRepository    *OAuthTokenRepository
+	oauthConsentRepository  *OAuthConsentRepository
+	roleRepository          *RoleRepository
+	recoveryCodeRepository  *RecoveryCodeRepository
+	auditRepository         *AuditRepository
 }
 
 func New(db *sql.DB) *Store {
@@ -28,3 +44,195 @@ func (s *Store) User() store.UserRepository {
 
 	return s.userRepository
 }
+
+func (s *Store) Organization() store.OrganizationRepository {
+	if s.organizationRepository != nil {
+		return s.organizationRepository
+	}
+
+	s.organizationRepository = &OrganizationRepository{
+		store: s,
+	}
+
+	return s.organizationRepository
+}
+
+func (s *Store) Membership() store.MembershipRepository {
+	if s.membershipRepository != nil {
+		return s.membershipRepository
+	}
+
+	s.membershipRepository = &MembershipRepository{
+		store: s,
+	}
+
+	return s.membershipRepository
+}
+
+func (s *Store) Invitation() store.InvitationRepository {
+	if s.invitationRepository != nil {
+		return s.invitationRepository
+	}
+
+	s.invitationRepository = &InvitationRepository{
+		store: s,
+	}
+
+	return s.invitationRepository
+}
+
+func (s *Store) Session() store.SessionRepository {
+	if s.sessionRepository != nil {
+		return s.sessionRepository
+	}
+
+	s.sessionRepository = &SessionRepository{
+		store: s,
+	}
+
+	return s.sessionRepository
+}
+
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository != nil {
+		return s.refreshTokenRepository
+	}
+
+	s.refreshTokenRepository = &RefreshTokenRepository{
+		store: s,
+	}
+
+	return s.refreshTokenRepository
+}
+
+func (s *Store) APIToken() store.APITokenRepository {
+	if s.apiTokenRepository != nil {
+		return s.apiTokenRepository
+	}
+
+	s.apiTokenRepository = &APITokenRepository{
+		store: s,
+	}
+
+	return s.apiTokenRepository
+}
+
+func (s *Store) PasswordReset() store.PasswordResetRepository {
+	if s.passwordResetRepository != nil {
+		return s.passwordResetRepository
+	}
+
+	s.passwordResetRepository = &PasswordResetRepository{
+		store: s,
+	}
+
+	return s.passwordResetRepository
+}
+
+func (s *Store) MagicLink() store.MagicLinkRepository {
+	if s.magicLinkRepository != nil {
+		return s.magicLinkRepository
+	}
+
+	s.magicLinkRepository = &MagicLinkRepository{
+		store: s,
+	}
+
+	return s.magicLinkRepository
+}
+
+func (s *Store) Identity() store.IdentityRepository {
+	if s.identityRepository != nil {
+		return s.identityRepository
+	}
+
+	s.identityRepository = &IdentityRepository{
+		store: s,
+	}
+
+	return s.identityRepository
+}
+
+func (s *Store) OAuthClient() store.OAuthClientRepository {
+	if s.oauthClientRepository != nil {
+		return s.oauthClientRepository
+	}
+
+	s.oauthClientRepository = &OAuthClientRepository{
+		store: s,
+	}
+
+	return s.oauthClientRepository
+}
+
+func (s *Store) OAuthCode() store.OAuthCodeRepository {
+	if s.oauthCodeRepository != nil {
+		return s.oauthCodeRepository
+	}
+
+	s.oauthCodeRepository = &OAuthCodeRepository{
+		store: s,
+	}
+
+	return s.oauthCodeRepository
+}
+
+func (s *Store) OAuthToken() store.OAuthTokenRepository {
+	if s.oauthTokenRepository != nil {
+		return s.oauthTokenRepository
+	}
+
+	s.oauthTokenRepository = &OAuthTokenRepository{
+		store: s,
+	}
+
+	return s.oauthTokenRepository
+}
+
+func (s *Store) OAuthConsent() store.OAuthConsentRepository {
+	if s.oauthConsentRepository != nil {
+		return s.oauthConsentRepository
+	}
+
+	s.oauthConsentRepository = &OAuthConsentRepository{
+		store: s,
+	}
+
+	return s.oauthConsentRepository
+}
+
+func (s *Store) Role() store.RoleRepository {
+	if s.roleRepository != nil {
+		return s.roleRepository
+	}
+
+	s.roleRepository = &RoleRepository{
+		store: s,
+	}
+
+	return s.roleRepository
+}
+
+func (s *Store) RecoveryCode() store.RecoveryCodeRepository {
+	if s.recoveryCodeRepository != nil {
+		return s.recoveryCodeRepository
+	}
+
+	s.recoveryCodeRepository = &RecoveryCodeRepository{
+		store: s,
+	}
+
+	return s.recoveryCodeRepository
+}
+
+func (s *Store) Audit() store.AuditRepository {
+	if s.auditRepository != nil {
+		return s.auditRepository
+	}
+
+	s.auditRepository = &AuditRepository{
+		store: s,
+	}
+
+	return s.auditRepository
+}
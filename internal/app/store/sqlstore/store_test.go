@@ -3,6 +3,9 @@ package sqlstore_test
 import (
 	"os"
 	"testing"
+	"webserver/internal/app/model"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -15,5 +18,7 @@ func TestMain(m *testing.M) {
 		databaseURL = "host=localhost dbname=api_server sslmode=disable"
 	}
 
+	model.SetPasswordHasher(&model.BcryptHasher{Cost: bcrypt.MinCost})
+
 	os.Exit(m.Run())
 }
@@ -0,0 +1,94 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"time"
+	"webserver/internal/app/throttle"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ThrottleStore is a throttle.Store that keeps counters in the throttles
+// table, so that they are shared by all instances of the server.
+type ThrottleStore struct {
+	db *sql.DB
+}
+
+func NewThrottleStore(db *sql.DB) *ThrottleStore {
+	return &ThrottleStore{
+		db: db,
+	}
+}
+
+func (s *ThrottleStore) Get(key string) (*throttle.Entry, error) {
+	e := &throttle.Entry{}
+
+	var blockedUntil sql.NullTime
+
+	err := s.db.QueryRow(
+		"SELECT failures, blocked_until FROM throttles WHERE key = $1 AND expires_at > now()",
+		key).Scan(&e.Failures, &blockedUntil)
+
+	if err == sql.ErrNoRows {
+		return e, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.BlockedUntil = blockedUntil.Time
+
+	return e, nil
+}
+
+func (s *ThrottleStore) Fail(key string, window time.Duration) (int, error) {
+	var n int
+
+	err := s.db.QueryRow(
+		`INSERT INTO throttles (key, failures, expires_at) VALUES ($1, 1, now() + $2::double precision * interval '1 microsecond')
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN throttles.expires_at <= now() THEN 1 ELSE throttles.failures + 1 END,
+			blocked_until = CASE WHEN throttles.expires_at <= now() THEN NULL ELSE throttles.blocked_until END,
+			expires_at = greatest(EXCLUDED.expires_at, throttles.blocked_until)
+		RETURNING failures`,
+		key,
+		window.Microseconds()).Scan(&n)
+
+	return n, err
+}
+
+func (s *ThrottleStore) Block(key string, until time.Time) error {
+	_, err := s.db.Exec(
+		"UPDATE throttles SET blocked_until = $2, expires_at = greatest(expires_at, $2) WHERE key = $1",
+		key,
+		until)
+
+	return err
+}
+
+func (s *ThrottleStore) Reset(key string) error {
+	_, err := s.db.Exec("DELETE FROM throttles WHERE key = $1", key)
+
+	return err
+}
+
+// DeleteExpired removes all counters that are no longer in effect.
+func (s *ThrottleStore) DeleteExpired() error {
+	_, err := s.db.Exec("DELETE FROM throttles WHERE expires_at <= now()")
+
+	return err
+}
+
+// StartCleanup runs DeleteExpired every interval until quit is closed or
+// written to, logging its errors to logger. StopCleanup should be used to
+// shut the routine down.
+func (s *ThrottleStore) StartCleanup(interval time.Duration, logger logrus.FieldLogger) (chan<- struct{}, <-chan struct{}) {
+	return startCleanup(interval, s.DeleteExpired, logger)
+}
+
+// StopCleanup stops the routine started by StartCleanup and waits for it to
+// finish.
+func (s *ThrottleStore) StopCleanup(quit chan<- struct{}, done <-chan struct{}) {
+	stopCleanup(quit, done)
+}
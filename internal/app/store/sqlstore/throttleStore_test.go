@@ -0,0 +1,68 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+	"webserver/internal/app/store/sqlstore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottleStore_Fail(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("throttles")
+
+	s := sqlstore.NewThrottleStore(db)
+
+	e, err := s.Get("key")
+
+	assert.NoError(t, err)
+
+	assert.Zero(t, e.Failures)
+
+	n, err := s.Fail("key", time.Minute)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, n)
+
+	n, _ = s.Fail("key", time.Minute)
+
+	assert.Equal(t, 2, n)
+
+	until := time.Now().Add(time.Hour)
+
+	assert.NoError(t, s.Block("key", until))
+
+	e, _ = s.Get("key")
+
+	assert.Equal(t, 2, e.Failures)
+
+	assert.WithinDuration(t, until, e.BlockedUntil, time.Millisecond)
+
+	assert.NoError(t, s.Reset("key"))
+
+	e, _ = s.Get("key")
+
+	assert.Zero(t, e.Failures)
+}
+
+func TestThrottleStore_DeleteExpired(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+
+	defer teardown("throttles")
+
+	s := sqlstore.NewThrottleStore(db)
+
+	s.Fail("expired", -time.Minute)
+	s.Fail("active", time.Minute)
+
+	assert.NoError(t, s.DeleteExpired())
+
+	var count int
+
+	db.QueryRow("SELECT count(*) FROM throttles").Scan(&count)
+
+	assert.Equal(t, 1, count)
+}
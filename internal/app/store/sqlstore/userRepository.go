@@ -1,15 +1,26 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"webserver/internal/app/model"
 	"webserver/internal/app/store"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/lib/pq"
 )
 
 type UserRepository struct {
 	store *Store
 }
 
+const userColumns = "id, email, encrypted_password, email_verified_at, created_at, deleted_at, encrypted_totp_secret, totp_enabled_at, totp_last_step, tokens_revoked_at"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (r *UserRepository) Create(u *model.User) error {
 
 	if err := u.Validate(); err != nil {
@@ -21,64 +32,343 @@ func (r *UserRepository) Create(u *model.User) error {
 	}
 
 	return r.store.db.QueryRow(
-		"INSERT INTO users (email, encrypted_password) VALUES ($1, $2) RETURNING id",
+		"INSERT INTO users (email, encrypted_password) VALUES ($1, $2) RETURNING id, created_at",
 		u.Email,
-		u.EncryptedPassword).Scan(&u.ID)
+		u.EncryptedPassword).Scan(&u.ID, &u.CreatedAt)
 	
 }
 
 func (r *UserRepository) GetAll() ([]*model.User, error) {
-	users := []*model.User{}
-
-	rows, err := r.store.db.Query("SELECT * FROM users ORDER BY id ASC")
+	rows, err := r.store.db.Query("SELECT " + userColumns + " FROM users WHERE deleted_at IS NULL ORDER BY id ASC")
 
 	if err != nil {
 		return nil, err
 	}
 
-	for rows.Next() {
-		var u *model.User
+	return r.scanAll(rows)
+}
 
-		if err := rows.Scan(&u.ID, &u.Email, &u.Password); err != nil {
-			return nil, err
+// List returns a page of users matching opts, together with the cursor of
+// the next page, which is nil on the last page.
+func (r *UserRepository) List(ctx context.Context, opts store.ListOptions) ([]*model.User, *store.Cursor, error) {
+	if err := opts.Normalize(); err != nil {
+		return nil, nil, err
+	}
+
+	args := []interface{}{}
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := userConditions(opts, arg)
+
+	order, cmp := "ASC", ">"
+
+	if opts.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	if opts.Cursor != nil {
+		if opts.Sort == store.SortByID {
+			where = append(where, "id "+cmp+" "+arg(opts.Cursor.ID))
+		} else {
+			where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", opts.Sort, cmp, arg(opts.Cursor.Value), arg(opts.Cursor.ID)))
 		}
+	}
 
-		users = append(users, u)
+	query := "SELECT " + userColumns + " FROM users"
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
 	}
 
-	return users, nil
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", opts.Sort, order, order, arg(opts.Limit+1))
+
+	rows, err := r.store.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users, err := r.scanAll(rows)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(users) <= opts.Limit {
+		return users, nil, nil
+	}
+
+	users = users[:opts.Limit]
+
+	return users, store.NewUserCursor(users[len(users)-1], opts.Sort), nil
 }
 
-func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
-	u := &model.User{}
+// Count returns the number of users matching opts. The sort order, limit and
+// cursor of opts are ignored.
+func (r *UserRepository) Count(ctx context.Context, opts store.ListOptions) (int, error) {
+	args := []interface{}{}
 
-	if err := r.store.db.QueryRow("SELECT id, email, encrypted_password FROM users WHERE email = $1", email).Scan(
-		&u.ID,
-		&u.Email,
-		&u.EncryptedPassword);
-		err != nil {
-			if err == sql.ErrNoRows {
-				return nil, store.ErrorRecordNotFound
-			}
-		return nil, err
+	arg := func(v interface{}) string {
+		args = append(args, v)
+
+		return fmt.Sprintf("$%d", len(args))
 	}
 
-	return u, nil
+	query := "SELECT count(*) FROM users"
+
+	if where := userConditions(opts, arg); len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var n int
+
+	if err := r.store.db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// userConditions returns the conditions on users that make up the filters of
+// opts. arg adds a query argument and returns its placeholder.
+func userConditions(opts store.ListOptions, arg func(interface{}) string) []string {
+	where := []string{}
+
+	switch {
+	case opts.OnlyDeleted:
+		where = append(where, "deleted_at IS NOT NULL")
+	case !opts.IncludeDeleted:
+		where = append(where, "deleted_at IS NULL")
+	}
+
+	if opts.ID != 0 {
+		where = append(where, "id = "+arg(opts.ID))
+	}
+
+	if opts.Email != "" {
+		where = append(where, "email ILIKE '%' || "+arg(likeEscaper.Replace(opts.Email))+" || '%'")
+	}
+
+	if !opts.CreatedAfter.IsZero() {
+		where = append(where, "created_at >= "+arg(opts.CreatedAfter))
+	}
+
+	if !opts.CreatedBefore.IsZero() {
+		where = append(where, "created_at < "+arg(opts.CreatedBefore))
+	}
+
+	return where
+}
+
+func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
+	return r.scan(r.store.db.QueryRow("SELECT "+userColumns+" FROM users WHERE email = $1 AND deleted_at IS NULL", email))
 }
 
 func (r *UserRepository) Find(id int) (*model.User, error) {
+	return r.scan(r.store.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1 AND deleted_at IS NULL", id))
+}
+
+// FindIncludingDeleted is Find for users that may have been soft deleted.
+func (r *UserRepository) FindIncludingDeleted(id int) (*model.User, error) {
+	return r.scan(r.store.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+// UpdateEmail persists the email address of u together with its
+// verification state. It fails with store.ErrorEmailTaken if another user has
+// the address.
+func (r *UserRepository) UpdateEmail(u *model.User) error {
+	if err := validation.Validate(u.Email, validation.Required, is.Email); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE users SET email = $1, email_verified_at = $2 WHERE id = $3",
+		u.Email,
+		u.EmailVerifiedAt,
+		u.ID)
+
+	if err, ok := err.(*pq.Error); ok && err.Code == uniqueViolation {
+		return store.ErrorEmailTaken
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// UpdatePassword validates and encrypts u.Password and persists it as the new
+// password of u.
+func (r *UserRepository) UpdatePassword(u *model.User) error {
+	if err := u.SetPassword(u.Password); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE users SET encrypted_password = $1 WHERE id = $2",
+		u.EncryptedPassword,
+		u.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// Update validates u and persists its email, verification state and, when
+// u.Password is set, a newly encrypted password.
+func (r *UserRepository) Update(u *model.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE users SET email = $1, encrypted_password = $2, email_verified_at = $3 WHERE id = $4 AND deleted_at IS NULL",
+		u.Email,
+		u.EncryptedPassword,
+		u.EmailVerifiedAt,
+		u.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// Delete permanently removes the user together with everything that
+// references it.
+func (r *UserRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM users WHERE id = $1", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// SoftDelete marks the user as deleted, hiding it from Find and FindByEmail
+// until it is restored.
+func (r *UserRepository) SoftDelete(id int) error {
+	res, err := r.store.db.Exec("UPDATE users SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// Restore reverts a previous SoftDelete. It fails with store.ErrorEmailTaken
+// if another user has taken the email address in the meantime.
+func (r *UserRepository) Restore(id int) error {
+	res, err := r.store.db.Exec("UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", id)
+
+	if err, ok := err.(*pq.Error); ok && err.Code == uniqueViolation {
+		return store.ErrorEmailTaken
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// UpdateTOTP persists the TOTP enrollment state of u.
+func (r *UserRepository) UpdateTOTP(u *model.User) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET encrypted_totp_secret = $1, totp_enabled_at = $2, totp_last_step = $3 WHERE id = $4",
+		u.EncryptedTOTPSecret,
+		u.TOTPEnabledAt,
+		u.TOTPLastStep,
+		u.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// UseTOTPStep records step as the last TOTP step used by the user. It fails
+// with store.ErrorRecordNotFound if the step, or a later one, has already been
+// used.
+func (r *UserRepository) UseTOTPStep(id int, step int64) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2",
+		id,
+		step)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// RevokeTokens records that every access token issued to the user so far is
+// revoked. Deleted users are included.
+func (r *UserRepository) RevokeTokens(id int) error {
+	res, err := r.store.db.Exec("UPDATE users SET tokens_revoked_at = now() WHERE id = $1", id)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *UserRepository) scan(row scanner) (*model.User, error) {
 	u := &model.User{}
 
-	if err := r.store.db.QueryRow("SELECT id, email, encrypted_password FROM users WHERE id = $1", id).Scan(
+	if err := row.Scan(
 		&u.ID,
 		&u.Email,
-		&u.EncryptedPassword);
-		err != nil {
-			if err == sql.ErrNoRows {
-				return nil, store.ErrorRecordNotFound
-			}
+		&u.EncryptedPassword,
+		&u.EmailVerifiedAt,
+		&u.CreatedAt,
+		&u.DeletedAt,
+		&u.EncryptedTOTPSecret,
+		&u.TOTPEnabledAt,
+		&u.TOTPLastStep,
+		&u.TokensRevokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrorRecordNotFound
+		}
+
 		return nil, err
 	}
 
 	return u, nil
-}
\ No newline at end of file
+}
+
+func (r *UserRepository) scanAll(rows *sql.Rows) ([]*model.User, error) {
+	defer rows.Close()
+
+	users := []*model.User{}
+
+	for rows.Next() {
+		u, err := r.scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
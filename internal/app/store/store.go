@@ -2,4 +2,20 @@ package store
 
 type Store interface {
 	User() UserRepository
-}
\ No newline at end of file
+	Organization() OrganizationRepository
+	Membership() MembershipRepository
+	Invitation() InvitationRepository
+	Session() SessionRepository
+	RefreshToken() RefreshTokenRepository
+	APIToken() APITokenRepository
+	PasswordReset() PasswordResetRepository
+	MagicLink() MagicLinkRepository
+	Identity() IdentityRepository
+	OAuthClient() OAuthClientRepository
+	OAuthCode() OAuthCodeRepository
+	OAuthToken() OAuthTokenRepository
+	OAuthConsent() OAuthConsentRepository
+	Role() RoleRepository
+	RecoveryCode() RecoveryCodeRepository
+	Audit() AuditRepository
+}
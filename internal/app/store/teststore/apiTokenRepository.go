@@ -0,0 +1,92 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type APITokenRepository struct {
+	store  *Store
+	tokens map[int]*model.APIToken
+	lastID int
+}
+
+func (r *APITokenRepository) Create(t *model.APIToken) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	r.lastID++
+	t.ID = r.lastID
+	t.CreatedAt = time.Now()
+	r.tokens[t.ID] = t
+
+	return nil
+}
+
+func (r *APITokenRepository) Find(id int) (*model.APIToken, error) {
+	t, ok := r.tokens[id]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return t, nil
+}
+
+func (r *APITokenRepository) FindByToken(token string) (*model.APIToken, error) {
+	hash := model.HashToken(token)
+
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return t, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *APITokenRepository) FindByUser(userID int) ([]*model.APIToken, error) {
+	tokens := []*model.APIToken{}
+
+	for _, t := range r.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, t)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+
+	return tokens, nil
+}
+
+func (r *APITokenRepository) Touch(id int) error {
+	t, ok := r.tokens[id]
+
+	if !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	t.LastUsedAt = &now
+
+	return nil
+}
+
+func (r *APITokenRepository) Delete(id int) error {
+	if _, ok := r.tokens[id]; !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	delete(r.tokens, id)
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokenRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	at := model.TestAPIToken(t, 1)
+
+	assert.NoError(t, s.APIToken().Create(at))
+
+	assert.NotEmpty(t, at.Token)
+
+	at = model.TestAPIToken(t, 1)
+	at.Name = ""
+
+	assert.Error(t, s.APIToken().Create(at))
+}
+
+func TestAPITokenRepository_Find(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.APIToken().Find(1)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	at := model.TestAPIToken(t, 1)
+
+	s.APIToken().Create(at)
+
+	found, err := s.APIToken().Find(at.ID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, at.Name, found.Name)
+}
+
+func TestAPITokenRepository_FindByToken(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.APIToken().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	at := model.TestAPIToken(t, 1)
+
+	s.APIToken().Create(at)
+
+	found, err := s.APIToken().FindByToken(at.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, at.ID, found.ID)
+}
+
+func TestAPITokenRepository_FindByUser(t *testing.T) {
+	s := teststore.New()
+
+	s.APIToken().Create(model.TestAPIToken(t, 1))
+	s.APIToken().Create(model.TestAPIToken(t, 2))
+
+	tokens, err := s.APIToken().FindByUser(1)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, tokens, 1)
+}
+
+func TestAPITokenRepository_Touch(t *testing.T) {
+	s := teststore.New()
+
+	at := model.TestAPIToken(t, 1)
+
+	s.APIToken().Create(at)
+
+	assert.NoError(t, s.APIToken().Touch(at.ID))
+
+	found, _ := s.APIToken().Find(at.ID)
+
+	assert.NotNil(t, found.LastUsedAt)
+}
+
+func TestAPITokenRepository_Delete(t *testing.T) {
+	s := teststore.New()
+
+	at := model.TestAPIToken(t, 1)
+
+	s.APIToken().Create(at)
+
+	assert.NoError(t, s.APIToken().Delete(at.ID))
+
+	assert.EqualError(t, s.APIToken().Delete(at.ID), store.ErrorRecordNotFound.Error())
+}
@@ -0,0 +1,64 @@
+package teststore
+
+import (
+	"context"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type AuditRepository struct {
+	store  *Store
+	events []*model.AuditEvent
+}
+
+func (r *AuditRepository) Append(e *model.AuditEvent) error {
+	prev := ""
+
+	if len(r.events) > 0 {
+		prev = r.events[len(r.events)-1].Hash
+	}
+
+	e.ID = len(r.events) + 1
+	e.Seal(prev)
+	r.events = append(r.events, e)
+
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, f store.AuditFilter) ([]*model.AuditEvent, *store.Cursor, error) {
+	f.Normalize()
+
+	events := []*model.AuditEvent{}
+
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+
+		if f.Action != "" && e.Action != f.Action ||
+			f.ActorID != 0 && (e.ActorID == nil || *e.ActorID != f.ActorID) ||
+			f.TargetID != 0 && (e.TargetID == nil || *e.TargetID != f.TargetID) ||
+			!f.Since.IsZero() && e.CreatedAt.Before(f.Since) ||
+			!f.Until.IsZero() && !e.CreatedAt.Before(f.Until) ||
+			f.Cursor != nil && e.ID >= f.Cursor.ID {
+			continue
+		}
+
+		if len(events) == f.Limit {
+			return events, &store.Cursor{ID: events[len(events)-1].ID}, nil
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil, nil
+}
+
+// Walk calls fn with every event, oldest first, until fn fails.
+func (r *AuditRepository) Walk(fn func(*model.AuditEvent) error) error {
+	for _, e := range r.events {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package teststore_test
+
+import (
+	"context"
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRepository_Append(t *testing.T) {
+	s := teststore.New()
+
+	first := model.TestAuditEvent(t, model.AuditUserCreated)
+
+	assert.NoError(t, s.Audit().Append(first))
+
+	second := model.TestAuditEvent(t, model.AuditLoginSucceeded)
+
+	assert.NoError(t, s.Audit().Append(second))
+
+	assert.Equal(t, first.Hash, second.PrevHash)
+
+	n, err := store.VerifyAuditChain(s.Audit())
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, n)
+
+	first.IP = "198.51.100.1"
+
+	_, err = store.VerifyAuditChain(s.Audit())
+
+	assert.EqualError(t, err, (&store.AuditChainError{ID: first.ID}).Error())
+}
+
+func TestAuditRepository_List(t *testing.T) {
+	s := teststore.New()
+
+	userID := 1
+
+	for i := 0; i < 3; i++ {
+		e := model.TestAuditEvent(t, model.AuditLoginFailed)
+		e.TargetID = &userID
+
+		s.Audit().Append(e)
+	}
+
+	s.Audit().Append(model.TestAuditEvent(t, model.AuditUserCreated))
+
+	events, cursor, err := s.Audit().List(context.Background(), store.AuditFilter{TargetID: userID, Limit: 2})
+
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 2)
+
+	assert.Equal(t, 3, events[0].ID)
+
+	assert.NotNil(t, cursor)
+
+	events, cursor, err = s.Audit().List(context.Background(), store.AuditFilter{TargetID: userID, Limit: 2, Cursor: cursor})
+
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 1)
+
+	assert.Nil(t, cursor)
+
+	events, _, err = s.Audit().List(context.Background(), store.AuditFilter{Action: model.AuditUserCreated})
+
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 1)
+}
@@ -0,0 +1,47 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type IdentityRepository struct {
+	store      *Store
+	identities map[int]*model.Identity
+}
+
+func (r *IdentityRepository) Create(i *model.Identity) error {
+	i.ID = len(r.identities) + 1
+	i.CreatedAt = time.Now()
+	r.identities[i.ID] = i
+
+	return nil
+}
+
+func (r *IdentityRepository) FindBySubject(provider, subject string) (*model.Identity, error) {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return i, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *IdentityRepository) FindByUser(userID int) ([]*model.Identity, error) {
+	identities := []*model.Identity{}
+
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			identities = append(identities, i)
+		}
+	}
+
+	sort.Slice(identities, func(a, b int) bool {
+		return identities[a].ID < identities[b].ID
+	})
+
+	return identities, nil
+}
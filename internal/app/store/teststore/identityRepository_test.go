@@ -0,0 +1,54 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	i := model.TestIdentity(t, 1)
+
+	assert.NoError(t, s.Identity().Create(i))
+
+	assert.NotZero(t, i.ID)
+}
+
+func TestIdentityRepository_FindBySubject(t *testing.T) {
+	s := teststore.New()
+
+	i := model.TestIdentity(t, 1)
+
+	s.Identity().Create(i)
+
+	_, err := s.Identity().FindBySubject("other", i.Subject)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	found, err := s.Identity().FindBySubject(i.Provider, i.Subject)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, i.UserID, found.UserID)
+}
+
+func TestIdentityRepository_FindByUser(t *testing.T) {
+	s := teststore.New()
+
+	s.Identity().Create(model.TestIdentity(t, 1))
+
+	other := model.TestIdentity(t, 2)
+	other.Subject = "other"
+	s.Identity().Create(other)
+
+	identities, err := s.Identity().FindByUser(1)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, identities, 1)
+}
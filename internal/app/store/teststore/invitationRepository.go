@@ -0,0 +1,121 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type InvitationRepository struct {
+	store       *Store
+	invitations map[int]*model.Invitation
+}
+
+func (r *InvitationRepository) Create(i *model.Invitation) error {
+	if err := i.Validate(); err != nil {
+		return err
+	}
+
+	i.ID = len(r.invitations) + 1
+	i.CreatedAt = time.Now()
+	r.invitations[i.ID] = i
+
+	return nil
+}
+
+func (r *InvitationRepository) Find(id int) (*model.Invitation, error) {
+	i, ok := r.invitations[id]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return i, nil
+}
+
+func (r *InvitationRepository) FindByOrganization(organizationID int) ([]*model.Invitation, error) {
+	return r.findAll(func(i *model.Invitation) bool {
+		return i.OrganizationID != nil && *i.OrganizationID == organizationID
+	}), nil
+}
+
+// FindWithoutOrganization returns the invitations to sign up that are not
+// tied to an organization.
+func (r *InvitationRepository) FindWithoutOrganization() ([]*model.Invitation, error) {
+	return r.findAll(func(i *model.Invitation) bool {
+		return i.OrganizationID == nil
+	}), nil
+}
+
+// Renew stores the new expiry of an invitation. It fails with
+// store.ErrorRecordNotFound if the invitation has been accepted or revoked.
+func (r *InvitationRepository) Renew(i *model.Invitation) error {
+	existing, err := r.findOpen(i.ID)
+
+	if err != nil {
+		return err
+	}
+
+	existing.ExpiresAt = i.ExpiresAt
+
+	return nil
+}
+
+// Revoke withdraws an invitation. It fails with store.ErrorRecordNotFound if
+// the invitation has been accepted or revoked.
+func (r *InvitationRepository) Revoke(id int) error {
+	i, err := r.findOpen(id)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	i.RevokedAt = &now
+
+	return nil
+}
+
+// MarkAccepted consumes an invitation. It fails with
+// store.ErrorRecordNotFound if the invitation has been accepted or revoked.
+func (r *InvitationRepository) MarkAccepted(id int) error {
+	i, err := r.findOpen(id)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	i.AcceptedAt = &now
+
+	return nil
+}
+
+// findOpen returns the invitation with the given ID unless it has been
+// accepted or revoked.
+func (r *InvitationRepository) findOpen(id int) (*model.Invitation, error) {
+	i, ok := r.invitations[id]
+
+	if !ok || i.AcceptedAt != nil || i.RevokedAt != nil {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return i, nil
+}
+
+func (r *InvitationRepository) findAll(match func(*model.Invitation) bool) []*model.Invitation {
+	invitations := []*model.Invitation{}
+
+	for _, i := range r.invitations {
+		if match(i) {
+			invitations = append(invitations, i)
+		}
+	}
+
+	sort.Slice(invitations, func(a, b int) bool {
+		return invitations[a].ID < invitations[b].ID
+	})
+
+	return invitations
+}
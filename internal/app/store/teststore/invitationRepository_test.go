@@ -0,0 +1,117 @@
+package teststore_test
+
+import (
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitationRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	i := model.TestInvitation(t, nil)
+
+	assert.NoError(t, s.Invitation().Create(i))
+
+	assert.NotZero(t, i.ID)
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	i = model.TestInvitation(t, &o.ID)
+
+	assert.NoError(t, s.Invitation().Create(i))
+
+	found, err := s.Invitation().Find(i.ID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, o.ID, *found.OrganizationID)
+
+	i = model.TestInvitation(t, &o.ID)
+	i.Role = ""
+
+	assert.Error(t, s.Invitation().Create(i))
+}
+
+func TestInvitationRepository_FindByOrganization(t *testing.T) {
+	s := teststore.New()
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	s.Invitation().Create(model.TestInvitation(t, nil))
+
+	s.Invitation().Create(model.TestInvitation(t, &o.ID))
+
+	invitations, err := s.Invitation().FindByOrganization(o.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, invitations, 1)
+
+	invitations, err = s.Invitation().FindWithoutOrganization()
+
+	assert.NoError(t, err)
+
+	assert.Len(t, invitations, 1)
+
+	assert.Nil(t, invitations[0].OrganizationID)
+}
+
+func TestInvitationRepository_Renew(t *testing.T) {
+	s := teststore.New()
+
+	i := model.TestInvitation(t, nil)
+	i.ExpiresAt = time.Now().Add(-time.Minute)
+
+	s.Invitation().Create(i)
+
+	i.ExpiresAt = time.Now().Add(time.Hour)
+
+	assert.NoError(t, s.Invitation().Renew(i))
+
+	found, _ := s.Invitation().Find(i.ID)
+
+	assert.True(t, found.IsPending())
+}
+
+func TestInvitationRepository_Revoke(t *testing.T) {
+	s := teststore.New()
+
+	i := model.TestInvitation(t, nil)
+
+	s.Invitation().Create(i)
+
+	assert.NoError(t, s.Invitation().Revoke(i.ID))
+
+	found, _ := s.Invitation().Find(i.ID)
+
+	assert.NotNil(t, found.RevokedAt)
+
+	assert.EqualError(t, s.Invitation().Revoke(i.ID), store.ErrorRecordNotFound.Error())
+
+	assert.EqualError(t, s.Invitation().MarkAccepted(i.ID), store.ErrorRecordNotFound.Error())
+
+	assert.EqualError(t, s.Invitation().Renew(i), store.ErrorRecordNotFound.Error())
+}
+
+func TestInvitationRepository_MarkAccepted(t *testing.T) {
+	s := teststore.New()
+
+	i := model.TestInvitation(t, nil)
+
+	s.Invitation().Create(i)
+
+	assert.NoError(t, s.Invitation().MarkAccepted(i.ID))
+
+	assert.EqualError(t, s.Invitation().MarkAccepted(i.ID), store.ErrorRecordNotFound.Error())
+
+	assert.EqualError(t, s.Invitation().Revoke(i.ID), store.ErrorRecordNotFound.Error())
+}
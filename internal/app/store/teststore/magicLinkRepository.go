@@ -0,0 +1,49 @@
+package teststore
+
+import (
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type MagicLinkRepository struct {
+	store *Store
+	links map[int]*model.MagicLink
+}
+
+func (r *MagicLinkRepository) Create(l *model.MagicLink) error {
+	if err := l.BeforeCreate(); err != nil {
+		return err
+	}
+
+	l.ID = len(r.links) + 1
+	l.CreatedAt = time.Now()
+	r.links[l.ID] = l
+
+	return nil
+}
+
+func (r *MagicLinkRepository) FindByToken(token string) (*model.MagicLink, error) {
+	hash := model.HashToken(token)
+
+	for _, l := range r.links {
+		if l.TokenHash == hash {
+			return l, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *MagicLinkRepository) MarkUsed(id int) error {
+	l, ok := r.links[id]
+
+	if !ok || l.IsUsed() {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	l.UsedAt = &now
+
+	return nil
+}
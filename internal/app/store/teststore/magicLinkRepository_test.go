@@ -0,0 +1,50 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLinkRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	l := model.TestMagicLink(t, 1, "nonce")
+
+	assert.NoError(t, s.MagicLink().Create(l))
+
+	assert.NotEmpty(t, l.Token)
+}
+
+func TestMagicLinkRepository_FindByToken(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.MagicLink().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	l := model.TestMagicLink(t, 1, "nonce")
+
+	s.MagicLink().Create(l)
+
+	found, err := s.MagicLink().FindByToken(l.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, l.ID, found.ID)
+}
+
+func TestMagicLinkRepository_MarkUsed(t *testing.T) {
+	s := teststore.New()
+
+	l := model.TestMagicLink(t, 1, "nonce")
+
+	s.MagicLink().Create(l)
+
+	assert.NoError(t, s.MagicLink().MarkUsed(l.ID))
+
+	assert.EqualError(t, s.MagicLink().MarkUsed(l.ID), store.ErrorRecordNotFound.Error())
+}
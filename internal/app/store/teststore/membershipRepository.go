@@ -0,0 +1,83 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type MembershipRepository struct {
+	store       *Store
+	memberships map[[2]int]*model.Membership
+}
+
+func (r *MembershipRepository) Create(m *model.Membership) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	u, err := r.store.User().FindIncludingDeleted(m.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	m.Email = u.Email
+	m.CreatedAt = time.Now()
+	r.memberships[[2]int{m.OrganizationID, m.UserID}] = m
+
+	return nil
+}
+
+func (r *MembershipRepository) Find(organizationID, userID int) (*model.Membership, error) {
+	m, ok := r.memberships[[2]int{organizationID, userID}]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return m, nil
+}
+
+func (r *MembershipRepository) FindByOrganization(organizationID int) ([]*model.Membership, error) {
+	memberships := []*model.Membership{}
+
+	for key, m := range r.memberships {
+		if key[0] == organizationID {
+			memberships = append(memberships, m)
+		}
+	}
+
+	sort.Slice(memberships, func(i, j int) bool {
+		return memberships[i].UserID < memberships[j].UserID
+	})
+
+	return memberships, nil
+}
+
+func (r *MembershipRepository) UpdateRole(m *model.Membership) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := r.Find(m.OrganizationID, m.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	existing.Role = m.Role
+
+	return nil
+}
+
+func (r *MembershipRepository) Delete(organizationID, userID int) error {
+	if _, err := r.Find(organizationID, userID); err != nil {
+		return err
+	}
+
+	delete(r.memberships, [2]int{organizationID, userID})
+
+	return nil
+}
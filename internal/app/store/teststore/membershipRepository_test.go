@@ -0,0 +1,111 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembershipRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	m := model.TestMembership(t, o.ID, u.ID)
+
+	assert.NoError(t, s.Membership().Create(m))
+
+	assert.Equal(t, u.Email, m.Email)
+
+	m = model.TestMembership(t, o.ID, u.ID)
+	m.Role = "superuser"
+
+	assert.Error(t, s.Membership().Create(m))
+}
+
+func TestMembershipRepository_FindByOrganization(t *testing.T) {
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	memberships, err := s.Membership().FindByOrganization(o.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, memberships, 0)
+
+	s.Membership().Create(model.TestMembership(t, o.ID, u.ID))
+
+	memberships, err = s.Membership().FindByOrganization(o.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, memberships, 1)
+
+	assert.Equal(t, u.Email, memberships[0].Email)
+}
+
+func TestMembershipRepository_UpdateRole(t *testing.T) {
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	m := model.TestMembership(t, o.ID, u.ID)
+
+	s.Membership().Create(m)
+
+	m.Role = model.OrganizationRoleAdmin
+
+	assert.NoError(t, s.Membership().UpdateRole(m))
+
+	found, err := s.Membership().Find(o.ID, u.ID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, model.OrganizationRoleAdmin, found.Role)
+
+	assert.EqualError(t, s.Membership().UpdateRole(model.TestMembership(t, o.ID, u.ID+1)), store.ErrorRecordNotFound.Error())
+}
+
+func TestMembershipRepository_Delete(t *testing.T) {
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	o := model.TestOrganization(t)
+
+	s.Organization().Create(o)
+
+	s.Membership().Create(model.TestMembership(t, o.ID, u.ID))
+
+	assert.NoError(t, s.Membership().Delete(o.ID, u.ID))
+
+	_, err := s.Membership().Find(o.ID, u.ID)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	assert.EqualError(t, s.Membership().Delete(o.ID, u.ID), store.ErrorRecordNotFound.Error())
+}
@@ -0,0 +1,75 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type OAuthClientRepository struct {
+	store   *Store
+	clients map[int]*model.OAuthClient
+	lastID  int
+}
+
+func (r *OAuthClientRepository) Create(c *model.OAuthClient) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.BeforeCreate(); err != nil {
+		return err
+	}
+
+	r.lastID++
+	c.ID = r.lastID
+	c.CreatedAt = time.Now()
+	r.clients[c.ID] = c
+
+	return nil
+}
+
+func (r *OAuthClientRepository) Find(id int) (*model.OAuthClient, error) {
+	c, ok := r.clients[id]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return c, nil
+}
+
+func (r *OAuthClientRepository) FindByClientID(clientID string) (*model.OAuthClient, error) {
+	for _, c := range r.clients {
+		if c.ClientID == clientID {
+			return c, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *OAuthClientRepository) GetAll() ([]*model.OAuthClient, error) {
+	clients := make([]*model.OAuthClient, 0, len(r.clients))
+
+	for _, c := range r.clients {
+		clients = append(clients, c)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+
+	return clients, nil
+}
+
+func (r *OAuthClientRepository) Delete(id int) error {
+	if _, ok := r.clients[id]; !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	delete(r.clients, id)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClientRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	c := model.TestOAuthClient(t, 1)
+
+	assert.NoError(t, s.OAuthClient().Create(c))
+
+	assert.NotEmpty(t, c.ClientID)
+
+	c = model.TestOAuthClient(t, 1)
+	c.Name = ""
+
+	assert.Error(t, s.OAuthClient().Create(c))
+}
+
+func TestOAuthClientRepository_FindByClientID(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.OAuthClient().FindByClientID("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	c := model.TestOAuthClient(t, 1)
+
+	s.OAuthClient().Create(c)
+
+	found, err := s.OAuthClient().FindByClientID(c.ClientID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, c.ID, found.ID)
+}
+
+func TestOAuthClientRepository_Delete(t *testing.T) {
+	s := teststore.New()
+
+	c := model.TestOAuthClient(t, 1)
+
+	s.OAuthClient().Create(c)
+
+	assert.NoError(t, s.OAuthClient().Delete(c.ID))
+
+	assert.EqualError(t, s.OAuthClient().Delete(c.ID), store.ErrorRecordNotFound.Error())
+
+	clients, err := s.OAuthClient().GetAll()
+
+	assert.NoError(t, err)
+
+	assert.Empty(t, clients)
+}
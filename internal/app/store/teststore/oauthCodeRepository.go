@@ -0,0 +1,49 @@
+package teststore
+
+import (
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type OAuthCodeRepository struct {
+	store *Store
+	codes map[int]*model.OAuthCode
+}
+
+func (r *OAuthCodeRepository) Create(c *model.OAuthCode) error {
+	if err := c.BeforeCreate(); err != nil {
+		return err
+	}
+
+	c.ID = len(r.codes) + 1
+	c.CreatedAt = time.Now()
+	r.codes[c.ID] = c
+
+	return nil
+}
+
+func (r *OAuthCodeRepository) FindByCode(code string) (*model.OAuthCode, error) {
+	hash := model.HashToken(code)
+
+	for _, c := range r.codes {
+		if c.CodeHash == hash {
+			return c, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *OAuthCodeRepository) MarkUsed(id int) error {
+	c, ok := r.codes[id]
+
+	if !ok || c.IsUsed() {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	c.UsedAt = &now
+
+	return nil
+}
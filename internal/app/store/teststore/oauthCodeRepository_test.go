@@ -0,0 +1,40 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthCodeRepository_FindByCode(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.OAuthCode().FindByCode("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	c := model.TestOAuthCode(t, 1, 1)
+
+	assert.NoError(t, s.OAuthCode().Create(c))
+
+	found, err := s.OAuthCode().FindByCode(c.Code)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, c.ID, found.ID)
+}
+
+func TestOAuthCodeRepository_MarkUsed(t *testing.T) {
+	s := teststore.New()
+
+	c := model.TestOAuthCode(t, 1, 1)
+
+	s.OAuthCode().Create(c)
+
+	assert.NoError(t, s.OAuthCode().MarkUsed(c.ID))
+
+	assert.EqualError(t, s.OAuthCode().MarkUsed(c.ID), store.ErrorRecordNotFound.Error())
+}
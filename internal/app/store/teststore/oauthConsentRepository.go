@@ -0,0 +1,27 @@
+package teststore
+
+import (
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type OAuthConsentRepository struct {
+	store    *Store
+	consents map[[2]int]*model.OAuthConsent
+}
+
+func (r *OAuthConsentRepository) Find(userID, clientID int) (*model.OAuthConsent, error) {
+	c, ok := r.consents[[2]int{userID, clientID}]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return c, nil
+}
+
+func (r *OAuthConsentRepository) Save(c *model.OAuthConsent) error {
+	r.consents[[2]int{c.UserID, c.ClientID}] = c
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthConsentRepository_Save(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.OAuthConsent().Find(1, 1)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	assert.NoError(t, s.OAuthConsent().Save(&model.OAuthConsent{UserID: 1, ClientID: 1, Scopes: []string{model.ScopeUserRead}}))
+	assert.NoError(t, s.OAuthConsent().Save(&model.OAuthConsent{UserID: 1, ClientID: 1, Scopes: []string{model.ScopeUserRead, model.ScopeUserWrite}}))
+
+	c, err := s.OAuthConsent().Find(1, 1)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, c.Scopes, 2)
+}
@@ -0,0 +1,51 @@
+package teststore
+
+import (
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type OAuthTokenRepository struct {
+	store  *Store
+	tokens map[int]*model.OAuthToken
+}
+
+func (r *OAuthTokenRepository) Create(t *model.OAuthToken) error {
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	t.ID = len(r.tokens) + 1
+	t.CreatedAt = time.Now()
+	r.tokens[t.ID] = t
+
+	return nil
+}
+
+func (r *OAuthTokenRepository) FindByToken(token string) (*model.OAuthToken, error) {
+	hash := model.HashToken(token)
+
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return t, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *OAuthTokenRepository) Revoke(id int) error {
+	t, ok := r.tokens[id]
+
+	if !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	if t.RevokedAt == nil {
+		now := time.Now()
+		t.RevokedAt = &now
+	}
+
+	return nil
+}
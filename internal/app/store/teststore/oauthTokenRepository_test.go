@@ -0,0 +1,45 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthTokenRepository_FindByToken(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.OAuthToken().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	tok := model.TestOAuthToken(t, 1, 1)
+
+	assert.NoError(t, s.OAuthToken().Create(tok))
+
+	found, err := s.OAuthToken().FindByToken(tok.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, tok.ID, found.ID)
+}
+
+func TestOAuthTokenRepository_Revoke(t *testing.T) {
+	s := teststore.New()
+
+	tok := model.TestOAuthToken(t, 1, 1)
+
+	s.OAuthToken().Create(tok)
+
+	assert.NoError(t, s.OAuthToken().Revoke(tok.ID))
+	assert.NoError(t, s.OAuthToken().Revoke(tok.ID))
+
+	found, _ := s.OAuthToken().FindByToken(tok.Token)
+
+	assert.False(t, found.IsActive())
+
+	assert.EqualError(t, s.OAuthToken().Revoke(100), store.ErrorRecordNotFound.Error())
+}
@@ -0,0 +1,82 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type OrganizationRepository struct {
+	store         *Store
+	organizations map[int]*model.Organization
+	lastID        int
+}
+
+func (r *OrganizationRepository) Create(o *model.Organization) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	r.lastID++
+	o.ID = r.lastID
+	o.CreatedAt = time.Now()
+	r.organizations[o.ID] = o
+
+	return nil
+}
+
+func (r *OrganizationRepository) Find(id int) (*model.Organization, error) {
+	o, ok := r.organizations[id]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return o, nil
+}
+
+// FindByUser returns the organizations the user is a member of.
+func (r *OrganizationRepository) FindByUser(userID int) ([]*model.Organization, error) {
+	organizations := []*model.Organization{}
+
+	for id, o := range r.organizations {
+		if _, err := r.store.Membership().Find(id, userID); err == nil {
+			organizations = append(organizations, o)
+		}
+	}
+
+	sort.Slice(organizations, func(i, j int) bool {
+		return organizations[i].ID < organizations[j].ID
+	})
+
+	return organizations, nil
+}
+
+// Delete removes an organization together with its memberships and
+// invitations.
+func (r *OrganizationRepository) Delete(id int) error {
+	if _, ok := r.organizations[id]; !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	delete(r.organizations, id)
+
+	memberships := r.store.Membership().(*MembershipRepository).memberships
+
+	for key := range memberships {
+		if key[0] == id {
+			delete(memberships, key)
+		}
+	}
+
+	invitations := r.store.Invitation().(*InvitationRepository).invitations
+
+	for key, i := range invitations {
+		if i.OrganizationID != nil && *i.OrganizationID == id {
+			delete(invitations, key)
+		}
+	}
+
+	return nil
+}
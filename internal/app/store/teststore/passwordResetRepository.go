@@ -0,0 +1,61 @@
+package teststore
+
+import (
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type PasswordResetRepository struct {
+	store  *Store
+	resets map[int]*model.PasswordReset
+}
+
+func (r *PasswordResetRepository) Create(p *model.PasswordReset) error {
+	if err := p.BeforeCreate(); err != nil {
+		return err
+	}
+
+	p.ID = len(r.resets) + 1
+	p.CreatedAt = time.Now()
+	r.resets[p.ID] = p
+
+	return nil
+}
+
+func (r *PasswordResetRepository) FindByToken(token string) (*model.PasswordReset, error) {
+	hash := model.HashToken(token)
+
+	for _, p := range r.resets {
+		if p.TokenHash == hash {
+			return p, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *PasswordResetRepository) MarkUsed(id int) error {
+	p, ok := r.resets[id]
+
+	if !ok || p.IsUsed() {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	p.UsedAt = &now
+
+	return nil
+}
+
+func (r *PasswordResetRepository) MarkUsedByUser(userID int) error {
+	now := time.Now()
+
+	for _, p := range r.resets {
+		if p.UserID == userID && !p.IsUsed() {
+			p.UsedAt = &now
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	p := model.TestPasswordReset(t, 1)
+
+	assert.NoError(t, s.PasswordReset().Create(p))
+
+	assert.NotEmpty(t, p.Token)
+}
+
+func TestPasswordResetRepository_FindByToken(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.PasswordReset().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	p := model.TestPasswordReset(t, 1)
+
+	s.PasswordReset().Create(p)
+
+	found, err := s.PasswordReset().FindByToken(p.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, p.ID, found.ID)
+}
+
+func TestPasswordResetRepository_MarkUsed(t *testing.T) {
+	s := teststore.New()
+
+	p := model.TestPasswordReset(t, 1)
+
+	s.PasswordReset().Create(p)
+
+	assert.NoError(t, s.PasswordReset().MarkUsed(p.ID))
+
+	assert.EqualError(t, s.PasswordReset().MarkUsed(p.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestPasswordResetRepository_MarkUsedByUser(t *testing.T) {
+	s := teststore.New()
+
+	p1 := model.TestPasswordReset(t, 1)
+	p2 := model.TestPasswordReset(t, 1)
+	other := model.TestPasswordReset(t, 2)
+
+	s.PasswordReset().Create(p1)
+	s.PasswordReset().Create(p2)
+	s.PasswordReset().Create(other)
+
+	assert.NoError(t, s.PasswordReset().MarkUsedByUser(1))
+
+	for _, token := range []string{p1.Token, p2.Token} {
+		found, _ := s.PasswordReset().FindByToken(token)
+		assert.True(t, found.IsUsed())
+	}
+
+	found, _ := s.PasswordReset().FindByToken(other.Token)
+	assert.False(t, found.IsUsed())
+}
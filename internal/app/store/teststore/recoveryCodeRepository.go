@@ -0,0 +1,60 @@
+package teststore
+
+import (
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type RecoveryCodeRepository struct {
+	store  *Store
+	codes  map[int]*model.RecoveryCode
+	lastID int
+}
+
+// Replace discards the recovery codes of a user in favour of codes.
+func (r *RecoveryCodeRepository) Replace(userID int, codes []*model.RecoveryCode) error {
+	for _, c := range codes {
+		if err := c.BeforeCreate(); err != nil {
+			return err
+		}
+	}
+
+	r.DeleteByUser(userID)
+
+	for _, c := range codes {
+		r.lastID++
+		c.ID = r.lastID
+		c.UserID = userID
+		r.codes[c.ID] = c
+	}
+
+	return nil
+}
+
+// Use marks an unused recovery code of a user as used. It returns
+// store.ErrorRecordNotFound when there is no such code.
+func (r *RecoveryCodeRepository) Use(userID int, code string) error {
+	hash := model.HashRecoveryCode(code)
+
+	for _, c := range r.codes {
+		if c.UserID == userID && c.CodeHash == hash && !c.IsUsed() {
+			now := time.Now()
+			c.UsedAt = &now
+
+			return nil
+		}
+	}
+
+	return store.ErrorRecordNotFound
+}
+
+func (r *RecoveryCodeRepository) DeleteByUser(userID int) error {
+	for id, c := range r.codes {
+		if c.UserID == userID {
+			delete(r.codes, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package teststore
+
+import (
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type RefreshTokenRepository struct {
+	store  *Store
+	tokens map[string]*model.RefreshToken
+}
+
+func (r *RefreshTokenRepository) Create(t *model.RefreshToken) error {
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	t.CreatedAt = time.Now()
+	r.tokens[t.ID] = t
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) FindByToken(token string) (*model.RefreshToken, error) {
+	hash := model.HashToken(token)
+
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return t, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *RefreshTokenRepository) MarkUsed(id string) error {
+	t, ok := r.tokens[id]
+
+	if !ok || t.IsUsed() {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	t.UsedAt = &now
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	now := time.Now()
+
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID && !t.IsRevoked() {
+			t.RevokedAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllByUser(userID int) error {
+	now := time.Now()
+
+	for _, t := range r.tokens {
+		if t.UserID == userID && !t.IsRevoked() {
+			t.RevokedAt = &now
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	rt := model.TestRefreshToken(t, 1)
+
+	assert.NoError(t, s.RefreshToken().Create(rt))
+
+	assert.NotEmpty(t, rt.Token)
+}
+
+func TestRefreshTokenRepository_FindByToken(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.RefreshToken().FindByToken("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	rt := model.TestRefreshToken(t, 1)
+
+	s.RefreshToken().Create(rt)
+
+	found, err := s.RefreshToken().FindByToken(rt.Token)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, rt.ID, found.ID)
+}
+
+func TestRefreshTokenRepository_MarkUsed(t *testing.T) {
+	s := teststore.New()
+
+	rt := model.TestRefreshToken(t, 1)
+
+	s.RefreshToken().Create(rt)
+
+	assert.NoError(t, s.RefreshToken().MarkUsed(rt.ID))
+
+	assert.EqualError(t, s.RefreshToken().MarkUsed(rt.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	s := teststore.New()
+
+	first := model.TestRefreshToken(t, 1)
+
+	s.RefreshToken().Create(first)
+
+	second := model.TestRefreshToken(t, 1)
+	second.FamilyID = first.FamilyID
+
+	s.RefreshToken().Create(second)
+
+	assert.NoError(t, s.RefreshToken().RevokeFamily(first.FamilyID))
+
+	found, _ := s.RefreshToken().FindByToken(second.Token)
+
+	assert.True(t, found.IsRevoked())
+}
+
+func TestRefreshTokenRepository_RevokeAllByUser(t *testing.T) {
+	s := teststore.New()
+
+	rt := model.TestRefreshToken(t, 1)
+
+	s.RefreshToken().Create(rt)
+
+	assert.NoError(t, s.RefreshToken().RevokeAllByUser(1))
+
+	found, _ := s.RefreshToken().FindByToken(rt.Token)
+
+	assert.True(t, found.IsRevoked())
+}
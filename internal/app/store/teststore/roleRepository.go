@@ -0,0 +1,114 @@
+package teststore
+
+import (
+	"sort"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type RoleRepository struct {
+	store     *Store
+	roles     map[int]*model.Role
+	userRoles map[int]map[int]bool
+}
+
+func (r *RoleRepository) Create(role *model.Role) error {
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+
+	role.ID = len(r.roles) + 1
+	r.roles[role.ID] = role
+
+	return nil
+}
+
+func (r *RoleRepository) Find(id int) (*model.Role, error) {
+	role, ok := r.roles[id]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return role, nil
+}
+
+func (r *RoleRepository) FindByName(name string) (*model.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+
+	return nil, store.ErrorRecordNotFound
+}
+
+func (r *RoleRepository) GetAll() ([]*model.Role, error) {
+	roles := make([]*model.Role, 0, len(r.roles))
+
+	for _, role := range r.roles {
+		roles = append(roles, role)
+	}
+
+	sortRoles(roles)
+
+	return roles, nil
+}
+
+func (r *RoleRepository) FindByUser(userID int) ([]*model.Role, error) {
+	roles := []*model.Role{}
+
+	for id := range r.userRoles[userID] {
+		roles = append(roles, r.roles[id])
+	}
+
+	sortRoles(roles)
+
+	return roles, nil
+}
+
+// Assign grants a role to a user. Assigning a role twice is not an error.
+func (r *RoleRepository) Assign(userID, roleID int) error {
+	if _, ok := r.roles[roleID]; !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	if r.userRoles[userID] == nil {
+		r.userRoles[userID] = make(map[int]bool)
+	}
+
+	r.userRoles[userID][roleID] = true
+
+	return nil
+}
+
+func (r *RoleRepository) Unassign(userID, roleID int) error {
+	if !r.userRoles[userID][roleID] {
+		return store.ErrorRecordNotFound
+	}
+
+	delete(r.userRoles[userID], roleID)
+
+	return nil
+}
+
+// HasPermission reports whether any role of the user grants permission.
+func (r *RoleRepository) HasPermission(userID int, permission string) (bool, error) {
+	for id := range r.userRoles[userID] {
+		if r.roles[id].HasPermission(permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func sortRoles(roles []*model.Role) {
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+}
@@ -0,0 +1,87 @@
+package teststore_test
+
+import (
+	"testing"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	role := model.TestRole(t)
+
+	assert.NoError(t, s.Role().Create(role))
+
+	assert.NotZero(t, role.ID)
+
+	role = model.TestRole(t)
+	role.Permissions = []string{"everything"}
+
+	assert.Error(t, s.Role().Create(role))
+}
+
+func TestRoleRepository_FindByName(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.Role().FindByName("editor")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	role := model.TestRole(t)
+
+	s.Role().Create(role)
+
+	found, err := s.Role().FindByName("editor")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, role.Permissions, found.Permissions)
+}
+
+func TestRoleRepository_Assign(t *testing.T) {
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	role := model.TestRole(t)
+
+	s.Role().Create(role)
+
+	ok, err := s.Role().HasPermission(u.ID, model.PermissionUsersRead)
+
+	assert.NoError(t, err)
+
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Role().Assign(u.ID, role.ID))
+
+	assert.NoError(t, s.Role().Assign(u.ID, role.ID))
+
+	roles, err := s.Role().FindByUser(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, roles, 1)
+
+	ok, _ = s.Role().HasPermission(u.ID, model.PermissionUsersRead)
+
+	assert.True(t, ok)
+
+	ok, _ = s.Role().HasPermission(u.ID, model.PermissionRolesWrite)
+
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Role().Unassign(u.ID, role.ID))
+
+	assert.EqualError(t, s.Role().Unassign(u.ID, role.ID), store.ErrorRecordNotFound.Error())
+
+	ok, _ = s.Role().HasPermission(u.ID, model.PermissionUsersRead)
+
+	assert.False(t, ok)
+}
@@ -0,0 +1,85 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+)
+
+type SessionRepository struct {
+	store    *Store
+	sessions map[string]*model.Session
+}
+
+func (r *SessionRepository) Create(s *model.Session) error {
+	s.BeforeCreate()
+	s.CreatedAt = time.Now()
+	s.LastSeenAt = s.CreatedAt
+	r.sessions[s.ID] = s
+
+	return nil
+}
+
+func (r *SessionRepository) Find(id string) (*model.Session, error) {
+	s, ok := r.sessions[id]
+
+	if !ok {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return s, nil
+}
+
+func (r *SessionRepository) FindByUser(userID int) ([]*model.Session, error) {
+	sessions := []*model.Session{}
+
+	for _, s := range r.sessions {
+		if s.UserID == userID && !s.IsRevoked() {
+			sessions = append(sessions, s)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Touch(id string) error {
+	s, ok := r.sessions[id]
+
+	if !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	s.LastSeenAt = time.Now()
+
+	return nil
+}
+
+func (r *SessionRepository) Revoke(id string) error {
+	s, ok := r.sessions[id]
+
+	if !ok || s.IsRevoked() {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	s.RevokedAt = &now
+
+	return nil
+}
+
+func (r *SessionRepository) RevokeAllByUser(userID int, except string) error {
+	now := time.Now()
+
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.ID != except && !s.IsRevoked() {
+			s.RevokedAt = &now
+		}
+	}
+
+	return nil
+}
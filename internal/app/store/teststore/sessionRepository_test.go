@@ -0,0 +1,136 @@
+package teststore_test
+
+import (
+	"testing"
+	"time"
+	"webserver/internal/app/model"
+	"webserver/internal/app/store"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository_Create(t *testing.T) {
+	s := teststore.New()
+
+	sess := model.TestSession(t, 1)
+
+	assert.NoError(t, s.Session().Create(sess))
+
+	assert.NotEmpty(t, sess.ID)
+}
+
+func TestSessionRepository_Find(t *testing.T) {
+	s := teststore.New()
+
+	_, err := s.Session().Find("unknown")
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	sess := model.TestSession(t, 1)
+
+	s.Session().Create(sess)
+
+	found, err := s.Session().Find(sess.ID)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, sess.UserID, found.UserID)
+}
+
+func TestSessionRepository_Revoke(t *testing.T) {
+	s := teststore.New()
+
+	sess := model.TestSession(t, 1)
+
+	s.Session().Create(sess)
+
+	assert.NoError(t, s.Session().Revoke(sess.ID))
+
+	found, _ := s.Session().Find(sess.ID)
+
+	assert.True(t, found.IsRevoked())
+
+	assert.EqualError(t, s.Session().Revoke(sess.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestSessionRepository_FindByUser(t *testing.T) {
+	s := teststore.New()
+
+	active := model.TestSession(t, 1)
+	revoked := model.TestSession(t, 1)
+	other := model.TestSession(t, 2)
+
+	s.Session().Create(active)
+	s.Session().Create(revoked)
+	s.Session().Create(other)
+	s.Session().Revoke(revoked.ID)
+
+	sessions, err := s.Session().FindByUser(1)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, sessions, 1)
+
+	assert.Equal(t, active.ID, sessions[0].ID)
+}
+
+func TestSessionRepository_Touch(t *testing.T) {
+	s := teststore.New()
+
+	assert.EqualError(t, s.Session().Touch("unknown"), store.ErrorRecordNotFound.Error())
+
+	sess := model.TestSession(t, 1)
+
+	s.Session().Create(sess)
+
+	assert.NoError(t, s.Session().Touch(sess.ID))
+}
+
+func TestSessionRepository_RevokeAllByUser(t *testing.T) {
+	s := teststore.New()
+
+	current := model.TestSession(t, 1)
+	another := model.TestSession(t, 1)
+
+	s.Session().Create(current)
+	s.Session().Create(another)
+
+	assert.NoError(t, s.Session().RevokeAllByUser(1, current.ID))
+
+	sessions, _ := s.Session().FindByUser(1)
+
+	assert.Len(t, sessions, 1)
+
+	assert.Equal(t, current.ID, sessions[0].ID)
+}
+
+func TestSessionRepository_CreateImpersonated(t *testing.T) {
+	s := teststore.New()
+
+	admin := model.TestUser(t)
+	admin.Email = "admin@example.org"
+
+	u := model.TestUser(t)
+
+	s.User().Create(admin)
+	s.User().Create(u)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	sess := model.TestSession(t, u.ID)
+	sess.ImpersonatorID = &admin.ID
+	sess.ExpiresAt = &expiresAt
+
+	assert.NoError(t, s.Session().Create(sess))
+
+	found, err := s.Session().Find(sess.ID)
+
+	assert.NoError(t, err)
+
+	assert.True(t, found.IsImpersonated())
+
+	assert.Equal(t, admin.ID, *found.ImpersonatorID)
+
+	assert.WithinDuration(t, expiresAt, *found.ExpiresAt, time.Second)
+}
@@ -0,0 +1,149 @@
+package teststore
+
+import (
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// SessionStore is an in-memory twin of sqlstore.SessionStore.
+type SessionStore struct {
+	Codecs   []securecookie.Codec
+	Options  *sessions.Options
+	mu       sync.Mutex
+	sessions map[string]*sessionEntry
+}
+
+type sessionEntry struct {
+	data      string
+	expiresAt time.Time
+}
+
+func NewSessionStore(keyPairs ...[]byte) *SessionStore {
+	s := &SessionStore{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		sessions: make(map[string]*sessionEntry),
+	}
+
+	s.MaxAge(s.Options.MaxAge)
+
+	return s
+}
+
+func (s *SessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *SessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+
+	if err != nil {
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		return session, err
+	}
+
+	s.mu.Lock()
+	e, ok := s.sessions[session.ID]
+	s.mu.Unlock()
+
+	if !ok || e.expiresAt.Before(time.Now()) {
+		session.ID = ""
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, e.data, &session.Values, s.Codecs...); err != nil {
+		return session, err
+	}
+
+	session.IsNew = false
+
+	return session, nil
+}
+
+func (s *SessionStore) Save(r *http.Request, rw http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		s.mu.Lock()
+		delete(s.sessions, session.ID)
+		s.mu.Unlock()
+
+		http.SetCookie(rw, sessions.NewCookie(session.Name(), "", session.Options))
+
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = strings.TrimRight(
+			base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
+	}
+
+	data, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.sessions[session.ID] = &sessionEntry{
+		data:      data,
+		expiresAt: time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second),
+	}
+	s.mu.Unlock()
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(rw, sessions.NewCookie(session.Name(), encoded, session.Options))
+
+	return nil
+}
+
+func (s *SessionStore) MaxAge(age int) {
+	s.Options.MaxAge = age
+
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+func (s *SessionStore) DeleteExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.sessions {
+		if e.expiresAt.Before(time.Now()) {
+			delete(s.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of stored sessions.
+func (s *SessionStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.sessions)
+}
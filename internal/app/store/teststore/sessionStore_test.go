@@ -0,0 +1,73 @@
+package teststore_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webserver/internal/app/store/teststore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionStore_SaveAndLoad(t *testing.T) {
+	s := teststore.NewSessionStore([]byte("secret"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	session, err := s.New(req, "test")
+
+	assert.NoError(t, err)
+
+	assert.True(t, session.IsNew)
+
+	session.Values["user_id"] = 1
+
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, s.Save(req, rec, session))
+
+	assert.Equal(t, 1, s.Len())
+
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", rec.Header().Get("Set-Cookie"))
+
+	session, err = s.New(req, "test")
+
+	assert.NoError(t, err)
+
+	assert.False(t, session.IsNew)
+
+	assert.Equal(t, 1, session.Values["user_id"])
+}
+
+func TestSessionStore_Delete(t *testing.T) {
+	s := teststore.NewSessionStore([]byte("secret"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	session, _ := s.New(req, "test")
+
+	s.Save(req, httptest.NewRecorder(), session)
+
+	session.Options.MaxAge = -1
+
+	assert.NoError(t, s.Save(req, httptest.NewRecorder(), session))
+
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSessionStore_DeleteExpired(t *testing.T) {
+	s := teststore.NewSessionStore([]byte("secret"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	session, _ := s.New(req, "test")
+
+	session.Options.MaxAge = 1
+
+	s.Save(req, httptest.NewRecorder(), session)
+
+	assert.NoError(t, s.DeleteExpired())
+
+	assert.Equal(t, 1, s.Len())
+}
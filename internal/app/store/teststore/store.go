@@ -6,7 +6,23 @@ import (
 )
 
 type Store struct {
-	userRepository *UserRepository
+	userRepository          *UserRepository
+	organizationRepository  *OrganizationRepository
+	membershipRepository    *MembershipRepository
+	invitationRepository    *InvitationRepository
+	sessionRepository       *SessionRepository
+	refreshTokenRepository  *RefreshTokenRepository
+	apiTokenRepository      *APITokenRepository
+	passwordResetRepository *PasswordResetRepository
+	magicLinkRepository     *MagicLinkRepository
+	identityRepository      *IdentityRepository
+	oauthClientRepository   *OAuthClientRepository
+	oauthCodeRepository     *OAuthCodeRepository
+	oauthTokenRepository    *OAuthTokenRepository
+	oauthConsentRepository  *OAuthConsentRepository
+	roleRepository          *RoleRepository
+	recoveryCodeRepository  *RecoveryCodeRepository
+	auditRepository         *AuditRepository
 }
 
 func New() *Store {
@@ -25,3 +41,211 @@ func (s *Store) User() store.UserRepository {
 
 	return s.userRepository
 }
+
+func (s *Store) Organization() store.OrganizationRepository {
+	if s.organizationRepository != nil {
+		return s.organizationRepository
+	}
+
+	s.organizationRepository = &OrganizationRepository{
+		store:         s,
+		organizations: make(map[int]*model.Organization),
+	}
+
+	return s.organizationRepository
+}
+
+func (s *Store) Membership() store.MembershipRepository {
+	if s.membershipRepository != nil {
+		return s.membershipRepository
+	}
+
+	s.membershipRepository = &MembershipRepository{
+		store:       s,
+		memberships: make(map[[2]int]*model.Membership),
+	}
+
+	return s.membershipRepository
+}
+
+func (s *Store) Invitation() store.InvitationRepository {
+	if s.invitationRepository != nil {
+		return s.invitationRepository
+	}
+
+	s.invitationRepository = &InvitationRepository{
+		store:       s,
+		invitations: make(map[int]*model.Invitation),
+	}
+
+	return s.invitationRepository
+}
+
+func (s *Store) Session() store.SessionRepository {
+	if s.sessionRepository != nil {
+		return s.sessionRepository
+	}
+
+	s.sessionRepository = &SessionRepository{
+		store:    s,
+		sessions: make(map[string]*model.Session),
+	}
+
+	return s.sessionRepository
+}
+
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository != nil {
+		return s.refreshTokenRepository
+	}
+
+	s.refreshTokenRepository = &RefreshTokenRepository{
+		store:  s,
+		tokens: make(map[string]*model.RefreshToken),
+	}
+
+	return s.refreshTokenRepository
+}
+
+func (s *Store) APIToken() store.APITokenRepository {
+	if s.apiTokenRepository != nil {
+		return s.apiTokenRepository
+	}
+
+	s.apiTokenRepository = &APITokenRepository{
+		store:  s,
+		tokens: make(map[int]*model.APIToken),
+	}
+
+	return s.apiTokenRepository
+}
+
+func (s *Store) PasswordReset() store.PasswordResetRepository {
+	if s.passwordResetRepository != nil {
+		return s.passwordResetRepository
+	}
+
+	s.passwordResetRepository = &PasswordResetRepository{
+		store:  s,
+		resets: make(map[int]*model.PasswordReset),
+	}
+
+	return s.passwordResetRepository
+}
+
+func (s *Store) MagicLink() store.MagicLinkRepository {
+	if s.magicLinkRepository != nil {
+		return s.magicLinkRepository
+	}
+
+	s.magicLinkRepository = &MagicLinkRepository{
+		store: s,
+		links: make(map[int]*model.MagicLink),
+	}
+
+	return s.magicLinkRepository
+}
+
+func (s *Store) Identity() store.IdentityRepository {
+	if s.identityRepository != nil {
+		return s.identityRepository
+	}
+
+	s.identityRepository = &IdentityRepository{
+		store:      s,
+		identities: make(map[int]*model.Identity),
+	}
+
+	return s.identityRepository
+}
+
+func (s *Store) OAuthClient() store.OAuthClientRepository {
+	if s.oauthClientRepository != nil {
+		return s.oauthClientRepository
+	}
+
+	s.oauthClientRepository = &OAuthClientRepository{
+		store:   s,
+		clients: make(map[int]*model.OAuthClient),
+	}
+
+	return s.oauthClientRepository
+}
+
+func (s *Store) OAuthCode() store.OAuthCodeRepository {
+	if s.oauthCodeRepository != nil {
+		return s.oauthCodeRepository
+	}
+
+	s.oauthCodeRepository = &OAuthCodeRepository{
+		store: s,
+		codes: make(map[int]*model.OAuthCode),
+	}
+
+	return s.oauthCodeRepository
+}
+
+func (s *Store) OAuthToken() store.OAuthTokenRepository {
+	if s.oauthTokenRepository != nil {
+		return s.oauthTokenRepository
+	}
+
+	s.oauthTokenRepository = &OAuthTokenRepository{
+		store:  s,
+		tokens: make(map[int]*model.OAuthToken),
+	}
+
+	return s.oauthTokenRepository
+}
+
+func (s *Store) OAuthConsent() store.OAuthConsentRepository {
+	if s.oauthConsentRepository != nil {
+		return s.oauthConsentRepository
+	}
+
+	s.oauthConsentRepository = &OAuthConsentRepository{
+		store:    s,
+		consents: make(map[[2]int]*model.OAuthConsent),
+	}
+
+	return s.oauthConsentRepository
+}
+
+func (s *Store) Role() store.RoleRepository {
+	if s.roleRepository != nil {
+		return s.roleRepository
+	}
+
+	s.roleRepository = &RoleRepository{
+		store:     s,
+		roles:     make(map[int]*model.Role),
+		userRoles: make(map[int]map[int]bool),
+	}
+
+	return s.roleRepository
+}
+
+func (s *Store) RecoveryCode() store.RecoveryCodeRepository {
+	if s.recoveryCodeRepository != nil {
+		return s.recoveryCodeRepository
+	}
+
+	s.recoveryCodeRepository = &RecoveryCodeRepository{
+		store: s,
+		codes: make(map[int]*model.RecoveryCode),
+	}
+
+	return s.recoveryCodeRepository
+}
+
+func (s *Store) Audit() store.AuditRepository {
+	if s.auditRepository != nil {
+		return s.auditRepository
+	}
+
+	s.auditRepository = &AuditRepository{
+		store: s,
+	}
+
+	return s.auditRepository
+}
@@ -0,0 +1,15 @@
+package teststore_test
+
+import (
+	"os"
+	"testing"
+	"webserver/internal/app/model"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	model.SetPasswordHasher(&model.BcryptHasher{Cost: bcrypt.MinCost})
+
+	os.Exit(m.Run())
+}
@@ -1,13 +1,21 @@
 package teststore
 
 import (
+	"context"
+	"sort"
+	"strings"
+	"time"
 	"webserver/internal/app/model"
 	"webserver/internal/app/store"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
 )
 
 type UserRepository struct {
-	store *Store
-	users map[int]*model.User
+	store  *Store
+	users  map[int]*model.User
+	lastID int
 }
 
 func (r *UserRepository) Create(u *model.User) error {
@@ -19,7 +27,9 @@ func (r *UserRepository) Create(u *model.User) error {
 		return err
 	}
 
-	u.ID = len(r.users) + 1
+	r.lastID++
+	u.ID = r.lastID
+	u.CreatedAt = time.Now()
 	r.users[u.ID] = u
 
 	return nil
@@ -29,16 +39,97 @@ func (r *UserRepository) GetAll() ([]*model.User, error) {
 	arr := make([]*model.User, 0, len(r.users))
 
 	for _, user := range r.users {
-		arr = append(arr, user)
+		if !user.IsDeleted() {
+			arr = append(arr, user)
+		}
 	}
 
 	return arr, nil
 }
 
+// List returns a page of users matching opts, together with the cursor of
+// the next page, which is nil on the last page.
+func (r *UserRepository) List(ctx context.Context, opts store.ListOptions) ([]*model.User, *store.Cursor, error) {
+	if err := opts.Normalize(); err != nil {
+		return nil, nil, err
+	}
+
+	// after reports whether u comes after the position c in the listing.
+	after := func(u *model.User, c *store.Cursor) bool {
+		n := compareUser(u, opts.Sort, c)
+
+		if opts.Desc {
+			return n < 0
+		}
+
+		return n > 0
+	}
+
+	users := []*model.User{}
+
+	for _, u := range r.users {
+		if !userMatches(u, opts) {
+			continue
+		}
+
+		if opts.Cursor != nil && !after(u, opts.Cursor) {
+			continue
+		}
+
+		users = append(users, u)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return after(users[j], store.NewUserCursor(users[i], opts.Sort))
+	})
+
+	if len(users) <= opts.Limit {
+		return users, nil, nil
+	}
+
+	users = users[:opts.Limit]
+
+	return users, store.NewUserCursor(users[len(users)-1], opts.Sort), nil
+}
+
+// Count returns the number of users matching opts. The sort order, limit and
+// cursor of opts are ignored.
+func (r *UserRepository) Count(ctx context.Context, opts store.ListOptions) (int, error) {
+	n := 0
+
+	for _, u := range r.users {
+		if userMatches(u, opts) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// userMatches reports whether u passes the filters of opts.
+func userMatches(u *model.User, opts store.ListOptions) bool {
+	switch {
+	case opts.OnlyDeleted && !u.IsDeleted():
+		return false
+	case u.IsDeleted() && !opts.IncludeDeleted && !opts.OnlyDeleted:
+		return false
+	case opts.ID != 0 && u.ID != opts.ID:
+		return false
+	case opts.Email != "" && !strings.Contains(strings.ToLower(u.Email), strings.ToLower(opts.Email)):
+		return false
+	case !opts.CreatedAfter.IsZero() && u.CreatedAt.Before(opts.CreatedAfter):
+		return false
+	case !opts.CreatedBefore.IsZero() && !u.CreatedAt.Before(opts.CreatedBefore):
+		return false
+	}
+
+	return true
+}
+
 func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 
 	for _, u := range r.users {
-		if u.Email == email {
+		if u.Email == email && !u.IsDeleted() {
 			return u, nil
 		}
 	}
@@ -50,6 +141,17 @@ func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 func (r *UserRepository) Find(id int) (*model.User, error) {
 	u, ok := r.users[id]
 
+	if !ok || u.IsDeleted() {
+		return nil, store.ErrorRecordNotFound
+	}
+
+	return u, nil
+}
+
+// FindIncludingDeleted is Find for users that may have been soft deleted.
+func (r *UserRepository) FindIncludingDeleted(id int) (*model.User, error) {
+	u, ok := r.users[id]
+
 	if !ok {
 		return nil, store.ErrorRecordNotFound
 	}
@@ -57,3 +159,189 @@ func (r *UserRepository) Find(id int) (*model.User, error) {
 	return u, nil
 }
 
+// UpdateEmail persists the email address of u together with its
+// verification state. It fails with store.ErrorEmailTaken if another user has
+// the address.
+func (r *UserRepository) UpdateEmail(u *model.User) error {
+	if err := validation.Validate(u.Email, validation.Required, is.Email); err != nil {
+		return err
+	}
+
+	stored, ok := r.users[u.ID]
+
+	if !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	if other, err := r.FindByEmail(u.Email); err == nil && other.ID != u.ID {
+		return store.ErrorEmailTaken
+	}
+
+	stored.Email = u.Email
+	stored.EmailVerifiedAt = u.EmailVerifiedAt
+
+	return nil
+}
+
+// UpdatePassword validates and encrypts u.Password and persists it as the new
+// password of u.
+func (r *UserRepository) UpdatePassword(u *model.User) error {
+	if err := u.SetPassword(u.Password); err != nil {
+		return err
+	}
+
+	stored, ok := r.users[u.ID]
+
+	if !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	stored.EncryptedPassword = u.EncryptedPassword
+
+	return nil
+}
+
+// Update validates u and persists its email, verification state and, when
+// u.Password is set, a newly encrypted password.
+func (r *UserRepository) Update(u *model.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.users[u.ID]
+
+	if !ok || stored.IsDeleted() {
+		return store.ErrorRecordNotFound
+	}
+
+	stored.Email = u.Email
+	stored.EncryptedPassword = u.EncryptedPassword
+	stored.EmailVerifiedAt = u.EmailVerifiedAt
+
+	return nil
+}
+
+func (r *UserRepository) Delete(id int) error {
+	if _, ok := r.users[id]; !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	delete(r.users, id)
+
+	return nil
+}
+
+// SoftDelete marks the user as deleted, hiding it from Find and FindByEmail
+// until it is restored.
+func (r *UserRepository) SoftDelete(id int) error {
+	u, ok := r.users[id]
+
+	if !ok || u.IsDeleted() {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	u.DeletedAt = &now
+
+	return nil
+}
+
+// Restore reverts a previous SoftDelete. It fails with store.ErrorEmailTaken
+// if another user has taken the email address in the meantime.
+func (r *UserRepository) Restore(id int) error {
+	u, ok := r.users[id]
+
+	if !ok || !u.IsDeleted() {
+		return store.ErrorRecordNotFound
+	}
+
+	if _, err := r.FindByEmail(u.Email); err == nil {
+		return store.ErrorEmailTaken
+	}
+
+	u.DeletedAt = nil
+
+	return nil
+}
+
+// compareUser compares u with the position c of a listing sorted by sort,
+// breaking ties by ID.
+func compareUser(u *model.User, sort string, c *store.Cursor) int {
+	n := 0
+
+	switch sort {
+	case store.SortByEmail:
+		n = strings.Compare(u.Email, c.Value)
+	case store.SortByCreatedAt:
+		t, _ := time.Parse(time.RFC3339Nano, c.Value)
+
+		switch {
+		case u.CreatedAt.Before(t):
+			n = -1
+		case u.CreatedAt.After(t):
+			n = 1
+		}
+	}
+
+	if n != 0 {
+		return n
+	}
+
+	switch {
+	case u.ID < c.ID:
+		return -1
+	case u.ID > c.ID:
+		return 1
+	}
+
+	return 0
+}
+
+// UpdateTOTP persists the TOTP enrollment state of u.
+func (r *UserRepository) UpdateTOTP(u *model.User) error {
+	stored, ok := r.users[u.ID]
+
+	if !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	stored.EncryptedTOTPSecret = u.EncryptedTOTPSecret
+	stored.TOTPEnabledAt = u.TOTPEnabledAt
+	stored.TOTPLastStep = u.TOTPLastStep
+
+	return nil
+}
+
+// RevokeTokens records that every access token issued to the user so far is
+// revoked. Deleted users are included.
+func (r *UserRepository) RevokeTokens(id int) error {
+	u, ok := r.users[id]
+
+	if !ok {
+		return store.ErrorRecordNotFound
+	}
+
+	now := time.Now()
+	u.TokensRevokedAt = &now
+
+	return nil
+}
+
+// UseTOTPStep records step as the last TOTP step used by the user. It fails
+// with store.ErrorRecordNotFound if the step, or a later one, has already been
+// used.
+func (r *UserRepository) UseTOTPStep(id int, step int64) error {
+	stored, ok := r.users[id]
+
+	if !ok || stored.TOTPLastStep >= step {
+		return store.ErrorRecordNotFound
+	}
+
+	stored.TOTPLastStep = step
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package teststore_test
 
 import (
+	"context"
 	"testing"
+	"time"
 	"webserver/internal/app/model"
 	"webserver/internal/app/store"
 	"webserver/internal/app/store/teststore"
@@ -73,3 +75,297 @@ func TestUserRepository_Find(t *testing.T) {
 
 	assert.NotNil(t, u)
 }
+
+func TestUserRepository_UpdateEmail(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	now := time.Now()
+
+	updated := &model.User{
+		ID:              u.ID,
+		Email:           "updated@gmail.com",
+		EmailVerifiedAt: &now,
+	}
+
+	assert.NoError(t, s.User().UpdateEmail(updated))
+
+	u, _ = s.User().Find(u.ID)
+
+	assert.Equal(t, "updated@gmail.com", u.Email)
+
+	assert.True(t, u.IsEmailVerified())
+
+	updated.Email = "invalid"
+
+	assert.Error(t, s.User().UpdateEmail(updated))
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	updated := &model.User{
+		ID:       u.ID,
+		Email:    u.Email,
+		Password: "short",
+	}
+
+	assert.Error(t, s.User().UpdatePassword(updated))
+
+	updated.Password = "new-password"
+
+	assert.NoError(t, s.User().UpdatePassword(updated))
+
+	u, _ = s.User().Find(u.ID)
+
+	assert.True(t, u.ComparePassword("new-password"))
+}
+
+func TestUserRepository_Update(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	u.Email = "invalid"
+
+	assert.Error(t, s.User().Update(u))
+
+	u.Email = "updated@gmail.com"
+	u.Password = "new-password"
+
+	assert.NoError(t, s.User().Update(u))
+
+	u, _ = s.User().Find(u.ID)
+
+	assert.Equal(t, "updated@gmail.com", u.Email)
+
+	assert.True(t, u.ComparePassword("new-password"))
+
+	assert.EqualError(t, s.User().Update(&model.User{ID: 42, Email: "e@gmail.com", Password: "password"}), store.ErrorRecordNotFound.Error())
+}
+
+func TestUserRepository_Delete(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	assert.NoError(t, s.User().Delete(u.ID))
+
+	_, err := s.User().Find(u.ID)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	assert.EqualError(t, s.User().Delete(u.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	assert.NoError(t, s.User().SoftDelete(u.ID))
+
+	_, err := s.User().Find(u.ID)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	_, err = s.User().FindByEmail(u.Email)
+
+	assert.EqualError(t, err, store.ErrorRecordNotFound.Error())
+
+	deleted, err := s.User().FindIncludingDeleted(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.True(t, deleted.IsDeleted())
+
+	assert.EqualError(t, s.User().SoftDelete(u.ID), store.ErrorRecordNotFound.Error())
+
+	assert.NoError(t, s.User().Restore(u.ID))
+
+	_, err = s.User().Find(u.ID)
+
+	assert.NoError(t, err)
+
+	assert.EqualError(t, s.User().Restore(u.ID), store.ErrorRecordNotFound.Error())
+}
+
+func TestUserRepository_RestoreEmailTaken(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+	s.User().SoftDelete(u.ID)
+
+	signup := model.TestUser(t)
+
+	assert.NoError(t, s.User().Create(signup))
+
+	assert.EqualError(t, s.User().Restore(u.ID), store.ErrorEmailTaken.Error())
+}
+
+func TestUserRepository_UpdateEmailTaken(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	other := model.TestUser(t)
+	other.Email = "other@gmail.com"
+
+	s.User().Create(other)
+
+	assert.EqualError(t, s.User().UpdateEmail(&model.User{ID: other.ID, Email: u.Email}), store.ErrorEmailTaken.Error())
+}
+
+func TestUserRepository_List(t *testing.T) {
+
+	s := teststore.New()
+
+	base := time.Now().Add(-24 * time.Hour)
+
+	for i, email := range []string{"carol@example.org", "alice@example.org", "eve@example.org", "bob@example.org", "dave@example.org"} {
+		u := model.TestUser(t)
+		u.Email = email
+		s.User().Create(u)
+		u.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+	}
+
+	list := func(opts store.ListOptions) []string {
+		emails := []string{}
+
+		for {
+			users, next, err := s.User().List(context.Background(), opts)
+
+			assert.NoError(t, err)
+
+			for _, u := range users {
+				emails = append(emails, u.Email)
+			}
+
+			if next == nil {
+				return emails
+			}
+
+			opts.Cursor = next
+		}
+	}
+
+	assert.Equal(t, []string{
+		"carol@example.org",
+		"alice@example.org",
+		"eve@example.org",
+		"bob@example.org",
+		"dave@example.org",
+	}, list(store.ListOptions{Limit: 2}))
+
+	assert.Equal(t, []string{
+		"eve@example.org",
+		"dave@example.org",
+		"carol@example.org",
+		"bob@example.org",
+		"alice@example.org",
+	}, list(store.ListOptions{Sort: store.SortByEmail, Desc: true, Limit: 2}))
+
+	assert.Equal(t, []string{"carol@example.org"}, list(store.ListOptions{Email: "CAR"}))
+
+	assert.Equal(t, []string{"eve@example.org", "alice@example.org"}, list(store.ListOptions{
+		CreatedAfter:  base.Add(time.Hour),
+		CreatedBefore: base.Add(3 * time.Hour),
+		Sort:          store.SortByCreatedAt,
+		Desc:          true,
+		Limit:         1,
+	}))
+
+	s.User().SoftDelete(1)
+
+	assert.Len(t, list(store.ListOptions{}), 4)
+
+	assert.Len(t, list(store.ListOptions{IncludeDeleted: true}), 5)
+
+	assert.Equal(t, []string{"carol@example.org"}, list(store.ListOptions{OnlyDeleted: true}))
+
+	assert.Equal(t, []string{"alice@example.org"}, list(store.ListOptions{ID: 2}))
+
+	_, _, err := s.User().List(context.Background(), store.ListOptions{Sort: "password"})
+
+	assert.EqualError(t, err, store.ErrorInvalidSort.Error())
+}
+
+func TestUserRepository_Count(t *testing.T) {
+
+	s := teststore.New()
+
+	for _, email := range []string{"alice@example.org", "bob@example.org", "carol@example.net"} {
+		u := model.TestUser(t)
+		u.Email = email
+		s.User().Create(u)
+	}
+
+	s.User().SoftDelete(1)
+
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		opts     store.ListOptions
+		expected int
+	}{
+		{store.ListOptions{}, 2},
+		{store.ListOptions{IncludeDeleted: true}, 3},
+		{store.ListOptions{OnlyDeleted: true}, 1},
+		{store.ListOptions{Email: "example.org", IncludeDeleted: true, Limit: 1}, 2},
+		{store.ListOptions{ID: 2}, 1},
+	} {
+		n, err := s.User().Count(ctx, tc.opts)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, n)
+	}
+}
+
+func TestUserRepository_UpdateTOTP(t *testing.T) {
+
+	s := teststore.New()
+
+	u := model.TestUser(t)
+
+	s.User().Create(u)
+
+	now := time.Now()
+
+	u.EncryptedTOTPSecret = "sealed"
+	u.TOTPEnabledAt = &now
+	u.TOTPLastStep = 42
+
+	assert.NoError(t, s.User().UpdateTOTP(u))
+
+	u, _ = s.User().Find(u.ID)
+
+	assert.Equal(t, "sealed", u.EncryptedTOTPSecret)
+
+	assert.True(t, u.IsTOTPEnabled())
+
+	assert.Equal(t, int64(42), u.TOTPLastStep)
+}
@@ -0,0 +1,156 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. Expired entries are dropped
+// as they are accessed, and by DeleteExpired for keys that are not accessed
+// again.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entry(key)
+
+	if e == nil {
+		return &Entry{}, nil
+	}
+
+	entry := e.Entry
+
+	return &entry, nil
+}
+
+func (s *MemoryStore) Fail(key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entry(key)
+
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+
+	e.Failures++
+
+	if expiresAt := s.now().Add(window); expiresAt.After(e.expiresAt) {
+		e.expiresAt = expiresAt
+	}
+
+	return e.Failures, nil
+}
+
+func (s *MemoryStore) Block(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entry(key)
+
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+
+	e.BlockedUntil = until
+
+	if until.After(e.expiresAt) {
+		e.expiresAt = until
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
+
+// DeleteExpired drops every expired entry.
+func (s *MemoryStore) DeleteExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+
+	return nil
+}
+
+// StartCleanup runs DeleteExpired every interval until quit is closed or
+// written to. StopCleanup should be used to shut the routine down.
+func (s *MemoryStore) StartCleanup(interval time.Duration) (chan<- struct{}, <-chan struct{}) {
+	quit, done := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+
+		defer func() {
+			ticker.Stop()
+			close(done)
+		}()
+
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				s.DeleteExpired()
+			}
+		}
+	}()
+
+	return quit, done
+}
+
+// StopCleanup stops the routine started by StartCleanup and waits for it to
+// finish.
+func (s *MemoryStore) StopCleanup(quit chan<- struct{}, done <-chan struct{}) {
+	quit <- struct{}{}
+	<-done
+}
+
+// entry returns the live entry of key. It must be called with mu held.
+func (s *MemoryStore) entry(key string) *memoryEntry {
+	e, ok := s.entries[key]
+
+	if !ok {
+		return nil
+	}
+
+	if !s.now().Before(e.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,60 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	now := time.Now()
+
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	n, _ := s.Fail("key", time.Minute)
+
+	assert.Equal(t, 1, n)
+
+	now = now.Add(30 * time.Second)
+
+	n, _ = s.Fail("key", time.Minute)
+
+	assert.Equal(t, 2, n)
+
+	s.Block("key", now.Add(time.Hour))
+
+	now = now.Add(2 * time.Minute)
+
+	e, _ := s.Get("key")
+
+	assert.Equal(t, 2, e.Failures)
+
+	now = now.Add(time.Hour)
+
+	e, _ = s.Get("key")
+
+	assert.Zero(t, e.Failures)
+
+	n, _ = s.Fail("key", time.Minute)
+
+	assert.Equal(t, 1, n)
+}
+
+func TestMemoryStore_DeleteExpired(t *testing.T) {
+	now := time.Now()
+
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	s.Fail("short", time.Minute)
+	s.Fail("long", time.Hour)
+
+	now = now.Add(2 * time.Minute)
+
+	assert.NoError(t, s.DeleteExpired())
+
+	assert.Len(t, s.entries, 1)
+	assert.Contains(t, s.entries, "long")
+}
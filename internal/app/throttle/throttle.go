@@ -0,0 +1,121 @@
+// Package throttle slows down repeated failures, such as wrong passwords,
+// with an exponential backoff and an optional lockout.
+package throttle
+
+import "time"
+
+// Entry is the state of a throttled key.
+type Entry struct {
+	Failures     int
+	BlockedUntil time.Time
+}
+
+// Store keeps failure counters. Implementations must be safe for concurrent
+// use.
+type Store interface {
+	// Get returns the entry of key, or a zero entry when key is unknown or
+	// has expired.
+	Get(key string) (*Entry, error)
+	// Fail increments the failure count of key and returns it. The count
+	// starts over once key has seen no failure for window.
+	Fail(key string, window time.Duration) (int, error)
+	// Block rejects key until the given time.
+	Block(key string, until time.Time) error
+	Reset(key string) error
+}
+
+// Policy decides how long a key is blocked after a number of failures.
+type Policy struct {
+	// FreeAttempts is the number of failures allowed before backoff starts.
+	FreeAttempts int
+	// BaseDelay is doubled with every failure past FreeAttempts, up to
+	// MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutThreshold is the number of failures after which the key is
+	// blocked for LockoutDuration. Zero disables the lockout.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// Window is how long failures are remembered.
+	Window time.Duration
+}
+
+// Delay returns how long a key is blocked after failures failures.
+func (p Policy) Delay(failures int) time.Duration {
+	if p.LockoutThreshold > 0 && failures >= p.LockoutThreshold {
+		return p.LockoutDuration
+	}
+
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	d := p.BaseDelay
+
+	for i := p.FreeAttempts + 1; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+
+	if d > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return d
+}
+
+// Throttler applies a policy to the keys of one kind, which share a prefix
+// in the store.
+type Throttler struct {
+	store  Store
+	prefix string
+	policy Policy
+	now    func() time.Time
+}
+
+func New(store Store, prefix string, policy Policy) *Throttler {
+	return &Throttler{
+		store:  store,
+		prefix: prefix,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Check returns how long key has to wait before its next attempt, which is
+// zero when it is not blocked.
+func (t *Throttler) Check(key string) (time.Duration, error) {
+	e, err := t.store.Get(t.prefix + key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if wait := e.BlockedUntil.Sub(t.now()); wait > 0 {
+		return wait, nil
+	}
+
+	return 0, nil
+}
+
+// Fail records a failure of key and blocks it according to the policy. It
+// returns how long key is blocked.
+func (t *Throttler) Fail(key string) (time.Duration, error) {
+	n, err := t.store.Fail(t.prefix+key, t.policy.Window)
+
+	if err != nil {
+		return 0, err
+	}
+
+	d := t.policy.Delay(n)
+
+	if d == 0 {
+		return 0, nil
+	}
+
+	return d, t.store.Block(t.prefix+key, t.now().Add(d))
+}
+
+// Reset forgets all failures of key and lifts any block.
+func (t *Throttler) Reset(key string) error {
+	return t.store.Reset(t.prefix + key)
+}
@@ -0,0 +1,91 @@
+package throttle_test
+
+import (
+	"testing"
+	"time"
+	"webserver/internal/app/throttle"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	p := throttle.Policy{
+		FreeAttempts:     2,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  time.Hour,
+	}
+
+	testCases := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 1, expected: 0},
+		{failures: 2, expected: 0},
+		{failures: 3, expected: time.Second},
+		{failures: 4, expected: 2 * time.Second},
+		{failures: 5, expected: 4 * time.Second},
+		{failures: 6, expected: 5 * time.Second},
+		{failures: 9, expected: 5 * time.Second},
+		{failures: 10, expected: time.Hour},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, p.Delay(tc.failures), "failures: %d", tc.failures)
+	}
+
+	p.LockoutThreshold = 0
+
+	assert.Equal(t, 5*time.Second, p.Delay(100))
+}
+
+func TestThrottler(t *testing.T) {
+	s := throttle.NewMemoryStore()
+
+	accounts := throttle.New(s, "account:", throttle.Policy{
+		FreeAttempts:     1,
+		BaseDelay:        time.Minute,
+		MaxDelay:         time.Hour,
+		LockoutThreshold: 3,
+		LockoutDuration:  24 * time.Hour,
+		Window:           time.Hour,
+	})
+
+	ips := throttle.New(s, "ip:", throttle.Policy{
+		FreeAttempts: 100,
+		Window:       time.Hour,
+	})
+
+	wait, err := accounts.Check("user")
+
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+
+	d, err := accounts.Fail("user")
+
+	assert.NoError(t, err)
+	assert.Zero(t, d)
+
+	d, _ = accounts.Fail("user")
+
+	assert.Equal(t, time.Minute, d)
+
+	wait, _ = accounts.Check("user")
+
+	assert.True(t, wait > 0 && wait <= time.Minute)
+
+	d, _ = accounts.Fail("user")
+
+	assert.Equal(t, 24*time.Hour, d)
+
+	wait, _ = ips.Check("user")
+
+	assert.Zero(t, wait)
+
+	assert.NoError(t, accounts.Reset("user"))
+
+	wait, _ = accounts.Check("user")
+
+	assert.Zero(t, wait)
+}
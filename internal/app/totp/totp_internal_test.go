@@ -0,0 +1,61 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors of RFC 6238, appendix B, for SHA1.
+func TestCode_RFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	testCases := []struct {
+		time     int64
+		expected string
+	}{
+		{time: 59, expected: "94287082"},
+		{time: 1111111109, expected: "07081804"},
+		{time: 1111111111, expected: "14050471"},
+		{time: 1234567890, expected: "89005924"},
+		{time: 2000000000, expected: "69279037"},
+		{time: 20000000000, expected: "65353130"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, code(key, uint64(Step(time.Unix(tc.time, 0))), 8))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+
+	assert.NoError(t, err)
+
+	now := time.Now()
+
+	c, err := Code(secret, Step(now.Add(-Period)))
+
+	assert.NoError(t, err)
+
+	step, ok := Validate(secret, c, now, 1)
+
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+
+	_, ok = Validate(secret, c, now, 0)
+
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 1)
+
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	assert.Equal(
+		t,
+		"otpauth://totp/webserver:user@example.org?algorithm=SHA1&digits=6&issuer=webserver&period=30&secret=JBSWY3DPEHPK3PXP",
+		URI("webserver", "user@example.org", "JBSWY3DPEHPK3PXP"))
+}
@@ -0,0 +1,6 @@
+CREATE TABLE user_sessions (
+  id varchar not null primary key,
+  user_id bigint not null references users (id) on delete cascade,
+  created_at timestamptz not null default now(),
+  revoked_at timestamptz
+);
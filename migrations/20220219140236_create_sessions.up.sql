@@ -0,0 +1,8 @@
+CREATE TABLE sessions (
+  id varchar not null primary key,
+  data text not null,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null
+);
+
+CREATE INDEX sessions_expires_at_idx ON sessions (expires_at);
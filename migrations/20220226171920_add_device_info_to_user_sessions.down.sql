@@ -0,0 +1,6 @@
+DROP INDEX user_sessions_user_id_idx;
+
+ALTER TABLE user_sessions
+  DROP COLUMN last_seen_at,
+  DROP COLUMN ip,
+  DROP COLUMN user_agent;
@@ -0,0 +1,6 @@
+ALTER TABLE user_sessions
+  ADD COLUMN last_seen_at timestamptz not null default now(),
+  ADD COLUMN ip varchar not null default '',
+  ADD COLUMN user_agent varchar not null default '';
+
+CREATE INDEX user_sessions_user_id_idx ON user_sessions (user_id);
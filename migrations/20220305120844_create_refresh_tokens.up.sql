@@ -0,0 +1,13 @@
+CREATE TABLE refresh_tokens (
+  id varchar not null primary key,
+  user_id bigint not null references users (id) on delete cascade,
+  family_id varchar not null,
+  token_hash varchar not null unique,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  used_at timestamptz,
+  revoked_at timestamptz
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE api_tokens (
+  id bigserial not null primary key,
+  user_id bigint not null references users (id) on delete cascade,
+  name varchar not null,
+  scopes text[] not null default '{}',
+  token_hash varchar not null unique,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz,
+  last_used_at timestamptz
+);
+
+CREATE INDEX api_tokens_user_id_idx ON api_tokens (user_id);
@@ -0,0 +1,8 @@
+CREATE TABLE password_resets (
+  id bigserial not null primary key,
+  user_id bigint not null references users (id) on delete cascade,
+  token_hash varchar not null unique,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  used_at timestamptz
+);
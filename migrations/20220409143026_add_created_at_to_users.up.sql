@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN created_at timestamptz not null default now();
+
+CREATE INDEX users_created_at_idx ON users (created_at);
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE roles (
+  id bigserial not null primary key,
+  name varchar not null unique
+);
+
+CREATE TABLE role_permissions (
+  role_id bigint not null references roles (id) on delete cascade,
+  permission varchar not null,
+  primary key (role_id, permission)
+);
+
+CREATE TABLE user_roles (
+  user_id bigint not null references users (id) on delete cascade,
+  role_id bigint not null references roles (id) on delete cascade,
+  primary key (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles (role_id);
+
+INSERT INTO roles (name) VALUES ('admin'), ('support');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, unnest(array['users:read', 'users:write', 'roles:read', 'roles:write']) FROM roles WHERE name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, unnest(array['users:read', 'roles:read']) FROM roles WHERE name = 'support';
@@ -0,0 +1 @@
+DROP TABLE throttles;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE throttles (
+  key varchar not null primary key,
+  failures integer not null,
+  blocked_until timestamptz,
+  expires_at timestamptz not null
+);
+
+CREATE INDEX throttles_expires_at_idx ON throttles (expires_at);
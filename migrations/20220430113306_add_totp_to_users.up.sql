@@ -0,0 +1,12 @@
+ALTER TABLE users
+  ADD COLUMN encrypted_totp_secret varchar not null default '',
+  ADD COLUMN totp_enabled_at timestamptz,
+  ADD COLUMN totp_last_step bigint not null default 0;
+
+CREATE TABLE recovery_codes (
+  id bigserial not null primary key,
+  user_id bigint not null references users (id) on delete cascade,
+  code_hash varchar not null,
+  used_at timestamptz,
+  unique (user_id, code_hash)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE magic_links (
+  id bigserial not null primary key,
+  user_id bigint not null references users (id) on delete cascade,
+  token_hash varchar not null unique,
+  nonce_hash varchar not null,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  used_at timestamptz
+);
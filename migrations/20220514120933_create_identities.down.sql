@@ -0,0 +1 @@
+DROP TABLE identities;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE identities (
+  id bigserial not null primary key,
+  user_id bigint not null references users (id) on delete cascade,
+  provider varchar not null,
+  subject varchar not null,
+  email varchar not null default '',
+  created_at timestamptz not null default now(),
+  unique (provider, subject)
+);
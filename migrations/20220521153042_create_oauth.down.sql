@@ -0,0 +1,6 @@
+DELETE FROM role_permissions WHERE permission IN ('clients:read', 'clients:write');
+
+DROP TABLE oauth_consents;
+DROP TABLE oauth_tokens;
+DROP TABLE oauth_codes;
+DROP TABLE oauth_clients;
\ No newline at end of file
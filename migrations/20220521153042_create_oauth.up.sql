@@ -0,0 +1,45 @@
+CREATE TABLE oauth_clients (
+  id bigserial not null primary key,
+  client_id varchar not null unique,
+  secret_hash varchar not null default '',
+  name varchar not null,
+  redirect_uris text[] not null default '{}',
+  scopes text[] not null default '{}',
+  confidential boolean not null default false,
+  user_id bigint not null references users (id) on delete cascade,
+  created_at timestamptz not null default now()
+);
+
+CREATE TABLE oauth_codes (
+  id bigserial not null primary key,
+  client_id bigint not null references oauth_clients (id) on delete cascade,
+  user_id bigint not null references users (id) on delete cascade,
+  code_hash varchar not null unique,
+  redirect_uri varchar not null,
+  scopes text[] not null default '{}',
+  challenge varchar not null,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  used_at timestamptz
+);
+
+CREATE TABLE oauth_tokens (
+  id bigserial not null primary key,
+  client_id bigint not null references oauth_clients (id) on delete cascade,
+  user_id bigint not null references users (id) on delete cascade,
+  scopes text[] not null default '{}',
+  token_hash varchar not null unique,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  revoked_at timestamptz
+);
+
+CREATE TABLE oauth_consents (
+  user_id bigint not null references users (id) on delete cascade,
+  client_id bigint not null references oauth_clients (id) on delete cascade,
+  scopes text[] not null default '{}',
+  primary key (user_id, client_id)
+);
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, unnest(array['clients:read', 'clients:write']) FROM roles WHERE name = 'admin';
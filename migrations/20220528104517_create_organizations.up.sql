@@ -0,0 +1,15 @@
+CREATE TABLE organizations (
+  id bigserial not null primary key,
+  name varchar not null,
+  created_at timestamptz not null default now()
+);
+
+CREATE TABLE memberships (
+  organization_id bigint not null references organizations (id) on delete cascade,
+  user_id bigint not null references users (id) on delete cascade,
+  role varchar not null,
+  created_at timestamptz not null default now(),
+  primary key (organization_id, user_id)
+);
+
+CREATE INDEX memberships_user_id_idx ON memberships (user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE invitations (
+  id bigserial not null primary key,
+  organization_id bigint references organizations (id) on delete cascade,
+  email varchar not null,
+  role varchar not null default '',
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  accepted_at timestamptz,
+  revoked_at timestamptz
+);
+
+CREATE INDEX invitations_organization_id_idx ON invitations (organization_id);
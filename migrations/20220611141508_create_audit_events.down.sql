@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DROP TABLE audit_events;
+DROP FUNCTION audit_events_append_only();
\ No newline at end of file
@@ -0,0 +1,29 @@
+CREATE TABLE audit_events (
+  id bigserial not null primary key,
+  action varchar not null,
+  actor_id bigint,
+  target_id bigint,
+  ip varchar not null default '',
+  request_id varchar not null default '',
+  data jsonb not null default '{}',
+  created_at timestamptz not null,
+  prev_hash varchar not null,
+  hash varchar not null unique
+);
+
+CREATE INDEX audit_events_actor_id_idx ON audit_events (actor_id);
+CREATE INDEX audit_events_target_id_idx ON audit_events (target_id);
+
+-- Events are never changed or removed. The hash chain detects changes made
+-- by anyone who gets around this.
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE PROCEDURE audit_events_append_only();
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'audit:read' FROM roles WHERE name = 'admin';
@@ -0,0 +1,5 @@
+DELETE FROM role_permissions WHERE permission = 'users:impersonate';
+
+ALTER TABLE user_sessions
+  DROP COLUMN impersonator_id,
+  DROP COLUMN expires_at;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE user_sessions
+  ADD COLUMN impersonator_id bigint references users (id) on delete cascade,
+  ADD COLUMN expires_at timestamptz;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'users:impersonate' FROM roles WHERE name = 'admin';
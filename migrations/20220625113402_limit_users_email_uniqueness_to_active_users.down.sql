@@ -0,0 +1,3 @@
+DROP INDEX users_email_key;
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+
+CREATE UNIQUE INDEX users_email_key ON users (email) WHERE deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE users
+  DROP COLUMN tokens_revoked_at;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE users
+  ADD COLUMN tokens_revoked_at timestamptz;
\ No newline at end of file